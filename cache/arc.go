@@ -0,0 +1,174 @@
+//go:build go1.18
+// +build go1.18
+
+package cache
+
+import "container/list"
+
+// arc keeps resident entries seen once (t1) apart from those seen again
+// (t2), and remembers the keys recently evicted from each (the ghost lists
+// b1 and b2). A miss on a ghost key shows which list was evicted from too
+// eagerly, and shifts the target size p of t1 accordingly.
+type arc[K comparable, V any] struct {
+	maxEntries     int
+	p              int
+	t1, t2, b1, b2 *list.List // *arcNode, most recent first
+	nodes          map[K]*list.Element
+}
+
+type arcNode[K comparable, V any] struct {
+	entry *entry[K, V] // only the key is kept in the ghost lists
+	in    *list.List
+}
+
+func newARC[K comparable, V any](maxEntries int) *arc[K, V] {
+	return &arc[K, V]{
+		maxEntries: maxEntries,
+		t1:         list.New(),
+		t2:         list.New(),
+		b1:         list.New(),
+		b2:         list.New(),
+		nodes:      make(map[K]*list.Element),
+	}
+}
+
+func (a *arc[K, V]) resident(key K) (*list.Element, bool) {
+	element, found := a.nodes[key]
+	if !found {
+		return nil, false
+	}
+	in := element.Value.(*arcNode[K, V]).in
+	return element, in == a.t1 || in == a.t2
+}
+
+func (a *arc[K, V]) get(key K) (*entry[K, V], bool) {
+	element, found := a.resident(key)
+	if !found {
+		return nil, false
+	}
+	node := a.move(element, a.t2)
+	return node.entry, true
+}
+
+func (a *arc[K, V]) peek(key K) (*entry[K, V], bool) {
+	element, found := a.resident(key)
+	if !found {
+		return nil, false
+	}
+	return element.Value.(*arcNode[K, V]).entry, true
+}
+
+// move puts the node of element at the front of list to.
+func (a *arc[K, V]) move(element *list.Element, to *list.List) *arcNode[K, V] {
+	node := element.Value.(*arcNode[K, V])
+	node.in.Remove(element)
+	node.in = to
+	a.nodes[node.entry.key] = to.PushFront(node)
+	return node
+}
+
+func (a *arc[K, V]) push(e *entry[K, V], to *list.List) {
+	a.nodes[e.key] = to.PushFront(&arcNode[K, V]{entry: e, in: to})
+}
+
+// dropLast forgets the least recent node of list from.
+func (a *arc[K, V]) dropLast(from *list.List) *entry[K, V] {
+	node := from.Remove(from.Back()).(*arcNode[K, V])
+	delete(a.nodes, node.entry.key)
+	return node.entry
+}
+
+// replace evicts one resident entry into its ghost list if the cache is
+// full, taking it from t1 if t1 is over its target size.
+func (a *arc[K, V]) replace(ghostOfT2 bool) []*entry[K, V] {
+	if a.len() < a.maxEntries {
+		return nil
+	}
+	from, to := a.t2, a.b2
+	if a.t1.Len() > 0 && (a.t1.Len() > a.p || (ghostOfT2 && a.t1.Len() == a.p)) || a.t2.Len() == 0 {
+		from, to = a.t1, a.b1
+	}
+	evicted := from.Back().Value.(*arcNode[K, V]).entry
+	a.move(from.Back(), to).entry = &entry[K, V]{key: evicted.key}
+	return []*entry[K, V]{evicted}
+}
+
+func (a *arc[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	var evicted []*entry[K, V]
+	if element, found := a.nodes[e.key]; found {
+		// A ghost hit: the key was evicted too early from its list, so grow
+		// that list's share of the cache.
+		ghost := element.Value.(*arcNode[K, V]).in
+		if ghost == a.b1 {
+			a.p += atLeastOne(a.b2.Len() / a.b1.Len())
+			if a.p > a.maxEntries {
+				a.p = a.maxEntries
+			}
+		} else {
+			a.p -= atLeastOne(a.b1.Len() / a.b2.Len())
+			if a.p < 0 {
+				a.p = 0
+			}
+		}
+		evicted = a.replace(ghost == a.b2)
+		a.move(element, a.t2).entry = e
+		return evicted
+	}
+
+	switch l1, total := a.t1.Len()+a.b1.Len(), a.len()+a.b1.Len()+a.b2.Len(); {
+	case l1 >= a.maxEntries:
+		if a.t1.Len() < a.maxEntries {
+			a.dropLast(a.b1)
+			evicted = a.replace(false)
+		} else {
+			evicted = append(evicted, a.dropLast(a.t1))
+		}
+	case total >= a.maxEntries:
+		if total >= 2*a.maxEntries {
+			a.dropLast(a.b2)
+		}
+		evicted = a.replace(false)
+	}
+	a.push(e, a.t1)
+	return evicted
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (a *arc[K, V]) remove(key K) (*entry[K, V], bool) {
+	element, found := a.resident(key)
+	if !found {
+		return nil, false
+	}
+	node := element.Value.(*arcNode[K, V])
+	node.in.Remove(element)
+	delete(a.nodes, key)
+	return node.entry, true
+}
+
+func (a *arc[K, V]) len() int {
+	return a.t1.Len() + a.t2.Len()
+}
+
+func (a *arc[K, V]) keys() []K {
+	keys := make([]K, 0, a.len())
+	for _, l := range []*list.List{a.t2, a.t1} {
+		for element := l.Front(); element != nil; element = element.Next() {
+			keys = append(keys, element.Value.(*arcNode[K, V]).entry.key)
+		}
+	}
+	return keys
+}
+
+func (a *arc[K, V]) clear() {
+	a.p = 0
+	for _, l := range []*list.List{a.t1, a.t2, a.b1, a.b2} {
+		l.Init()
+	}
+	a.nodes = make(map[K]*list.Element)
+}
@@ -0,0 +1,207 @@
+//go:build go1.18
+// +build go1.18
+
+// Package cache is a bounded, goroutine-safe key/value cache for timeline
+// pages, user metadata and the like. Entries are evicted by a replacement
+// policy once the cache is full, or after a time to live.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy selects which entry is evicted when the cache is full.
+type Policy int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU Policy = iota
+	// ARC balances recency against frequency, so that a scan of keys read
+	// once does not flush entries read repeatedly.
+	// References: https://en.wikipedia.org/wiki/Adaptive_replacement_cache
+	ARC
+)
+
+// Reason tells an eviction callback why an entry left the cache.
+type Reason int
+
+const (
+	// Capacity means the entry was evicted to make room for another.
+	Capacity Reason = iota
+	// Expired means the entry outlived its time to live.
+	Expired
+)
+
+// Options configure a cache. Only MaxEntries is required.
+type Options[K comparable, V any] struct {
+	// MaxEntries bounds the number of entries.
+	MaxEntries int
+	// Policy selects the entry evicted when the cache is full.
+	Policy Policy
+	// TTL, if positive, expires entries that long after they were put.
+	TTL time.Duration
+	// OnEvict, if set, is called for every entry evicted for capacity or
+	// expiry, but not for entries removed or cleared explicitly. It runs
+	// without the cache lock held, so it may use the cache.
+	OnEvict func(key K, value V, reason Reason)
+	// Now returns the current time; it defaults to time.Now.
+	Now func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	deadline time.Time // zero for no expiry
+}
+
+// policy keeps the resident entries of a cache in eviction order.
+type policy[K comparable, V any] interface {
+	// get returns the entry of key, recording the access.
+	get(key K) (*entry[K, V], bool)
+	// peek returns the entry of key without recording the access.
+	peek(key K) (*entry[K, V], bool)
+	// add inserts a new entry and returns those evicted to make room.
+	add(e *entry[K, V]) []*entry[K, V]
+	remove(key K) (*entry[K, V], bool)
+	len() int
+	// keys returns the resident keys, most likely to stay cached first.
+	keys() []K
+	clear()
+}
+
+// Cache is a bounded key/value cache.
+// Structure is thread safe.
+type Cache[K comparable, V any] struct {
+	mutex   sync.Mutex
+	policy  policy[K, V]
+	ttl     time.Duration
+	onEvict func(key K, value V, reason Reason)
+	now     func() time.Time
+}
+
+// Instantiates a new empty cache. It panics if options.MaxEntries < 1.
+func New[K comparable, V any](options Options[K, V]) *Cache[K, V] {
+	if options.MaxEntries < 1 {
+		panic("cache: MaxEntries must be positive")
+	}
+	c := &Cache[K, V]{ttl: options.TTL, onEvict: options.OnEvict, now: options.Now}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	if options.Policy == ARC {
+		c.policy = newARC[K, V](options.MaxEntries)
+	} else {
+		c.policy = newLRU[K, V](options.MaxEntries)
+	}
+	return c
+}
+
+// Returns the value of key and whether it was cached and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	e, found := c.policy.get(key)
+	if found && c.expired(e) {
+		c.policy.remove(key)
+		c.mutex.Unlock()
+		c.evicted([]*entry[K, V]{e}, Expired)
+		var zero V
+		return zero, false
+	}
+	var value V
+	if found {
+		// Read under the lock, as PutWithTTL updates entries in place.
+		value = e.value
+	}
+	c.mutex.Unlock()
+	return value, found
+}
+
+// Check wether key is cached and not expired, without counting as an access.
+func (c *Cache[K, V]) Contains(key K) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, found := c.policy.peek(key)
+	return found && !c.expired(e)
+}
+
+// Caches value under key with the default time to live, replacing any
+// previous value and restarting its time to live.
+func (c *Cache[K, V]) Put(key K, value V) {
+	c.PutWithTTL(key, value, c.ttl)
+}
+
+// Caches value under key, expiring after ttl if it is positive.
+func (c *Cache[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	e := &entry[K, V]{key: key, value: value}
+	if ttl > 0 {
+		e.deadline = c.now().Add(ttl)
+	}
+	c.mutex.Lock()
+	if old, found := c.policy.get(key); found {
+		old.value, old.deadline = e.value, e.deadline
+		c.mutex.Unlock()
+		return
+	}
+	evicted := c.policy.add(e)
+	c.mutex.Unlock()
+	c.evicted(evicted, Capacity)
+}
+
+// Removes key and returns true if it was cached.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, found := c.policy.remove(key)
+	return found
+}
+
+// Evicts all expired entries, which are otherwise only dropped when read,
+// and returns how many there were.
+func (c *Cache[K, V]) Purge() int {
+	c.mutex.Lock()
+	var expired []*entry[K, V]
+	for _, key := range c.policy.keys() {
+		if e, _ := c.policy.peek(key); c.expired(e) {
+			c.policy.remove(key)
+			expired = append(expired, e)
+		}
+	}
+	c.mutex.Unlock()
+	c.evicted(expired, Expired)
+	return len(expired)
+}
+
+// Returns number of entries in the cache, including expired ones not yet purged.
+func (c *Cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.policy.len()
+}
+
+// Returns the cached keys, those most likely to stay cached first.
+func (c *Cache[K, V]) Keys() []K {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.policy.keys()
+}
+
+// Removes all entries.
+func (c *Cache[K, V]) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.policy.clear()
+}
+
+func (c *Cache[K, V]) expired(e *entry[K, V]) bool {
+	return !e.deadline.IsZero() && !c.now().Before(e.deadline)
+}
+
+func (c *Cache[K, V]) evicted(entries []*entry[K, V], reason Reason) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range entries {
+		c.onEvict(e.key, e.value, reason)
+	}
+}
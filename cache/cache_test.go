@@ -0,0 +1,188 @@
+//go:build go1.18
+// +build go1.18
+
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	var evicted []string
+	c := New(Options[string, int]{
+		MaxEntries: 2,
+		OnEvict: func(key string, value int, reason Reason) {
+			evicted = append(evicted, fmt.Sprint(key, value, reason))
+		},
+	})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Put("c", 3)
+
+	if _, found := c.Get("b"); found {
+		t.Errorf("expected b to be evicted")
+	}
+	if !reflect.DeepEqual(evicted, []string{fmt.Sprint("b", 2, Capacity)}) {
+		t.Errorf("expected: %v, got: %v", []string{"b2 0"}, evicted)
+	}
+	if keys := c.Keys(); !reflect.DeepEqual(keys, []string{"c", "a"}) {
+		t.Errorf("expected: %v, got: %v", []string{"c", "a"}, keys)
+	}
+
+	c.Put("a", 10)
+	if value, _ := c.Get("a"); value != 10 || c.Len() != 2 {
+		t.Errorf("expected: %v, got: %v", 10, value)
+	}
+	if !c.Remove("a") || c.Remove("a") || c.Len() != 1 {
+		t.Errorf("expected a to be removed once")
+	}
+	c.Clear()
+	if c.Len() != 0 || len(evicted) != 1 {
+		t.Errorf("expected Clear to remove without callbacks, got: %v", evicted)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Unix(1473000000, 0)
+	var expired []string
+	c := New(Options[string, int]{
+		MaxEntries: 10,
+		TTL:        time.Minute,
+		Now:        func() time.Time { return now },
+		OnEvict: func(key string, value int, reason Reason) {
+			if reason == Expired {
+				expired = append(expired, key)
+			}
+		},
+	})
+	c.Put("a", 1)
+	c.PutWithTTL("b", 2, time.Hour)
+	c.PutWithTTL("c", 3, 0)
+	c.Put("d", 4)
+
+	now = now.Add(time.Minute)
+	if _, found := c.Get("a"); found || c.Contains("d") {
+		t.Errorf("expected a and d to be expired")
+	}
+	if !c.Contains("b") || !c.Contains("c") {
+		t.Errorf("expected b and c to be cached")
+	}
+	if purged := c.Purge(); purged != 1 || c.Len() != 2 {
+		t.Errorf("expected: %v, got: %v", 1, purged)
+	}
+	if !reflect.DeepEqual(expired, []string{"a", "d"}) {
+		t.Errorf("expected: %v, got: %v", []string{"a", "d"}, expired)
+	}
+}
+
+func TestARCResistsScans(t *testing.T) {
+	for _, policy := range []Policy{LRU, ARC} {
+		c := New(Options[int, int]{MaxEntries: 100, Policy: policy})
+		// A hot working set read twice per round, interleaved with a scan
+		// of keys read once.
+		hits := 0
+		for round := 0; round < 20; round++ {
+			for i := 0; i < 100; i++ {
+				key := i % 50
+				if _, found := c.Get(key); found {
+					hits++
+				} else {
+					c.Put(key, key)
+				}
+			}
+			for key := 0; key < 200; key++ {
+				scanned := 1000 + round*200 + key
+				if _, found := c.Get(scanned); !found {
+					c.Put(scanned, scanned)
+				}
+			}
+		}
+		if c.Len() != 100 {
+			t.Errorf("expected: %v, got: %v", 100, c.Len())
+		}
+		if policy == LRU && hits != 20*50 {
+			t.Errorf("expected scans to flush LRU, got %d hits", hits)
+		}
+		if policy == ARC && hits < 20*50+15*50 {
+			t.Errorf("expected ARC to keep the hot keys, got %d hits", hits)
+		}
+	}
+}
+
+func TestARCMatchesContents(t *testing.T) {
+	c := New(Options[int, int]{MaxEntries: 16, Policy: ARC})
+	for i := 0; i < 10000; i++ {
+		key := (i * 7919) % 40
+		if i%3 == 0 {
+			key %= 10
+		}
+		if value, found := c.Get(key); found && value != key {
+			t.Fatalf("expected: %v, got: %v", key, value)
+		} else if !found {
+			c.Put(key, key)
+		}
+		if i%97 == 0 {
+			c.Remove(key)
+		}
+		if c.Len() > 16 || len(c.Keys()) != c.Len() {
+			t.Fatalf("unexpected size %d with keys %v", c.Len(), c.Keys())
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	for _, policy := range []Policy{LRU, ARC} {
+		c := New(Options[int, int]{MaxEntries: 64, Policy: policy})
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 2000; i++ {
+					key := (i * (g + 1)) % 128
+					if _, found := c.Get(key); !found {
+						c.Put(key, key)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		if c.Len() > 64 {
+			t.Errorf("expected at most %v, got: %v", 64, c.Len())
+		}
+	}
+}
+
+// Run with -race: Put updates the entry of a cached key that Get reads.
+func TestConcurrentGetPut(t *testing.T) {
+	for _, policy := range []Policy{LRU, ARC} {
+		c := New(Options[int, int]{MaxEntries: 4, Policy: policy})
+		c.Put(1, 0)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 2000; i++ {
+				c.Put(1, i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			last := 0
+			for i := 0; i < 2000; i++ {
+				value, found := c.Get(1)
+				if !found || value < last {
+					t.Errorf("expected a value of at least %v, got: %v, %v", last, value, found)
+					return
+				}
+				last = value
+			}
+		}()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,71 @@
+//go:build go1.18
+// +build go1.18
+
+package cache
+
+import "container/list"
+
+// lru keeps entries in a single recency list.
+type lru[K comparable, V any] struct {
+	maxEntries int
+	recency    *list.List // *entry, most recently used first
+	elements   map[K]*list.Element
+}
+
+func newLRU[K comparable, V any](maxEntries int) *lru[K, V] {
+	return &lru[K, V]{maxEntries: maxEntries, recency: list.New(), elements: make(map[K]*list.Element)}
+}
+
+func (l *lru[K, V]) get(key K) (*entry[K, V], bool) {
+	element, found := l.elements[key]
+	if !found {
+		return nil, false
+	}
+	l.recency.MoveToFront(element)
+	return element.Value.(*entry[K, V]), true
+}
+
+func (l *lru[K, V]) peek(key K) (*entry[K, V], bool) {
+	element, found := l.elements[key]
+	if !found {
+		return nil, false
+	}
+	return element.Value.(*entry[K, V]), true
+}
+
+func (l *lru[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	l.elements[e.key] = l.recency.PushFront(e)
+	var evicted []*entry[K, V]
+	for l.recency.Len() > l.maxEntries {
+		oldest := l.recency.Remove(l.recency.Back()).(*entry[K, V])
+		delete(l.elements, oldest.key)
+		evicted = append(evicted, oldest)
+	}
+	return evicted
+}
+
+func (l *lru[K, V]) remove(key K) (*entry[K, V], bool) {
+	element, found := l.elements[key]
+	if !found {
+		return nil, false
+	}
+	delete(l.elements, key)
+	return l.recency.Remove(element).(*entry[K, V]), true
+}
+
+func (l *lru[K, V]) len() int {
+	return l.recency.Len()
+}
+
+func (l *lru[K, V]) keys() []K {
+	keys := make([]K, 0, l.recency.Len())
+	for element := l.recency.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+func (l *lru[K, V]) clear() {
+	l.recency.Init()
+	l.elements = make(map[K]*list.Element)
+}
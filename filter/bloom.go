@@ -0,0 +1,164 @@
+// Package filter provides probabilistic membership filters. They answer
+// "definitely not present" or "probably present" in far less memory than an
+// exact set, which suits checks such as skipping items a user has already
+// been delivered, where an occasional false positive is acceptable.
+package filter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+var ErrCorrupt = errors.New("corrupt filter encoding")
+
+// Bloom is a Bloom filter over byte-string items. Items cannot be removed.
+// Structure is not thread safe.
+// References: https://en.wikipedia.org/wiki/Bloom_filter
+type Bloom struct {
+	bits   []uint64
+	m      uint64 // number of bits
+	k      uint64 // number of hash functions
+	length int
+}
+
+// Instantiates a new Bloom filter sized for n items at a false positive rate
+// of fpRate (e.g. 0.01) once n items have been added.
+// Panics if fpRate is not strictly between 0 and 1.
+func NewBloom(n int, fpRate float64) *Bloom {
+	checkFPRate(fpRate)
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Ceil(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// checkFPRate rejects false positive rates no filter can be sized for,
+// including NaN.
+func checkFPRate(fpRate float64) {
+	if !(fpRate > 0 && fpRate < 1) {
+		panic(fmt.Sprintf("filter: fpRate must be in (0, 1), got %v", fpRate))
+	}
+}
+
+// hash returns two independent 32-bit halves of the FNV-1a hash of item,
+// from which the k probe positions are derived by double hashing.
+func hash(item []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(item)
+	sum := h.Sum64()
+	return sum >> 32, sum&0xffffffff | 1
+}
+
+// Adds the item to the filter.
+func (b *Bloom) Add(item []byte) {
+	h1, h2 := hash(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.length++
+}
+
+// Returns false if item was definitely never added, true if it probably was.
+func (b *Bloom) Test(item []byte) bool {
+	h1, h2 := hash(item)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds the item and returns whether it was probably present before.
+func (b *Bloom) TestAndAdd(item []byte) bool {
+	present := b.Test(item)
+	if !present {
+		b.Add(item)
+	}
+	return present
+}
+
+// Adds the ID to the filter.
+func (b *Bloom) AddID(id int64) {
+	b.Add(idBytes(id))
+}
+
+// Returns false if id was definitely never added, true if it probably was.
+func (b *Bloom) TestID(id int64) bool {
+	return b.Test(idBytes(id))
+}
+
+func idBytes(id int64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(id))
+	return buf[:]
+}
+
+// Returns the number of items added, not counting repeated TestAndAdd hits.
+func (b *Bloom) Len() int {
+	return b.length
+}
+
+// Clears all items from the filter.
+func (b *Bloom) Clear() {
+	for i := range b.bits {
+		b.bits[i] = 0
+	}
+	b.length = 0
+}
+
+// Implements encoding.BinaryMarshaler. The encoding holds m, k and the item
+// count as uvarints, followed by the bit array as little-endian words.
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 3*binary.MaxVarintLen64+8*len(b.bits))
+	data = appendUvarint(data, b.m)
+	data = appendUvarint(data, b.k)
+	data = appendUvarint(data, uint64(b.length))
+	for _, word := range b.bits {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], word)
+		data = append(data, buf[:]...)
+	}
+	return data, nil
+}
+
+// Implements encoding.BinaryUnmarshaler, replacing the contents of b.
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrCorrupt
+		}
+		header[i], data = v, data[n:]
+	}
+	m, k, length := header[0], header[1], header[2]
+	// Bound m by the data before rounding it up, which would overflow.
+	if m == 0 || k == 0 || k > m || m > uint64(len(data))*8 || uint64(len(data)) != (m+63)/64*8 {
+		return ErrCorrupt
+	}
+	bits := make([]uint64, (m+63)/64)
+	for i := range bits {
+		bits[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	*b = Bloom{bits: bits, m: m, k: k, length: int(length)}
+	return nil
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], v)]...)
+}
@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	bucketSize = 4
+	maxKicks   = 500
+)
+
+// Cuckoo is a cuckoo filter over byte-string items. Unlike a Bloom filter it
+// supports removing items, at the cost of Add failing once the filter is
+// nearly full. Each item is stored as a short fingerprint in one of two
+// candidate buckets.
+// Structure is not thread safe.
+// References: https://www.cs.cmu.edu/~dga/papers/cuckoo-conext2014.pdf
+type Cuckoo struct {
+	buckets [][bucketSize]uint16
+	mask    uint64 // number of buckets - 1
+	fpMask  uint16
+	length  int
+	kick    uint64 // state of the victim choice when relocating
+}
+
+// Instantiates a new cuckoo filter able to hold capacity items at a false
+// positive rate of about fpRate. Fingerprints are at most 16 bits, which
+// bounds the rate from below at about 0.0001.
+// Panics if fpRate is not strictly between 0 and 1.
+func NewCuckoo(capacity int, fpRate float64) *Cuckoo {
+	checkFPRate(fpRate)
+	bits := int(math.Ceil(math.Log2(2 * bucketSize / fpRate)))
+	if bits > 16 {
+		bits = 16
+	}
+	if bits < 4 {
+		bits = 4
+	}
+	// Cuckoo filters load up to about 95% before inserts start failing.
+	needed := uint64(math.Ceil(float64(capacity) / bucketSize / 0.95))
+	n := uint64(1)
+	for n < needed {
+		n <<= 1
+	}
+	return &Cuckoo{buckets: make([][bucketSize]uint16, n), mask: n - 1, fpMask: uint16(1<<uint(bits) - 1)}
+}
+
+// fingerprint returns the non-zero fingerprint of item and its first bucket.
+func (c *Cuckoo) fingerprint(item []byte) (uint16, uint64) {
+	h1, h2 := hash(item)
+	fp := uint16(h2>>1) & c.fpMask
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, h1 & c.mask
+}
+
+// altIndex returns the other candidate bucket of fp. It is its own inverse,
+// so either bucket leads to the other.
+func (c *Cuckoo) altIndex(i uint64, fp uint16) uint64 {
+	return (i ^ uint64(fp)*0x5bd1e995) & c.mask
+}
+
+func (c *Cuckoo) insert(i uint64, fp uint16) bool {
+	for j, slot := range c.buckets[i] {
+		if slot == 0 {
+			c.buckets[i][j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the item to the filter and returns false if the filter is full.
+// Adding an item repeatedly stores it repeatedly, so that each Add can be
+// undone with a Remove.
+func (c *Cuckoo) Add(item []byte) bool {
+	fp, i1 := c.fingerprint(item)
+	i2 := c.altIndex(i1, fp)
+	if c.insert(i1, fp) || c.insert(i2, fp) {
+		c.length++
+		return true
+	}
+	// Evict fingerprints along a path of alternate buckets, and undo the
+	// path if no free slot turns up so that a failed Add changes nothing.
+	i := i1
+	if c.kick&1 == 1 {
+		i = i2
+	}
+	type move struct {
+		bucket uint64
+		slot   int
+	}
+	path := make([]move, 0, maxKicks)
+	for n := 0; n < maxKicks; n++ {
+		c.kick = c.kick*6364136223846793005 + 1442695040888963407
+		slot := int(c.kick>>62) % bucketSize
+		fp, c.buckets[i][slot] = c.buckets[i][slot], fp
+		path = append(path, move{i, slot})
+		i = c.altIndex(i, fp)
+		if c.insert(i, fp) {
+			c.length++
+			return true
+		}
+	}
+	for n := len(path) - 1; n >= 0; n-- {
+		m := path[n]
+		fp, c.buckets[m.bucket][m.slot] = c.buckets[m.bucket][m.slot], fp
+	}
+	return false
+}
+
+// Returns false if item is definitely not present, true if it probably is.
+func (c *Cuckoo) Test(item []byte) bool {
+	fp, i1 := c.fingerprint(item)
+	return c.find(i1, fp) >= 0 || c.find(c.altIndex(i1, fp), fp) >= 0
+}
+
+func (c *Cuckoo) find(i uint64, fp uint16) int {
+	for j, slot := range c.buckets[i] {
+		if slot == fp {
+			return j
+		}
+	}
+	return -1
+}
+
+// Removes one copy of item and returns true if it was probably present.
+// Only remove items that were added, or another item sharing the
+// fingerprint may be removed instead.
+func (c *Cuckoo) Remove(item []byte) bool {
+	fp, i1 := c.fingerprint(item)
+	for _, i := range []uint64{i1, c.altIndex(i1, fp)} {
+		if j := c.find(i, fp); j >= 0 {
+			c.buckets[i][j] = 0
+			c.length--
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the ID to the filter and returns false if the filter is full.
+func (c *Cuckoo) AddID(id int64) bool {
+	return c.Add(idBytes(id))
+}
+
+// Returns false if id is definitely not present, true if it probably is.
+func (c *Cuckoo) TestID(id int64) bool {
+	return c.Test(idBytes(id))
+}
+
+// Removes one copy of id and returns true if it was probably present.
+func (c *Cuckoo) RemoveID(id int64) bool {
+	return c.Remove(idBytes(id))
+}
+
+// Returns the number of items in the filter.
+func (c *Cuckoo) Len() int {
+	return c.length
+}
+
+// Clears all items from the filter.
+func (c *Cuckoo) Clear() {
+	for i := range c.buckets {
+		c.buckets[i] = [bucketSize]uint16{}
+	}
+	c.length = 0
+}
+
+// Implements encoding.BinaryMarshaler. The encoding holds the bucket count,
+// fingerprint mask and item count as uvarints, followed by the slots as
+// little-endian uint16s.
+func (c *Cuckoo) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 3*binary.MaxVarintLen64+2*bucketSize*len(c.buckets))
+	data = appendUvarint(data, uint64(len(c.buckets)))
+	data = appendUvarint(data, uint64(c.fpMask))
+	data = appendUvarint(data, uint64(c.length))
+	for _, bucket := range c.buckets {
+		for _, slot := range bucket {
+			data = append(data, byte(slot), byte(slot>>8))
+		}
+	}
+	return data, nil
+}
+
+// Implements encoding.BinaryUnmarshaler, replacing the contents of c.
+func (c *Cuckoo) UnmarshalBinary(data []byte) error {
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrCorrupt
+		}
+		header[i], data = v, data[n:]
+	}
+	n, fpMask, length := header[0], header[1], header[2]
+	// Bound n by the data before multiplying, which would overflow.
+	if n == 0 || n&(n-1) != 0 || n > uint64(len(data))/(2*bucketSize) || fpMask == 0 || fpMask > math.MaxUint16 ||
+		uint64(len(data)) != n*2*bucketSize || length > n*bucketSize {
+		return ErrCorrupt
+	}
+	buckets := make([][bucketSize]uint16, n)
+	for i := range buckets {
+		for j := range buckets[i] {
+			k := 2 * (i*bucketSize + j)
+			buckets[i][j] = uint16(data[k]) | uint16(data[k+1])<<8
+		}
+	}
+	*c = Cuckoo{buckets: buckets, mask: n - 1, fpMask: uint16(fpMask), length: int(length)}
+	return nil
+}
@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// falsePositiveRate returns the share of n never-added IDs that test positive.
+func falsePositiveRate(test func(int64) bool, n int) float64 {
+	positives := 0
+	for id := int64(0); id < int64(n); id++ {
+		if test(-1 - id) {
+			positives++
+		}
+	}
+	return float64(positives) / float64(n)
+}
+
+func TestBloom(t *testing.T) {
+	b := NewBloom(10000, 0.01)
+	for id := int64(0); id < 10000; id++ {
+		b.AddID(id)
+	}
+	for id := int64(0); id < 10000; id++ {
+		if !b.TestID(id) {
+			t.Fatalf("expected %v to be present", id)
+		}
+	}
+	if rate := falsePositiveRate(b.TestID, 100000); rate > 0.02 {
+		t.Errorf("expected false positive rate of about 0.01, got: %v", rate)
+	}
+	if b.TestAndAdd([]byte("a")) || !b.TestAndAdd([]byte("a")) {
+		t.Errorf("expected TestAndAdd to report the first add only")
+	}
+	b.Clear()
+	if b.TestID(1) || b.Len() != 0 {
+		t.Errorf("expected empty filter after Clear")
+	}
+}
+
+func TestBloomSerialization(t *testing.T) {
+	b := NewBloom(1000, 0.01)
+	for id := int64(0); id < 1000; id++ {
+		b.AddID(id)
+	}
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := &Bloom{}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(0); id < 1000; id++ {
+		if !restored.TestID(id) {
+			t.Fatalf("expected %v to be present", id)
+		}
+	}
+	if restored.Len() != 1000 {
+		t.Errorf("expected: %v, got: %v", 1000, restored.Len())
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+	// A huge m would overflow the expected data length to 0.
+	huge := appendUvarint(appendUvarint(appendUvarint(nil, math.MaxUint64), 3), 0)
+	if err := restored.UnmarshalBinary(huge); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+}
+
+func TestCuckoo(t *testing.T) {
+	c := NewCuckoo(10000, 0.001)
+	for id := int64(0); id < 10000; id++ {
+		if !c.AddID(id) {
+			t.Fatalf("expected room for %v", id)
+		}
+	}
+	for id := int64(0); id < 10000; id++ {
+		if !c.TestID(id) {
+			t.Fatalf("expected %v to be present", id)
+		}
+	}
+	if rate := falsePositiveRate(c.TestID, 100000); rate > 0.002 {
+		t.Errorf("expected false positive rate of about 0.001, got: %v", rate)
+	}
+
+	for id := int64(0); id < 10000; id += 2 {
+		if !c.RemoveID(id) {
+			t.Fatalf("expected %v to be removed", id)
+		}
+	}
+	if c.Len() != 5000 {
+		t.Errorf("expected: %v, got: %v", 5000, c.Len())
+	}
+	for id := int64(1); id < 10000; id += 2 {
+		if !c.TestID(id) {
+			t.Fatalf("expected %v to survive removals", id)
+		}
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	c := NewCuckoo(100, 0.01)
+	added := 0
+	for i := 0; i < 1000; i++ {
+		if c.Add([]byte(fmt.Sprint(i))) {
+			added++
+		}
+	}
+	if added < 100 || added != c.Len() || added > len(c.buckets)*bucketSize {
+		t.Errorf("unexpected number of items added: %v", added)
+	}
+	// A failed Add must not lose items that were already added.
+	for i := 0; i < 1000; i++ {
+		item := []byte(fmt.Sprint(i))
+		for c.Test(item) {
+			c.Remove(item)
+		}
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected every item to be removable, %d left", c.Len())
+	}
+}
+
+func TestCuckooSerialization(t *testing.T) {
+	c := NewCuckoo(1000, 0.01)
+	for id := int64(0); id < 1000; id++ {
+		c.AddID(id)
+	}
+	data, _ := c.MarshalBinary()
+	restored := &Cuckoo{}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(0); id < 1000; id++ {
+		if !restored.TestID(id) {
+			t.Fatalf("expected %v to be present", id)
+		}
+	}
+	if !restored.RemoveID(0) || restored.Len() != 999 {
+		t.Errorf("expected: %v, got: %v", 999, restored.Len())
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+	// A huge bucket count would overflow the expected data length to 0.
+	huge := appendUvarint(appendUvarint(appendUvarint(nil, 1<<62), 0xff), 0)
+	if err := restored.UnmarshalBinary(huge); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+}
+
+func TestInvalidFPRate(t *testing.T) {
+	for _, fpRate := range []float64{0, 1, -0.5, 2, math.NaN()} {
+		for name, create := range map[string]func(){
+			"bloom":  func() { NewBloom(100, fpRate) },
+			"cuckoo": func() { NewCuckoo(100, fpRate) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("expected %s to panic for fpRate %v", name, fpRate)
+					}
+				}()
+				create()
+			}()
+		}
+	}
+}
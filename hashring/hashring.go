@@ -0,0 +1,183 @@
+// Package hashring shards keys across nodes by consistent hashing, so that
+// adding or removing a node moves only the keys it gains or loses instead of
+// rehashing everything as modulo sharding does.
+//
+// Every node is placed on the ring at many points (virtual nodes), in
+// proportion to its weight, and a key belongs to the node at the first point
+// clockwise from the key's hash.
+// References: https://en.wikipedia.org/wiki/Consistent_hashing
+package hashring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplicas is the number of virtual nodes of a node with weight 1.
+const DefaultReplicas = 160
+
+type point struct {
+	hash uint64
+	node string
+}
+
+// Ring is a consistent hash ring of named nodes.
+// Structure is thread safe.
+type Ring struct {
+	mutex    sync.RWMutex
+	replicas int
+	weights  map[string]int
+	points   []point // ascending by hash
+}
+
+// Instantiates a new empty ring with DefaultReplicas virtual nodes per weight.
+func New() *Ring {
+	return NewWithReplicas(DefaultReplicas)
+}
+
+// Instantiates a new empty ring placing replicas virtual nodes per unit of
+// weight. More replicas spread keys more evenly at the cost of memory.
+func NewWithReplicas(replicas int) *Ring {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &Ring{replicas: replicas, weights: make(map[string]int)}
+}
+
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	// FNV-1a leaves similar keys such as "node#1" and "node#2" close
+	// together, so mix the bits before placing them on the ring.
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Adds the nodes (one or more) with weight 1.
+func (r *Ring) Add(nodes ...string) {
+	for _, node := range nodes {
+		r.AddWeighted(node, 1)
+	}
+}
+
+// Adds node, or changes its weight if it is already on the ring. A node of
+// weight 2 receives about twice the keys of a node of weight 1. A weight
+// < 1 removes the node.
+func (r *Ring) AddWeighted(node string, weight int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.remove(node)
+	if weight < 1 {
+		return
+	}
+	r.weights[node] = weight
+	for i := 0; i < weight*r.replicas; i++ {
+		r.points = append(r.points, point{hash: hash(node + "#" + strconv.Itoa(i)), node: node})
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].node < r.points[j].node
+	})
+}
+
+// Removes the nodes (one or more); their keys move to the remaining nodes.
+func (r *Ring) Remove(nodes ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, node := range nodes {
+		r.remove(node)
+	}
+}
+
+func (r *Ring) remove(node string) {
+	if _, found := r.weights[node]; !found {
+		return
+	}
+	delete(r.weights, node)
+	points := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+}
+
+// Returns the node owning key, and false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.points) == 0 {
+		return "", false
+	}
+	return r.points[r.search(hash(key))].node, true
+}
+
+// search returns the index of the first point at or clockwise from h.
+func (r *Ring) search(h uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// Returns up to n distinct nodes for key, owner first, e.g. to place
+// replicas of a timeline. The nodes are those met walking clockwise from
+// the key, so they also change minimally as nodes come and go.
+func (r *Ring) GetN(key string, n int) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if n > len(r.weights) {
+		n = len(r.weights)
+	}
+	nodes := make([]string, 0, n)
+	if n <= 0 {
+		return nodes
+	}
+	seen := make(map[string]bool, n)
+	for i, start := 0, r.search(hash(key)); len(nodes) < n; i++ {
+		node := r.points[(start+i)%len(r.points)].node
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Returns the nodes on the ring in ascending order.
+func (r *Ring) Nodes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	nodes := make([]string, 0, len(r.weights))
+	for node := range r.weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Returns the weight of node, and false if it is not on the ring.
+func (r *Ring) Weight(node string) (int, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	weight, found := r.weights[node]
+	return weight, found
+}
+
+// Returns number of nodes on the ring.
+func (r *Ring) Size() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.weights)
+}
@@ -0,0 +1,95 @@
+package hashring
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func owners(r *Ring, keys int) map[string]string {
+	owner := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := "timeline:" + strconv.Itoa(i)
+		owner[key], _ = r.Get(key)
+	}
+	return owner
+}
+
+func TestEmptyRing(t *testing.T) {
+	r := New()
+	if _, found := r.Get("a"); found {
+		t.Errorf("expected no node on an empty ring")
+	}
+	if nodes := r.GetN("a", 3); len(nodes) != 0 {
+		t.Errorf("expected no nodes, got: %v", nodes)
+	}
+}
+
+func TestBalanceAndMinimalMoves(t *testing.T) {
+	r := New()
+	r.Add("redis1", "redis2", "redis3", "redis4")
+	before := owners(r, 100000)
+
+	counts := make(map[string]int)
+	for _, node := range before {
+		counts[node]++
+	}
+	for node, count := range counts {
+		if count < 20000 || count > 30000 {
+			t.Errorf("%s, expected about 25000 keys, got: %d", node, count)
+		}
+	}
+
+	r.Add("redis5")
+	after := owners(r, 100000)
+	moved := 0
+	for key, node := range after {
+		if node != before[key] {
+			moved++
+			if node != "redis5" {
+				t.Fatalf("%s moved between old nodes %s and %s", key, before[key], node)
+			}
+		}
+	}
+	if moved < 15000 || moved > 25000 {
+		t.Errorf("expected about 20000 keys moved, got: %d", moved)
+	}
+
+	r.Remove("redis5")
+	if !reflect.DeepEqual(owners(r, 100000), before) {
+		t.Errorf("expected removal to restore the previous placement")
+	}
+}
+
+func TestWeights(t *testing.T) {
+	r := New()
+	r.Add("small")
+	r.AddWeighted("large", 3)
+	counts := make(map[string]int)
+	for _, node := range owners(r, 100000) {
+		counts[node]++
+	}
+	if counts["large"] < 70000 || counts["large"] > 80000 {
+		t.Errorf("expected about 75000 keys, got: %d", counts["large"])
+	}
+	if weight, _ := r.Weight("large"); weight != 3 {
+		t.Errorf("expected: %v, got: %v", 3, weight)
+	}
+	r.AddWeighted("large", 0)
+	if r.Size() != 1 || !reflect.DeepEqual(r.Nodes(), []string{"small"}) {
+		t.Errorf("expected: %v, got: %v", []string{"small"}, r.Nodes())
+	}
+}
+
+func TestGetN(t *testing.T) {
+	r := New()
+	r.Add("a", "b", "c")
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		nodes := r.GetN(key, 5)
+		owner, _ := r.Get(key)
+		if len(nodes) != 3 || nodes[0] != owner || nodes[1] == nodes[2] || nodes[0] == nodes[1] || nodes[0] == nodes[2] {
+			t.Fatalf("%s, unexpected replicas: %v", key, nodes)
+		}
+	}
+}
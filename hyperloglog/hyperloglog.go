@@ -0,0 +1,163 @@
+// Package hyperloglog estimates the number of distinct items added to it,
+// e.g. unique viewers of a feed item, in a few kilobytes regardless of how
+// many items there are. With precision p it keeps 2^p one-byte registers and
+// has a standard error of about 1.04/sqrt(2^p), 0.8% at the default of 14.
+// References: https://en.wikipedia.org/wiki/HyperLogLog
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	MinPrecision     = 4
+	MaxPrecision     = 16
+	DefaultPrecision = 14
+
+	binaryVersion = 1
+)
+
+var (
+	ErrPrecision = errors.New("hyperloglog precision must be between 4 and 16")
+	ErrMismatch  = errors.New("hyperloglog precisions differ")
+	ErrCorrupt   = errors.New("corrupt hyperloglog encoding")
+)
+
+// Structure is not thread safe.
+type HyperLogLog struct {
+	p         uint8
+	registers []uint8
+}
+
+// Instantiates a new empty counter with DefaultPrecision.
+func New() *HyperLogLog {
+	h, _ := NewWithPrecision(DefaultPrecision)
+	return h
+}
+
+// Instantiates a new empty counter with 2^p registers.
+func NewWithPrecision(p int) (*HyperLogLog, error) {
+	if p < MinPrecision || p > MaxPrecision {
+		return nil, ErrPrecision
+	}
+	return &HyperLogLog{p: uint8(p), registers: make([]uint8, 1<<uint(p))}, nil
+}
+
+// hash returns a well mixed 64-bit hash of item. FNV-1a alone spreads
+// short keys such as sequential IDs poorly over the high bits.
+func hash(item []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(item)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Adds the item to the counter.
+func (h *HyperLogLog) Add(item []byte) {
+	x := hash(item)
+	index := x >> (64 - h.p)
+	// The rank is the position of the first set bit in the remaining bits.
+	rank := uint8(1)
+	for w := x << h.p; w&(1<<63) == 0 && rank <= 64-h.p; w <<= 1 {
+		rank++
+	}
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Adds the ID to the counter.
+func (h *HyperLogLog) AddID(id int64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(id))
+	h.Add(buf[:])
+}
+
+// Returns the estimated number of distinct items added.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+	// Small cardinalities are estimated more precisely by linear counting.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// Merges other into h, so that h counts the union of both.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.p != other.p {
+		return ErrMismatch
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Returns an independent copy of the counter.
+func (h *HyperLogLog) Clone() *HyperLogLog {
+	return &HyperLogLog{p: h.p, registers: append([]uint8(nil), h.registers...)}
+}
+
+// Clears the counter.
+func (h *HyperLogLog) Clear() {
+	for i := range h.registers {
+		h.registers[i] = 0
+	}
+}
+
+// Implements encoding.BinaryMarshaler. The encoding is a version byte, the
+// precision byte and the registers.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 2, 2+len(h.registers))
+	data[0], data[1] = binaryVersion, h.p
+	return append(data, h.registers...), nil
+}
+
+// Implements encoding.BinaryUnmarshaler, replacing the contents of h.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != binaryVersion || data[1] < MinPrecision || data[1] > MaxPrecision {
+		return ErrCorrupt
+	}
+	p := data[1]
+	registers := data[2:]
+	if len(registers) != 1<<p {
+		return ErrCorrupt
+	}
+	for _, r := range registers {
+		if r > 65-p {
+			return ErrCorrupt
+		}
+	}
+	h.p, h.registers = p, append([]uint8(nil), registers...)
+	return nil
+}
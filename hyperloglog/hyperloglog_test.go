@@ -0,0 +1,66 @@
+package hyperloglog
+
+import (
+	"math"
+	"testing"
+)
+
+func relativeError(estimate uint64, actual int) float64 {
+	return math.Abs(float64(estimate)-float64(actual)) / float64(actual)
+}
+
+func TestCount(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000, 1000000} {
+		h := New()
+		for id := 0; id < n; id++ {
+			h.AddID(int64(id))
+			h.AddID(int64(id)) // duplicates do not count
+		}
+		if err := relativeError(h.Count(), n); err > 0.03 {
+			t.Errorf("n=%d, expected within 3%%, got: %d", n, h.Count())
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(), New()
+	for id := 0; id < 60000; id++ {
+		a.AddID(int64(id))
+		b.AddID(int64(id + 40000))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := relativeError(a.Count(), 100000); err > 0.03 {
+		t.Errorf("expected about %d, got: %d", 100000, a.Count())
+	}
+
+	c, _ := NewWithPrecision(10)
+	if err := a.Merge(c); err != ErrMismatch {
+		t.Errorf("expected: %v, got: %v", ErrMismatch, err)
+	}
+	if _, err := NewWithPrecision(3); err != ErrPrecision {
+		t.Errorf("expected: %v, got: %v", ErrPrecision, err)
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	h, _ := NewWithPrecision(12)
+	for id := 0; id < 5000; id++ {
+		h.AddID(int64(id))
+	}
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := New()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Count() != h.Count() {
+		t.Errorf("expected: %d, got: %d", h.Count(), restored.Count())
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package pqueue is an indexed priority queue: a binary heap that also
+// indexes its items, so that the priority of a queued item can be changed
+// or the item removed in O(log n), e.g. to re-rank a candidate or cancel a
+// scheduled publish.
+package pqueue
+
+import "github.com/emirpasic/gods/utils"
+
+type element struct {
+	item     interface{}
+	priority interface{}
+	index    int
+}
+
+// Queue pops items in ascending priority order as defined by its
+// comparator; wrap the comparator with treeset.Reverse for a max-queue.
+// Items must be usable as map keys and are queued at most once.
+// Structure is not thread safe.
+type Queue struct {
+	heap       []*element
+	index      map[interface{}]*element
+	comparator utils.Comparator // orders priorities
+}
+
+// Instantiates a new empty queue ordering priorities with the comparator.
+func NewWith(comparator utils.Comparator) *Queue {
+	return &Queue{index: make(map[interface{}]*element), comparator: comparator}
+}
+
+// Queues item with priority, or updates its priority if it is queued already.
+func (q *Queue) Push(item, priority interface{}) {
+	if q.UpdatePriority(item, priority) {
+		return
+	}
+	e := &element{item: item, priority: priority, index: len(q.heap)}
+	q.heap = append(q.heap, e)
+	q.index[item] = e
+	q.up(e.index)
+}
+
+// Changes the priority of a queued item and returns false if it is not queued.
+func (q *Queue) UpdatePriority(item, priority interface{}) bool {
+	e, found := q.index[item]
+	if !found {
+		return false
+	}
+	e.priority = priority
+	q.fix(e.index)
+	return true
+}
+
+// Removes item from the queue and returns false if it was not queued.
+func (q *Queue) Remove(item interface{}) bool {
+	e, found := q.index[item]
+	if !found {
+		return false
+	}
+	q.removeAt(e.index)
+	return true
+}
+
+// Removes and returns the item with the lowest priority, and false if the
+// queue is empty.
+func (q *Queue) Pop() (item, priority interface{}, ok bool) {
+	if len(q.heap) == 0 {
+		return nil, nil, false
+	}
+	e := q.heap[0]
+	q.removeAt(0)
+	return e.item, e.priority, true
+}
+
+// Returns the item with the lowest priority without removing it, and false
+// if the queue is empty.
+func (q *Queue) Peek() (item, priority interface{}, ok bool) {
+	if len(q.heap) == 0 {
+		return nil, nil, false
+	}
+	return q.heap[0].item, q.heap[0].priority, true
+}
+
+// Returns the priority of item, and false if it is not queued.
+func (q *Queue) Priority(item interface{}) (interface{}, bool) {
+	e, found := q.index[item]
+	if !found {
+		return nil, false
+	}
+	return e.priority, true
+}
+
+// Check wether item is queued.
+func (q *Queue) Contains(item interface{}) bool {
+	_, found := q.index[item]
+	return found
+}
+
+// Returns true if queue does not contain any elements.
+func (q *Queue) Empty() bool {
+	return len(q.heap) == 0
+}
+
+// Returns number of elements within the queue.
+func (q *Queue) Size() int {
+	return len(q.heap)
+}
+
+// Clears all values in the queue.
+func (q *Queue) Clear() {
+	q.heap = nil
+	q.index = make(map[interface{}]*element)
+}
+
+// Returns all items in the queue, in no particular order.
+func (q *Queue) Values() []interface{} {
+	values := make([]interface{}, len(q.heap))
+	for i, e := range q.heap {
+		values[i] = e.item
+	}
+	return values
+}
+
+func (q *Queue) removeAt(i int) {
+	e := q.heap[i]
+	last := len(q.heap) - 1
+	q.swap(i, last)
+	q.heap[last] = nil
+	q.heap = q.heap[:last]
+	delete(q.index, e.item)
+	if i < last {
+		q.fix(i)
+	}
+}
+
+func (q *Queue) less(i, j int) bool {
+	return q.comparator(q.heap[i].priority, q.heap[j].priority) < 0
+}
+
+func (q *Queue) swap(i, j int) {
+	q.heap[i], q.heap[j] = q.heap[j], q.heap[i]
+	q.heap[i].index = i
+	q.heap[j].index = j
+}
+
+// fix restores the heap order after the priority at i changed.
+func (q *Queue) fix(i int) {
+	if !q.down(i) {
+		q.up(i)
+	}
+}
+
+func (q *Queue) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !q.less(i, parent) {
+			break
+		}
+		q.swap(i, parent)
+		i = parent
+	}
+}
+
+// down sifts the element at i towards the leaves and returns whether it moved.
+func (q *Queue) down(i int) bool {
+	start := i
+	for {
+		child := 2*i + 1
+		if child >= len(q.heap) {
+			break
+		}
+		if right := child + 1; right < len(q.heap) && q.less(right, child) {
+			child = right
+		}
+		if !q.less(child, i) {
+			break
+		}
+		q.swap(i, child)
+		i = child
+	}
+	return i > start
+}
@@ -0,0 +1,95 @@
+package pqueue
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"feed/treeset"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestPopOrder(t *testing.T) {
+	q := NewWith(utils.IntComparator)
+	q.Push("c", 3)
+	q.Push("a", 1)
+	q.Push("b", 2)
+	if item, priority, _ := q.Peek(); item != "a" || priority != 1 {
+		t.Errorf("expected: %v, got: %v", "a", item)
+	}
+	for _, expected := range []string{"a", "b", "c"} {
+		if item, _, ok := q.Pop(); !ok || item != expected {
+			t.Errorf("expected: %v, got: %v", expected, item)
+		}
+	}
+	if _, _, ok := q.Pop(); ok || !q.Empty() {
+		t.Errorf("expected empty queue")
+	}
+}
+
+func TestUpdateAndRemove(t *testing.T) {
+	q := NewWith(treeset.Reverse(utils.IntComparator))
+	q.Push("a", 1)
+	q.Push("b", 2)
+	q.Push("c", 3)
+	q.Push("a", 10) // updates
+	if !q.UpdatePriority("c", 0) || q.UpdatePriority("d", 5) {
+		t.Errorf("expected update of queued items only")
+	}
+	if !q.Remove("b") || q.Remove("b") || q.Contains("b") {
+		t.Errorf("expected b to be removed once")
+	}
+	if priority, _ := q.Priority("a"); priority != 10 || q.Size() != 2 {
+		t.Errorf("expected: %v, got: %v", 10, priority)
+	}
+	if item, _, _ := q.Pop(); item != "a" {
+		t.Errorf("expected: %v, got: %v", "a", item)
+	}
+	if item, _, _ := q.Pop(); item != "c" {
+		t.Errorf("expected: %v, got: %v", "c", item)
+	}
+}
+
+func TestRandomOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	q := NewWith(utils.IntComparator)
+	priorities := make(map[int]int)
+	for i := 0; i < 20000; i++ {
+		item := rnd.Intn(500)
+		switch rnd.Intn(3) {
+		case 0:
+			q.Remove(item)
+			delete(priorities, item)
+		default:
+			priority := rnd.Intn(1000)
+			q.Push(item, priority)
+			priorities[item] = priority
+		}
+	}
+	if q.Size() != len(priorities) {
+		t.Fatalf("expected: %v, got: %v", len(priorities), q.Size())
+	}
+	expected := make([]int, 0, len(priorities))
+	for _, priority := range priorities {
+		expected = append(expected, priority)
+	}
+	sort.Ints(expected)
+	for _, priority := range expected {
+		item, actual, _ := q.Pop()
+		if actual != priority || priorities[item.(int)] != priority {
+			t.Fatalf("expected: %v, got: %v", priority, actual)
+		}
+	}
+}
+
+func BenchmarkUpdatePriority(b *testing.B) {
+	q := NewWith(utils.IntComparator)
+	for i := 0; i < 100000; i++ {
+		q.Push(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.UpdatePriority(i%100000, (i*7919)%100000)
+	}
+}
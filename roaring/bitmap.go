@@ -0,0 +1,226 @@
+// Package roaring is a compressed set of int64 IDs, a much smaller
+// alternative to a treeset of IDs for dedup and membership checks.
+//
+// IDs are split into their high 48 and low 16 bits. Each distinct high part
+// gets a container holding the low parts, stored as a sorted array while it
+// has at most 4096 values and as a 8 KB bitmap beyond that. Dense ID ranges
+// thus cost about one bit per ID and sparse ones two bytes.
+// Structure is not thread safe.
+// References: https://roaringbitmap.org
+package roaring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emirpasic/gods/sets"
+)
+
+func assertInterfaceImplementation() {
+	var _ sets.Set = (*Bitmap)(nil)
+}
+
+type Bitmap struct {
+	keys       []uint64 // high bits, ascending
+	containers []*container
+}
+
+// Instantiates a new empty bitmap.
+func New() *Bitmap {
+	return &Bitmap{}
+}
+
+// split maps id to an unsigned value with the same order (by flipping the
+// sign bit), then into its high and low parts.
+func split(id int64) (uint64, uint16) {
+	u := uint64(id) ^ 1<<63
+	return u >> 16, uint16(u)
+}
+
+func join(high uint64, low uint16) int64 {
+	return int64((high<<16 | uint64(low)) ^ 1<<63)
+}
+
+func (b *Bitmap) search(high uint64) int {
+	return sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= high })
+}
+
+func (b *Bitmap) container(high uint64) *container {
+	if i := b.search(high); i < len(b.keys) && b.keys[i] == high {
+		return b.containers[i]
+	}
+	return nil
+}
+
+// Adds id and returns true if it was not present yet.
+func (b *Bitmap) AddID(id int64) bool {
+	high, low := split(id)
+	i := b.search(high)
+	if i == len(b.keys) || b.keys[i] != high {
+		b.keys = append(b.keys, 0)
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = high
+		b.containers = append(b.containers, nil)
+		copy(b.containers[i+1:], b.containers[i:])
+		b.containers[i] = &container{}
+	}
+	return b.containers[i].add(low)
+}
+
+// Removes id and returns true if it was present.
+func (b *Bitmap) RemoveID(id int64) bool {
+	high, low := split(id)
+	i := b.search(high)
+	if i == len(b.keys) || b.keys[i] != high || !b.containers[i].remove(low) {
+		return false
+	}
+	if b.containers[i].card == 0 {
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+		b.containers = append(b.containers[:i], b.containers[i+1:]...)
+	}
+	return true
+}
+
+// Check wether id is present in the bitmap.
+func (b *Bitmap) ContainsID(id int64) bool {
+	high, low := split(id)
+	c := b.container(high)
+	return c != nil && c.contains(low)
+}
+
+// Calls f for every ID in ascending order.
+func (b *Bitmap) Each(f func(id int64)) {
+	for i, high := range b.keys {
+		b.containers[i].each(func(low uint16) {
+			f(join(high, low))
+		})
+	}
+}
+
+// Returns all IDs in ascending order.
+func (b *Bitmap) IDs() []int64 {
+	ids := make([]int64, 0, b.Size())
+	b.Each(func(id int64) {
+		ids = append(ids, id)
+	})
+	return ids
+}
+
+// Adds the items (one or more), which must be int64, to the bitmap.
+func (b *Bitmap) Add(items ...interface{}) {
+	for _, item := range items {
+		b.AddID(item.(int64))
+	}
+}
+
+// Removes the items (one or more), which must be int64, from the bitmap.
+func (b *Bitmap) Remove(items ...interface{}) {
+	for _, item := range items {
+		b.RemoveID(item.(int64))
+	}
+}
+
+// Check wether items (one or more), which must be int64, are present in the bitmap.
+// Returns true if no arguments are passed at all.
+func (b *Bitmap) Contains(items ...interface{}) bool {
+	for _, item := range items {
+		if !b.ContainsID(item.(int64)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if bitmap does not contain any elements.
+func (b *Bitmap) Empty() bool {
+	return len(b.keys) == 0
+}
+
+// Returns number of elements within the bitmap.
+func (b *Bitmap) Size() int {
+	size := 0
+	for _, c := range b.containers {
+		size += c.card
+	}
+	return size
+}
+
+// Clears all values in the bitmap.
+func (b *Bitmap) Clear() {
+	b.keys, b.containers = nil, nil
+}
+
+// Returns all IDs in ascending order as int64 items.
+func (b *Bitmap) Values() []interface{} {
+	values := make([]interface{}, 0, b.Size())
+	b.Each(func(id int64) {
+		values = append(values, id)
+	})
+	return values
+}
+
+func (b *Bitmap) String() string {
+	items := []string{}
+	b.Each(func(id int64) {
+		items = append(items, fmt.Sprintf("%v", id))
+	})
+	return "Roaring\n" + strings.Join(items, ", ")
+}
+
+// Returns an independent copy of the bitmap.
+func (b *Bitmap) Clone() *Bitmap {
+	cloned := &Bitmap{keys: append([]uint64(nil), b.keys...), containers: make([]*container, len(b.containers))}
+	for i, c := range b.containers {
+		cloned.containers[i] = c.clone()
+	}
+	return cloned
+}
+
+// Returns the IDs present in b or other.
+func (b *Bitmap) Union(other *Bitmap) *Bitmap {
+	return b.merge(other, func(x, y uint64) uint64 { return x | y }, func(inB, inOther bool) bool { return inB || inOther })
+}
+
+// Returns the IDs present in both b and other.
+func (b *Bitmap) Inter(other *Bitmap) *Bitmap {
+	return b.merge(other, func(x, y uint64) uint64 { return x & y }, func(inB, inOther bool) bool { return inB && inOther })
+}
+
+// Returns the IDs of b not present in other.
+func (b *Bitmap) Diff(other *Bitmap) *Bitmap {
+	return b.merge(other, func(x, y uint64) uint64 { return x &^ y }, func(inB, inOther bool) bool { return inB && !inOther })
+}
+
+// merge walks the containers of both bitmaps in key order. Containers found
+// on one side only are copied if keep accepts that side; matching ones are
+// combined word by word with op.
+func (b *Bitmap) merge(other *Bitmap, op func(x, y uint64) uint64, keep func(inB, inOther bool) bool) *Bitmap {
+	result := New()
+	push := func(high uint64, c *container) {
+		if c != nil {
+			result.keys = append(result.keys, high)
+			result.containers = append(result.containers, c)
+		}
+	}
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || (i < len(b.keys) && b.keys[i] < other.keys[j]):
+			if keep(true, false) {
+				push(b.keys[i], b.containers[i].clone())
+			}
+			i++
+		case i == len(b.keys) || other.keys[j] < b.keys[i]:
+			if keep(false, true) {
+				push(other.keys[j], other.containers[j].clone())
+			}
+			j++
+		default:
+			push(b.keys[i], combine(b.containers[i], other.containers[j], op, keep))
+			i++
+			j++
+		}
+	}
+	return result
+}
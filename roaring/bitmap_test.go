@@ -0,0 +1,155 @@
+package roaring
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"feed/treeset"
+)
+
+func TestBitmapMatchesMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	b := New()
+	present := make(map[int64]bool)
+	for i := 0; i < 50000; i++ {
+		// A dense range that crosses the array/bitmap threshold, plus
+		// sparse and negative IDs.
+		var id int64
+		switch rnd.Intn(3) {
+		case 0:
+			id = rnd.Int63n(10000)
+		case 1:
+			id = rnd.Int63() - rnd.Int63()
+		default:
+			id = 1<<40 + rnd.Int63n(100)
+		}
+		if rnd.Intn(4) == 0 {
+			if b.RemoveID(id) != present[id] {
+				t.Fatalf("RemoveID(%v), expected: %v", id, present[id])
+			}
+			delete(present, id)
+		} else {
+			if b.AddID(id) == present[id] {
+				t.Fatalf("AddID(%v), expected: %v", id, !present[id])
+			}
+			present[id] = true
+		}
+	}
+
+	expected := make([]int64, 0, len(present))
+	for id := range present {
+		expected = append(expected, id)
+		if !b.ContainsID(id) {
+			t.Fatalf("expected %v to be present", id)
+		}
+	}
+	sort.Sort(int64s(expected))
+	if !reflect.DeepEqual(b.IDs(), expected) || b.Size() != len(expected) {
+		t.Fatalf("expected %d IDs, got: %d", len(expected), b.Size())
+	}
+}
+
+type int64s []int64
+
+func (s int64s) Len() int           { return len(s) }
+func (s int64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func newBitmap(ids ...int64) *Bitmap {
+	b := New()
+	for _, id := range ids {
+		b.AddID(id)
+	}
+	return b
+}
+
+func denseRange(from, to int64) []int64 {
+	var ids []int64
+	for id := from; id < to; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestBitmapAlgebra(t *testing.T) {
+	// Dense containers exercise the bitmap paths, small ones the array paths.
+	a := newBitmap(append(denseRange(0, 6000), -3, 1<<33)...)
+	b := newBitmap(append(denseRange(3000, 9000), -3, 1<<34)...)
+
+	tests := []struct {
+		name     string
+		actual   *Bitmap
+		expected []int64
+	}{
+		{"Union", a.Union(b), append(append([]int64{-3}, denseRange(0, 9000)...), 1<<33, 1<<34)},
+		{"Inter", a.Inter(b), append([]int64{-3}, denseRange(3000, 6000)...)},
+		{"Diff", a.Diff(b), append(denseRange(0, 3000), 1<<33)},
+		{"Diff all", a.Diff(a), []int64{}},
+	}
+	for _, test := range tests {
+		if actual := test.actual.IDs(); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s, expected %d IDs, got: %d", test.name, len(test.expected), len(actual))
+		}
+	}
+}
+
+func TestBitmapSerialization(t *testing.T) {
+	b := newBitmap(append(denseRange(0, 5000), -7, 1<<50)...)
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := newBitmap(99)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.IDs(), b.IDs()) {
+		t.Errorf("expected %d IDs, got: %d", b.Size(), restored.Size())
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt || !restored.Empty() {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+
+	// Array containers must be non-empty, sorted and free of duplicates.
+	corrupt := map[string][]byte{
+		"empty":     {binaryVersion, 1, 0, kindArray, 0},
+		"unsorted":  {binaryVersion, 1, 0, kindArray, 2, 5, 0, 3, 0},
+		"duplicate": {binaryVersion, 1, 0, kindArray, 2, 5, 0, 5, 0},
+		// High key 1<<48, beyond the 48 bits an ID keeps.
+		"high key": {binaryVersion, 1, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40, kindArray, 1, 5, 0},
+	}
+	for name, data := range corrupt {
+		if err := restored.UnmarshalBinary(data); err != ErrCorrupt || !restored.Empty() {
+			t.Errorf("%s, expected: %v, got: %v", name, ErrCorrupt, err)
+		}
+	}
+	valid := []byte{binaryVersion, 1, 0, kindArray, 2, 3, 0, 5, 0}
+	if err := restored.UnmarshalBinary(valid); err != nil || restored.Size() != 2 {
+		t.Errorf("expected: %v, got: %v (%v)", 2, restored.Size(), err)
+	}
+}
+
+func TestBitmapSmallerThanTreeSet(t *testing.T) {
+	b := newBitmap(denseRange(0, 100000)...)
+	data, _ := b.MarshalBinary()
+	set := treeset.NewWith(treeset.Int64Comparator)
+	for _, id := range denseRange(0, 100000) {
+		set.Add(id)
+	}
+	var encoded bytesCounter
+	if err := set.Encode(&encoded, treeset.Int64Codec); err != nil {
+		t.Fatal(err)
+	}
+	if len(data)*10 > int(encoded) {
+		t.Errorf("expected at least 10x smaller than %d bytes, got: %d", encoded, len(data))
+	}
+}
+
+type bytesCounter int
+
+func (c *bytesCounter) Write(p []byte) (int, error) {
+	*c += bytesCounter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,193 @@
+package roaring
+
+import "sort"
+
+// A container holds the low 16 bits of the IDs sharing the same high bits,
+// as a sorted array while sparse and as a 65536-bit bitmap once it holds more
+// than arrayMax values, the point where the bitmap becomes the smaller one.
+const (
+	arrayMax     = 4096
+	bitmapWords  = 1 << 16 / 64
+	bitmapLength = bitmapWords * 8 // bytes
+)
+
+type container struct {
+	array  []uint16 // sorted, used while bitmap is nil
+	bitmap []uint64
+	card   int
+}
+
+func (c *container) contains(low uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[low/64]&(1<<(low%64)) != 0
+	}
+	i := c.search(low)
+	return i < len(c.array) && c.array[i] == low
+}
+
+func (c *container) search(low uint16) int {
+	return sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+}
+
+func (c *container) add(low uint16) bool {
+	if c.bitmap != nil {
+		word, bit := low/64, uint64(1)<<(low%64)
+		if c.bitmap[word]&bit != 0 {
+			return false
+		}
+		c.bitmap[word] |= bit
+		c.card++
+		return true
+	}
+	i := c.search(low)
+	if i < len(c.array) && c.array[i] == low {
+		return false
+	}
+	c.array = append(c.array, 0)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = low
+	c.card++
+	if c.card > arrayMax {
+		c.toBitmap()
+	}
+	return true
+}
+
+func (c *container) remove(low uint16) bool {
+	if c.bitmap != nil {
+		word, bit := low/64, uint64(1)<<(low%64)
+		if c.bitmap[word]&bit == 0 {
+			return false
+		}
+		c.bitmap[word] &^= bit
+		c.card--
+		if c.card <= arrayMax {
+			c.toArray()
+		}
+		return true
+	}
+	i := c.search(low)
+	if i == len(c.array) || c.array[i] != low {
+		return false
+	}
+	c.array = append(c.array[:i], c.array[i+1:]...)
+	c.card--
+	return true
+}
+
+func (c *container) toBitmap() {
+	c.bitmap = make([]uint64, bitmapWords)
+	for _, low := range c.array {
+		c.bitmap[low/64] |= 1 << (low % 64)
+	}
+	c.array = nil
+}
+
+func (c *container) toArray() {
+	c.array = make([]uint16, 0, c.card)
+	c.each(func(low uint16) {
+		c.array = append(c.array, low)
+	})
+	c.bitmap = nil
+}
+
+// each calls f for every value in ascending order.
+func (c *container) each(f func(low uint16)) {
+	if c.bitmap == nil {
+		for _, low := range c.array {
+			f(low)
+		}
+		return
+	}
+	for word, bits := range c.bitmap {
+		for bits != 0 {
+			bit := bits & -bits
+			f(uint16(word*64 + popcount(bit-1)))
+			bits ^= bit
+		}
+	}
+}
+
+func (c *container) clone() *container {
+	cloned := &container{card: c.card}
+	if c.bitmap != nil {
+		cloned.bitmap = append([]uint64(nil), c.bitmap...)
+	} else {
+		cloned.array = append([]uint16(nil), c.array...)
+	}
+	return cloned
+}
+
+func (c *container) words() []uint64 {
+	if c.bitmap != nil {
+		return c.bitmap
+	}
+	words := make([]uint64, bitmapWords)
+	for _, low := range c.array {
+		words[low/64] |= 1 << (low % 64)
+	}
+	return words
+}
+
+// combine applies op word by word to the bitmaps of a and b and returns the
+// result in its smallest representation, or nil if it is empty. Two arrays
+// are merged directly instead.
+func combine(a, b *container, op func(x, y uint64) uint64, keep func(inA, inB bool) bool) *container {
+	var result *container
+	if a.bitmap == nil && b.bitmap == nil {
+		result = &container{array: mergeArrays(a.array, b.array, keep)}
+		result.card = len(result.array)
+		if result.card > arrayMax {
+			result.toBitmap()
+		}
+	} else {
+		x, y := a.words(), b.words()
+		result = &container{bitmap: make([]uint64, bitmapWords)}
+		for i := range result.bitmap {
+			result.bitmap[i] = op(x[i], y[i])
+			result.card += popcount(result.bitmap[i])
+		}
+		if result.card <= arrayMax {
+			result.toArray()
+		}
+	}
+	if result.card == 0 {
+		return nil
+	}
+	return result
+}
+
+func mergeArrays(a, b []uint16, keep func(inA, inB bool) bool) []uint16 {
+	merged := []uint16{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			if keep(true, false) {
+				merged = append(merged, a[i])
+			}
+			i++
+		case i == len(a) || b[j] < a[i]:
+			if keep(false, true) {
+				merged = append(merged, b[j])
+			}
+			j++
+		default:
+			if keep(true, true) {
+				merged = append(merged, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	return merged
+}
+
+func popcount(x uint64) int {
+	x -= (x >> 1) & 0x5555555555555555
+	x = (x>>2)&0x3333333333333333 + x&0x3333333333333333
+	x += x >> 4
+	x &= 0x0f0f0f0f0f0f0f0f
+	x *= 0x0101010101010101
+	return int(x >> 56)
+}
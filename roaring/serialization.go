@@ -0,0 +1,120 @@
+package roaring
+
+// Binary format: a version byte and the container count as a uvarint, then
+// per container its high key as a uvarint, a kind byte, and either the
+// cardinality as a uvarint followed by that many little-endian uint16s, or
+// the 1024 little-endian words of the bitmap.
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	binaryVersion = 1
+	kindArray     = 0
+	kindBitmap    = 1
+)
+
+var ErrCorrupt = errors.New("corrupt roaring bitmap encoding")
+
+// Implements encoding.BinaryMarshaler.
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	data := []byte{binaryVersion}
+	data = appendUvarint(data, uint64(len(b.keys)))
+	for i, high := range b.keys {
+		c := b.containers[i]
+		data = appendUvarint(data, high)
+		if c.bitmap != nil {
+			data = append(data, kindBitmap)
+			for _, word := range c.bitmap {
+				var buf [8]byte
+				binary.LittleEndian.PutUint64(buf[:], word)
+				data = append(data, buf[:]...)
+			}
+			continue
+		}
+		data = append(data, kindArray)
+		data = appendUvarint(data, uint64(len(c.array)))
+		for _, low := range c.array {
+			data = append(data, byte(low), byte(low>>8))
+		}
+	}
+	return data, nil
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// Implements encoding.BinaryUnmarshaler, replacing the contents of b.
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	b.Clear()
+	if len(data) == 0 || data[0] != binaryVersion {
+		return ErrCorrupt
+	}
+	data = data[1:]
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	for ; count > 0; count-- {
+		high, n := binary.Uvarint(data)
+		// High keys hold the top 48 bits of an ID, so larger ones would alias.
+		if n <= 0 || len(data) < n+1 || high >= 1<<48 || (len(b.keys) > 0 && high <= b.keys[len(b.keys)-1]) {
+			b.Clear()
+			return ErrCorrupt
+		}
+		kind := data[n]
+		data = data[n+1:]
+		c := &container{}
+		switch kind {
+		case kindBitmap:
+			if len(data) < bitmapLength {
+				b.Clear()
+				return ErrCorrupt
+			}
+			c.bitmap = make([]uint64, bitmapWords)
+			for i := range c.bitmap {
+				c.bitmap[i] = binary.LittleEndian.Uint64(data[i*8:])
+				c.card += popcount(c.bitmap[i])
+			}
+			if c.card == 0 {
+				b.Clear()
+				return ErrCorrupt
+			}
+			data = data[bitmapLength:]
+		case kindArray:
+			card, n := binary.Uvarint(data)
+			if n <= 0 || card == 0 || card > arrayMax || uint64(len(data)-n) < 2*card {
+				b.Clear()
+				return ErrCorrupt
+			}
+			data = data[n:]
+			c.array = make([]uint16, card)
+			for i := range c.array {
+				c.array[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+				// Lookups binary search the array, so it must be sorted
+				// and hold each value once.
+				if i > 0 && c.array[i] <= c.array[i-1] {
+					b.Clear()
+					return ErrCorrupt
+				}
+			}
+			c.card = int(card)
+			data = data[2*card:]
+		default:
+			b.Clear()
+			return ErrCorrupt
+		}
+		b.keys = append(b.keys, high)
+		b.containers = append(b.containers, c)
+	}
+	if len(data) != 0 {
+		b.Clear()
+		return ErrCorrupt
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Package sketch holds approximate frequency counters for hot-item detection.
+package sketch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// CountMin is a count-min sketch: it estimates how often each key was
+// counted in memory independent of the number of keys. Estimates never
+// undercount, and overcount by at most epsilon times the total count with
+// probability 1 - delta.
+// Decay scales every counter down, so that with periodic decays the counts
+// favour recent activity as a sliding window would.
+// Structure is not thread safe.
+// References: https://en.wikipedia.org/wiki/Count%E2%80%93min_sketch
+type CountMin struct {
+	width  uint64
+	counts [][]float64 // depth rows of width counters
+	total  float64
+}
+
+// Instantiates a new empty sketch with error bound epsilon (e.g. 0.001) and
+// failure probability delta (e.g. 0.01).
+// Panics if epsilon or delta is not strictly between 0 and 1.
+func NewCountMin(epsilon, delta float64) *CountMin {
+	checkUnit("epsilon", epsilon)
+	checkUnit("delta", delta)
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	if depth < 1 {
+		depth = 1
+	}
+	counts := make([][]float64, depth)
+	for i := range counts {
+		counts[i] = make([]float64, width)
+	}
+	return &CountMin{width: width, counts: counts}
+}
+
+// checkUnit rejects parameters no sketch can be sized for, including NaN.
+func checkUnit(name string, v float64) {
+	if !(v > 0 && v < 1) {
+		panic(fmt.Sprintf("sketch: %s must be in (0, 1), got %v", name, v))
+	}
+}
+
+// indexes calls f with the counter index of key in every row, derived from
+// two hash halves by double hashing.
+func (cm *CountMin) indexes(key string, f func(row int, i uint64)) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum>>32, sum&0xffffffff|1
+	for row := range cm.counts {
+		f(row, (h1+uint64(row)*h2)%cm.width)
+	}
+}
+
+// Counts key once and returns its new estimate.
+func (cm *CountMin) Incr(key string) float64 {
+	return cm.IncrBy(key, 1)
+}
+
+// Counts key delta times and returns its new estimate.
+func (cm *CountMin) IncrBy(key string, delta float64) float64 {
+	estimate := math.Inf(1)
+	cm.indexes(key, func(row int, i uint64) {
+		cm.counts[row][i] += delta
+		estimate = math.Min(estimate, cm.counts[row][i])
+	})
+	cm.total += delta
+	return estimate
+}
+
+// Returns the estimated count of key.
+func (cm *CountMin) Estimate(key string) float64 {
+	estimate := math.Inf(1)
+	cm.indexes(key, func(row int, i uint64) {
+		estimate = math.Min(estimate, cm.counts[row][i])
+	})
+	return estimate
+}
+
+// Multiplies every count by factor, e.g. 0.5 to halve them.
+func (cm *CountMin) Decay(factor float64) {
+	for _, row := range cm.counts {
+		for i := range row {
+			row[i] *= factor
+		}
+	}
+	cm.total *= factor
+}
+
+// Returns the sum of all counts.
+func (cm *CountMin) Total() float64 {
+	return cm.total
+}
+
+// Clears all counts.
+func (cm *CountMin) Clear() {
+	for _, row := range cm.counts {
+		for i := range row {
+			row[i] = 0
+		}
+	}
+	cm.total = 0
+}
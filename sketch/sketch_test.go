@@ -0,0 +1,98 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCountMinEstimate(t *testing.T) {
+	cm := NewCountMin(0.001, 0.01)
+	exact := make(map[string]float64)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		key := fmt.Sprint(rnd.Intn(5000))
+		cm.Incr(key)
+		exact[key]++
+	}
+	bound := 0.001 * cm.Total()
+	for key, count := range exact {
+		estimate := cm.Estimate(key)
+		if estimate < count {
+			t.Fatalf("%s, expected at least %v, got: %v", key, count, estimate)
+		}
+		if estimate > count+bound {
+			t.Errorf("%s, expected at most %v, got: %v", key, count+bound, estimate)
+		}
+	}
+}
+
+func TestCountMinDecay(t *testing.T) {
+	cm := NewCountMin(0.01, 0.01)
+	cm.IncrBy("a", 8)
+	cm.Decay(0.5)
+	if actual := cm.Estimate("a"); actual != 4 {
+		t.Errorf("expected: %v, got: %v", 4, actual)
+	}
+	if actual := cm.Total(); actual != 4 {
+		t.Errorf("expected: %v, got: %v", 4, actual)
+	}
+	cm.Clear()
+	if actual := cm.Estimate("a"); actual != 0 {
+		t.Errorf("expected: %v, got: %v", 0, actual)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tk := NewTopK(3, 0.001, 0.01)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		tk.Incr(fmt.Sprint("cold", rnd.Intn(1000)))
+		if i%4 == 0 {
+			tk.Incr("hot1")
+		}
+		if i%5 == 0 {
+			tk.Incr("hot2")
+		}
+		if i%6 == 0 {
+			tk.Incr("hot3")
+		}
+	}
+	top := tk.Top()
+	if len(top) != 3 || top[0].Key != "hot1" || top[1].Key != "hot2" || top[2].Key != "hot3" {
+		t.Fatalf("expected: %v, got: %v", []string{"hot1", "hot2", "hot3"}, top)
+	}
+	if top[0].Count < 5000 {
+		t.Errorf("expected at least %v, got: %v", 5000, top[0].Count)
+	}
+}
+
+func TestTopKDecay(t *testing.T) {
+	tk := NewTopK(2, 0.001, 0.01)
+	tk.IncrBy("old", 100)
+	tk.IncrBy("older", 90)
+	tk.Decay(0.01)
+	tk.IncrBy("new", 5)
+	top := tk.Top()
+	if len(top) != 2 || top[0].Key != "new" || top[1].Key != "old" || top[1].Count != 1 {
+		t.Errorf("expected new then old, got: %v", top)
+	}
+	tk.Clear()
+	if len(tk.Top()) != 0 {
+		t.Errorf("expected empty top, got: %v", tk.Top())
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	for _, params := range [][2]float64{{0, 0.01}, {-1, 0.01}, {1, 0.01}, {math.NaN(), 0.01}, {0.01, 0}, {0.01, 1}, {0.01, -0.5}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected NewCountMin(%v, %v) to panic", params[0], params[1])
+				}
+			}()
+			NewCountMin(params[0], params[1])
+		}()
+	}
+}
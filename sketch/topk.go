@@ -0,0 +1,105 @@
+package sketch
+
+import (
+	"strings"
+
+	"feed/treeset"
+)
+
+// Entry is a key and its estimated count.
+type Entry struct {
+	Key   string
+	Count float64
+}
+
+// byCount orders entries by count, then by key so that equal counts stay
+// distinct members of the set.
+func byCount(a, b interface{}) int {
+	x, y := a.(Entry), b.(Entry)
+	switch {
+	case x.Count < y.Count:
+		return -1
+	case x.Count > y.Count:
+		return 1
+	default:
+		return strings.Compare(x.Key, y.Key)
+	}
+}
+
+// TopK tracks the k most frequent keys, the heavy hitters, using a CountMin
+// sketch for the counts and a bounded treeset for the current leaders.
+// Structure is not thread safe.
+type TopK struct {
+	sketch  *CountMin
+	top     *treeset.BoundedSet // Entry by count, smallest evicted first
+	members map[string]float64  // key -> count of its Entry in top
+}
+
+// Instantiates a new empty tracker of the k most frequent keys over a sketch
+// with error bound epsilon and failure probability delta, which must be
+// strictly between 0 and 1.
+func NewTopK(k int, epsilon, delta float64) *TopK {
+	return &TopK{
+		sketch:  NewCountMin(epsilon, delta),
+		top:     treeset.NewBounded(byCount, k, treeset.EvictSmallest),
+		members: make(map[string]float64),
+	}
+}
+
+// Counts key once and returns its new estimate.
+func (tk *TopK) Incr(key string) float64 {
+	return tk.IncrBy(key, 1)
+}
+
+// Counts key delta times and returns its new estimate.
+func (tk *TopK) IncrBy(key string, delta float64) float64 {
+	count := tk.sketch.IncrBy(key, delta)
+	tk.offer(Entry{Key: key, Count: count})
+	return count
+}
+
+func (tk *TopK) offer(entry Entry) {
+	if old, found := tk.members[entry.Key]; found {
+		tk.top.Remove(Entry{Key: entry.Key, Count: old})
+	}
+	tk.members[entry.Key] = entry.Count
+	for _, evicted := range tk.top.Add(entry) {
+		delete(tk.members, evicted.(Entry).Key)
+	}
+}
+
+// Returns the estimated count of key.
+func (tk *TopK) Estimate(key string) float64 {
+	return tk.sketch.Estimate(key)
+}
+
+// Multiplies every count by factor, so that keys that stopped being counted
+// drop out of the top in favour of recent ones.
+func (tk *TopK) Decay(factor float64) {
+	tk.sketch.Decay(factor)
+	entries := tk.top.Values()
+	tk.top.Clear()
+	for _, entry := range entries {
+		key := entry.(Entry).Key
+		count := tk.sketch.Estimate(key)
+		tk.members[key] = count
+		tk.top.Add(Entry{Key: key, Count: count})
+	}
+}
+
+// Returns the tracked keys, most frequent first.
+func (tk *TopK) Top() []Entry {
+	values := tk.top.Values()
+	entries := make([]Entry, len(values))
+	for i, value := range values {
+		entries[len(values)-1-i] = value.(Entry)
+	}
+	return entries
+}
+
+// Clears all counts and tracked keys.
+func (tk *TopK) Clear() {
+	tk.sketch.Clear()
+	tk.top.Clear()
+	tk.members = make(map[string]float64)
+}
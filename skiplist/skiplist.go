@@ -0,0 +1,303 @@
+// Package skiplist is an ordered set backed by a concurrent skip list, an
+// alternative to treeset for workloads dominated by concurrent inserts.
+//
+// Unlike treeset it is safe for concurrent use without external locking.
+// It implements the lazy skip list of Herlihy, Lev, Luchangco and Shavit:
+// Contains never locks, and Add and Remove only lock the few predecessors of
+// the node they change, so writers on different parts of the set proceed in
+// parallel. The price is probabilistic rather than worst-case balance and
+// roughly twice the memory per item of a red-black tree.
+// References: http://people.csail.mit.edu/shanir/publications/LazySkipList.pdf
+package skiplist
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/emirpasic/gods/sets"
+	"github.com/emirpasic/gods/utils"
+)
+
+func assertInterfaceImplementation() {
+	var _ sets.Set = (*Set)(nil)
+}
+
+const (
+	maxLevel = 16 // enough for 4^16 items at p = 1/4
+)
+
+type node struct {
+	key         interface{}
+	next        []unsafe.Pointer // *node per level, nil past the last node
+	marked      int32            // logically removed
+	fullyLinked int32            // linked at all its levels
+	mu          sync.Mutex
+}
+
+func (n *node) nextAt(level int) *node {
+	return (*node)(atomic.LoadPointer(&n.next[level]))
+}
+
+func (n *node) setNextAt(level int, next *node) {
+	atomic.StorePointer(&n.next[level], unsafe.Pointer(next))
+}
+
+func (n *node) isMarked() bool {
+	return atomic.LoadInt32(&n.marked) == 1
+}
+
+func (n *node) isFullyLinked() bool {
+	return atomic.LoadInt32(&n.fullyLinked) == 1
+}
+
+// list is swapped out as a whole by Clear.
+type list struct {
+	head *node
+	size int64
+}
+
+func newList() *list {
+	return &list{head: &node{next: make([]unsafe.Pointer, maxLevel)}}
+}
+
+type Set struct {
+	list       unsafe.Pointer // *list
+	comparator utils.Comparator
+	seed       uint64
+}
+
+// Instantiates a new empty set with the custom comparator.
+func NewWith(comparator utils.Comparator) *Set {
+	return &Set{list: unsafe.Pointer(newList()), comparator: comparator}
+}
+
+// Instantiates a new empty set with the IntComparator, i.e. keys are of type int.
+func NewWithIntComparator() *Set {
+	return NewWith(utils.IntComparator)
+}
+
+// Instantiates a new empty set with the StringComparator, i.e. keys are of type string.
+func NewWithStringComparator() *Set {
+	return NewWith(utils.StringComparator)
+}
+
+func (set *Set) load() *list {
+	return (*list)(atomic.LoadPointer(&set.list))
+}
+
+// randomLevel returns a level in [1, maxLevel] with P(level > k) = 4^-k.
+// It hashes an atomic counter (splitmix64) so concurrent writers do not
+// contend on a shared random source.
+func (set *Set) randomLevel() int {
+	z := atomic.AddUint64(&set.seed, 0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	level := 1
+	for level < maxLevel && z&3 == 0 {
+		level++
+		z >>= 2
+	}
+	return level
+}
+
+// find fills preds and succs with the nodes around key at every level and
+// returns the highest level at which a node holding key was found, or -1.
+func (set *Set) find(l *list, key interface{}, preds, succs *[maxLevel]*node) int {
+	found := -1
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.nextAt(level)
+		for curr != nil && set.comparator(key, curr.key) > 0 {
+			pred = curr
+			curr = pred.nextAt(level)
+		}
+		if found == -1 && curr != nil && set.comparator(key, curr.key) == 0 {
+			found = level
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return found
+}
+
+// unlockPreds unlocks the distinct predecessors locked at levels [0, highest].
+func unlockPreds(preds *[maxLevel]*node, highest int) {
+	var prev *node
+	for level := 0; level <= highest; level++ {
+		if preds[level] != prev {
+			preds[level].mu.Unlock()
+			prev = preds[level]
+		}
+	}
+}
+
+// Adds the items (one or more) to the set.
+func (set *Set) Add(items ...interface{}) {
+	l := set.load()
+	for _, item := range items {
+		set.add(l, item)
+	}
+}
+
+func (set *Set) add(l *list, key interface{}) bool {
+	topLevel := set.randomLevel()
+	var preds, succs [maxLevel]*node
+	for {
+		if found := set.find(l, key, &preds, &succs); found != -1 {
+			existing := succs[found]
+			if !existing.isMarked() {
+				// Wait for a concurrent Add of the same key to finish linking.
+				for !existing.isFullyLinked() {
+					runtime.Gosched()
+				}
+				return false
+			}
+			continue // being removed, retry
+		}
+
+		highestLocked, valid := -1, true
+		var prev *node
+		for level := 0; valid && level < topLevel; level++ {
+			pred, succ := preds[level], succs[level]
+			if pred != prev {
+				pred.mu.Lock()
+				highestLocked, prev = level, pred
+			}
+			valid = !pred.isMarked() && (succ == nil || !succ.isMarked()) && pred.nextAt(level) == succ
+		}
+		if !valid {
+			unlockPreds(&preds, highestLocked)
+			continue
+		}
+
+		inserted := &node{key: key, next: make([]unsafe.Pointer, topLevel)}
+		for level := 0; level < topLevel; level++ {
+			inserted.next[level] = unsafe.Pointer(succs[level])
+		}
+		for level := 0; level < topLevel; level++ {
+			preds[level].setNextAt(level, inserted)
+		}
+		atomic.StoreInt32(&inserted.fullyLinked, 1)
+		unlockPreds(&preds, highestLocked)
+		atomic.AddInt64(&l.size, 1)
+		return true
+	}
+}
+
+// Removes the items (one or more) from the set.
+func (set *Set) Remove(items ...interface{}) {
+	l := set.load()
+	for _, item := range items {
+		set.remove(l, item)
+	}
+}
+
+func (set *Set) remove(l *list, key interface{}) bool {
+	var preds, succs [maxLevel]*node
+	var victim *node
+	marked := false
+	for {
+		found := set.find(l, key, &preds, &succs)
+		if !marked {
+			if found == -1 {
+				return false
+			}
+			victim = succs[found]
+			if !victim.isFullyLinked() || len(victim.next)-1 != found || victim.isMarked() {
+				return false
+			}
+			victim.mu.Lock()
+			if victim.isMarked() {
+				victim.mu.Unlock()
+				return false
+			}
+			atomic.StoreInt32(&victim.marked, 1)
+			marked = true
+		}
+
+		topLevel := len(victim.next)
+		highestLocked, valid := -1, true
+		var prev *node
+		for level := 0; valid && level < topLevel; level++ {
+			pred := preds[level]
+			if pred != prev {
+				pred.mu.Lock()
+				highestLocked, prev = level, pred
+			}
+			valid = !pred.isMarked() && pred.nextAt(level) == victim
+		}
+		if !valid {
+			unlockPreds(&preds, highestLocked)
+			continue
+		}
+
+		for level := topLevel - 1; level >= 0; level-- {
+			preds[level].setNextAt(level, victim.nextAt(level))
+		}
+		victim.mu.Unlock()
+		unlockPreds(&preds, highestLocked)
+		atomic.AddInt64(&l.size, -1)
+		return true
+	}
+}
+
+// Check wether items (one or more) are present in the set.
+// All items have to be present in the set for the method to return true.
+// Returns true if no arguments are passed at all, i.e. set is always superset of empty set.
+func (set *Set) Contains(items ...interface{}) bool {
+	l := set.load()
+	for _, item := range items {
+		if !set.contains(l, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (set *Set) contains(l *list, key interface{}) bool {
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.nextAt(level)
+		for curr != nil && set.comparator(key, curr.key) > 0 {
+			pred = curr
+			curr = pred.nextAt(level)
+		}
+		if curr != nil && set.comparator(key, curr.key) == 0 {
+			return curr.isFullyLinked() && !curr.isMarked()
+		}
+	}
+	return false
+}
+
+// Returns true if set does not contain any elements.
+func (set *Set) Empty() bool {
+	return set.Size() == 0
+}
+
+// Returns number of elements within the set.
+func (set *Set) Size() int {
+	return int(atomic.LoadInt64(&set.load().size))
+}
+
+// Clears all values in the set. Operations running concurrently with Clear
+// take effect either before it or not at all.
+func (set *Set) Clear() {
+	atomic.StorePointer(&set.list, unsafe.Pointer(newList()))
+}
+
+// Returns all items in the set in ascending order. The walk is weakly
+// consistent under concurrent updates: it sees every item present for its
+// whole duration, and may or may not see items added or removed meanwhile.
+func (set *Set) Values() []interface{} {
+	l := set.load()
+	values := make([]interface{}, 0, atomic.LoadInt64(&l.size))
+	for curr := l.head.nextAt(0); curr != nil; curr = curr.nextAt(0) {
+		if curr.isFullyLinked() && !curr.isMarked() {
+			values = append(values, curr.key)
+		}
+	}
+	return values
+}
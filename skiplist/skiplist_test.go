@@ -0,0 +1,115 @@
+package skiplist
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"feed/treeset"
+)
+
+func TestSetMatchesMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	set := NewWithIntComparator()
+	present := make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		key := rnd.Intn(500)
+		if rnd.Intn(3) == 0 {
+			set.Remove(key)
+			delete(present, key)
+		} else {
+			set.Add(key)
+			present[key] = true
+		}
+		if set.Contains(key) != present[key] {
+			t.Fatalf("Contains(%v), expected: %v", key, present[key])
+		}
+	}
+
+	expected := make([]interface{}, 0, len(present))
+	keys := make([]int, 0, len(present))
+	for key := range present {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	for _, key := range keys {
+		expected = append(expected, key)
+	}
+	if !reflect.DeepEqual(set.Values(), expected) || set.Size() != len(expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	set.Clear()
+	if !set.Empty() || len(set.Values()) != 0 {
+		t.Errorf("expected an empty set, got: %v", set.Values())
+	}
+}
+
+func TestSetConcurrentWriters(t *testing.T) {
+	set := NewWithIntComparator()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Writers overlap on half of their keys and remove the odd ones.
+			for i := 0; i < 1000; i++ {
+				key := w*500 + i
+				set.Add(key)
+				if key%2 == 1 {
+					set.Remove(key)
+				}
+				set.Contains(key - 1)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	values := set.Values()
+	if len(values) != set.Size() || len(values) != 2250 {
+		t.Fatalf("expected: %v, got: %v (size %v)", 2250, len(values), set.Size())
+	}
+	for i, value := range values {
+		if value.(int) != 2*i {
+			t.Fatalf("expected: %v, got: %v", 2*i, value)
+		}
+	}
+}
+
+// The fan-out workload: many goroutines inserting into one shared set.
+
+func BenchmarkParallelAddSkipList(b *testing.B) {
+	set := NewWithIntComparator()
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			set.Add(rnd.Intn(1 << 16))
+		}
+	})
+}
+
+func BenchmarkParallelAddTreeSet(b *testing.B) {
+	set := treeset.NewSyncSet(treeset.NewWithIntComparator())
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			set.Add(rnd.Intn(1 << 16))
+		}
+	})
+}
+
+func BenchmarkAddSkipList(b *testing.B) {
+	set := NewWithIntComparator()
+	for i := 0; i < b.N; i++ {
+		set.Add(i * 7919 % (1 << 20))
+	}
+}
+
+func BenchmarkAddTreeSet(b *testing.B) {
+	set := treeset.NewWithIntComparator()
+	for i := 0; i < b.N; i++ {
+		set.Add(i * 7919 % (1 << 20))
+	}
+}
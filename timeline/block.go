@@ -0,0 +1,152 @@
+package timeline
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockList is what a viewer must not be shown.
+type BlockList struct {
+	// Authors are the authors the viewer muted or blocked, or who blocked
+	// the viewer.
+	Authors map[uint64]bool
+	// Keywords are muted words, matched case-insensitively in payloads.
+	Keywords []string
+}
+
+// Returns true if the block list hides item.
+func (bl *BlockList) Blocks(item *Item) bool {
+	if bl.Authors[item.AuthorID] {
+		return true
+	}
+	if len(bl.Keywords) == 0 {
+		return false
+	}
+	payload := bytes.ToLower(item.Payload)
+	for _, keyword := range bl.Keywords {
+		if keyword != "" && bytes.Contains(payload, []byte(strings.ToLower(keyword))) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockListProvider returns the block lists of viewers, e.g. from the
+// service owning mutes and blocks.
+// Implementations must be safe for concurrent use.
+type BlockListProvider interface {
+	BlockList(ctx context.Context, viewerID uint64) (*BlockList, error)
+}
+
+// CachedBlockLists is a BlockListProvider caching the block lists of another
+// one for a while, so that reads do not call it every time. The least
+// recently used list is dropped once the cache is full.
+// Structure is thread safe.
+type CachedBlockLists struct {
+	provider   BlockListProvider
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	lists   map[uint64]*list.Element
+	recency *list.List // of *cachedBlockList, most recently used first
+}
+
+type cachedBlockList struct {
+	viewerID uint64
+	list     *BlockList
+	deadline time.Time
+}
+
+// Instantiates a new cache of up to maxEntries block lists of provider, kept
+// for ttl. It panics if maxEntries < 1.
+func NewCachedBlockLists(provider BlockListProvider, maxEntries int, ttl time.Duration) *CachedBlockLists {
+	if maxEntries < 1 {
+		panic("timeline: maxEntries must be positive")
+	}
+	return &CachedBlockLists{
+		provider:   provider,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		lists:      make(map[uint64]*list.Element),
+		recency:    list.New(),
+	}
+}
+
+func (cbl *CachedBlockLists) BlockList(ctx context.Context, viewerID uint64) (*BlockList, error) {
+	if blocks, found := cbl.get(viewerID); found {
+		return blocks, nil
+	}
+	blocks, err := cbl.provider.BlockList(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	cbl.put(viewerID, blocks)
+	return blocks, nil
+}
+
+func (cbl *CachedBlockLists) get(viewerID uint64) (*BlockList, bool) {
+	cbl.mu.Lock()
+	defer cbl.mu.Unlock()
+	element, found := cbl.lists[viewerID]
+	if !found {
+		return nil, false
+	}
+	cached := element.Value.(*cachedBlockList)
+	if cbl.ttl > 0 && !cbl.now().Before(cached.deadline) {
+		cbl.recency.Remove(element)
+		delete(cbl.lists, viewerID)
+		return nil, false
+	}
+	cbl.recency.MoveToFront(element)
+	return cached.list, true
+}
+
+func (cbl *CachedBlockLists) put(viewerID uint64, blocks *BlockList) {
+	cbl.mu.Lock()
+	defer cbl.mu.Unlock()
+	cached := &cachedBlockList{viewerID: viewerID, list: blocks, deadline: cbl.now().Add(cbl.ttl)}
+	if element, found := cbl.lists[viewerID]; found {
+		element.Value = cached
+		cbl.recency.MoveToFront(element)
+		return
+	}
+	cbl.lists[viewerID] = cbl.recency.PushFront(cached)
+	if cbl.recency.Len() > cbl.maxEntries {
+		oldest := cbl.recency.Back()
+		cbl.recency.Remove(oldest)
+		delete(cbl.lists, oldest.Value.(*cachedBlockList).viewerID)
+	}
+}
+
+// Drops the cached block list of a viewer, e.g. after they muted someone, so
+// that their change shows at once.
+func (cbl *CachedBlockLists) Invalidate(viewerID uint64) {
+	cbl.mu.Lock()
+	defer cbl.mu.Unlock()
+	if element, found := cbl.lists[viewerID]; found {
+		cbl.recency.Remove(element)
+		delete(cbl.lists, viewerID)
+	}
+}
+
+// blockFilter returns a filter leaving out what the viewer's block list
+// hides, or nil if there are no block lists.
+func (s *Service) blockFilter(ctx context.Context, viewerID uint64) (func(item *Item) bool, error) {
+	if s.options.BlockLists == nil {
+		return nil, nil
+	}
+	blocks, err := s.options.BlockLists.BlockList(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return func(item *Item) bool {
+		return !blocks.Blocks(item)
+	}, nil
+}
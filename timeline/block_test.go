@@ -0,0 +1,84 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// countingBlockLists serves one block list, counting the calls.
+type countingBlockLists struct {
+	list  *BlockList
+	err   error
+	calls int
+}
+
+func (c *countingBlockLists) BlockList(ctx context.Context, viewerID uint64) (*BlockList, error) {
+	c.calls++
+	return c.list, c.err
+}
+
+func TestBlockLists(t *testing.T) {
+	ctx := context.Background()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	graph.Follow(1, 11)
+	provider := &countingBlockLists{list: &BlockList{Authors: map[uint64]bool{11: true}, Keywords: []string{"Spoiler"}}}
+	lists := NewCachedBlockLists(provider, 10, time.Hour)
+	s := NewService(NewMemoryStorage(), graph, Options{BlockLists: lists})
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1, Payload: []byte("hello")})
+	s.Publish(ctx, &Item{ID: 2, AuthorID: 11, Timestamp: 2, Payload: []byte("hello")})
+	s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3, Payload: []byte("SPOILERS ahead")})
+	s.Pin(ctx, UserFeed(11), 2)
+	s.Wait()
+
+	for i := 0; i < 2; i++ {
+		page, err := s.Read(ctx, 1, ReadOptions{})
+		if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{1}) {
+			t.Errorf("expected: %v, got: %v (%v)", []uint64{1}, readIDs(page.Items), err)
+		}
+	}
+	if page, _ := s.ReadFeed(ctx, 1, UserFeed(11), ReadOptions{}); len(page.Items) != 0 || len(page.Pinned) != 0 {
+		t.Errorf("expected an empty page, got: %v %v", readIDs(page.Pinned), readIDs(page.Items))
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected: %v, got: %v", 1, provider.calls)
+	}
+
+	// The viewer unmutes the keyword.
+	provider.list = &BlockList{Authors: map[uint64]bool{11: true}}
+	lists.Invalidate(1)
+	if page, _ := s.Read(ctx, 1, ReadOptions{}); !reflect.DeepEqual(readIDs(page.Items), []uint64{3, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{3, 1}, readIDs(page.Items))
+	}
+
+	// Reads fail rather than show blocked content.
+	provider.err = errors.New("unavailable")
+	lists.Invalidate(1)
+	if _, err := s.Read(ctx, 1, ReadOptions{}); err != provider.err {
+		t.Errorf("expected: %v, got: %v", provider.err, err)
+	}
+}
+
+func TestCachedBlockListsEviction(t *testing.T) {
+	ctx := context.Background()
+	provider := &countingBlockLists{list: &BlockList{}}
+	lists := NewCachedBlockLists(provider, 2, time.Minute)
+	now := time.Unix(0, 0)
+	lists.now = func() time.Time { return now }
+	for _, viewerID := range []uint64{1, 2, 1, 3, 1, 2} {
+		lists.BlockList(ctx, viewerID)
+	}
+	// 2 was the least recently used when 3 came in.
+	if provider.calls != 4 {
+		t.Errorf("expected: %v, got: %v", 4, provider.calls)
+	}
+	now = now.Add(time.Minute)
+	lists.BlockList(ctx, 1)
+	if provider.calls != 5 {
+		t.Errorf("expected: %v, got: %v", 5, provider.calls)
+	}
+}
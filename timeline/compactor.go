@@ -0,0 +1,147 @@
+package timeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CompactorOptions configure a Compactor. Zero values select the defaults.
+type CompactorOptions struct {
+	// Interval is the pause between compaction passes. Defaults to 1m.
+	Interval time.Duration
+	// TTL, if set, returns how long the entries of a timeline are kept
+	// after their timestamp, or 0 to keep them.
+	TTL func(feedID string) time.Duration
+	// MaxLen, if set, returns the number of entries a timeline keeps, the
+	// oldest beyond it being trimmed, or 0 for no bound.
+	MaxLen func(feedID string) int
+	// BatchSize is the number of timelines listed and of entries scanned
+	// per storage call. Defaults to 1000.
+	BatchSize int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// OnError, if set, is called for every timeline that could not be
+	// compacted, and with an empty feedID if timelines could not be listed.
+	OnError func(feedID string, err error)
+}
+
+func (o *CompactorOptions) setDefaults() {
+	if o.Interval <= 0 {
+		o.Interval = time.Minute
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+}
+
+// Compactor removes expired entries from timelines in the background: those
+// of items past their ExpireAt and those older than their timeline's TTL.
+// It also trims timelines grown beyond their MaxLen. Readers leave expired
+// items out already, so compaction only reclaims space; item payloads are
+// kept, as other timelines may still reference them.
+// Structure is thread safe.
+type Compactor struct {
+	storage Storage
+	options CompactorOptions
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+}
+
+// Instantiates a new compactor and starts its background passes.
+func NewCompactor(storage Storage, options CompactorOptions) *Compactor {
+	options.setDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Compactor{storage: storage, options: options, cancel: cancel}
+	c.done.Add(1)
+	go c.run(ctx)
+	return c
+}
+
+func (c *Compactor) run(ctx context.Context) {
+	defer c.done.Done()
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Compact(ctx); err != nil && ctx.Err() == nil && c.options.OnError != nil {
+				c.options.OnError("", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Runs one compaction pass over every timeline. It fails only if timelines
+// cannot be listed; timelines failing to compact are reported to OnError
+// and skipped.
+func (c *Compactor) Compact(ctx context.Context) error {
+	after := ""
+	for {
+		feedIDs, err := c.storage.ListFeeds(ctx, after, c.options.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, feedID := range feedIDs {
+			if err := c.compactFeed(ctx, feedID); err != nil && c.options.OnError != nil {
+				c.options.OnError(feedID, err)
+			}
+		}
+		if len(feedIDs) < c.options.BatchSize {
+			return nil
+		}
+		after = feedIDs[len(feedIDs)-1]
+	}
+}
+
+// compactFeed scans the whole timeline, as items can expire anywhere in it,
+// deleting expired entries batch by batch, then trims it.
+func (c *Compactor) compactFeed(ctx context.Context, feedID string) error {
+	now := timestampOf(c.options.Now())
+	var ttl time.Duration
+	if c.options.TTL != nil {
+		ttl = c.options.TTL(feedID)
+	}
+	var from *Position
+	for {
+		entries, err := c.storage.RangeByCursor(ctx, feedID, from, Older, c.options.BatchSize)
+		if err != nil {
+			return err
+		}
+		expired := []uint64{}
+		for _, entry := range entries {
+			if entry.Expired(now) || (ttl > 0 && entry.Timestamp <= now-int64(ttl/time.Millisecond)) {
+				expired = append(expired, entry.ItemID)
+			}
+		}
+		if len(expired) > 0 {
+			if err := c.storage.Delete(ctx, feedID, expired...); err != nil {
+				return err
+			}
+		}
+		if len(entries) < c.options.BatchSize {
+			break
+		}
+		position := entries[len(entries)-1].Position()
+		from = &position
+	}
+	if c.options.MaxLen != nil {
+		if maxLen := c.options.MaxLen(feedID); maxLen > 0 {
+			if _, err := c.storage.Trim(ctx, feedID, maxLen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stops the background passes, waiting for a running one to give up.
+func (c *Compactor) Close() {
+	c.cancel()
+	c.done.Wait()
+}
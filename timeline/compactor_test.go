@@ -0,0 +1,79 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1473000000, 0)
+	ms := timestampOf(now)
+	hour := int64(time.Hour / time.Millisecond)
+	storage := NewMemoryStorage()
+	entries := []Entry{
+		{ItemID: 1, Timestamp: ms - 48*hour},
+		{ItemID: 2, Timestamp: ms - 2*hour, ExpireAt: ms - hour},
+		{ItemID: 3, Timestamp: ms - hour, ExpireAt: ms + hour},
+		{ItemID: 4, Timestamp: ms},
+	}
+	for _, feedID := range []string{"home:1", "topic:go", "user:1"} {
+		storage.AppendItems(ctx, feedID, entries...)
+	}
+
+	c := NewCompactor(storage, CompactorOptions{
+		Interval: time.Hour,
+		// Scan one timeline and one entry at a time to exercise paging.
+		BatchSize: 1,
+		Now:       func() time.Time { return now },
+		TTL: func(feedID string) time.Duration {
+			if strings.HasPrefix(feedID, "topic:") {
+				return 24 * time.Hour
+			}
+			return 0
+		},
+		MaxLen: func(feedID string) int {
+			if strings.HasPrefix(feedID, "home:") {
+				return 1
+			}
+			return 0
+		},
+	})
+	defer c.Close()
+	if err := c.Compact(ctx); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	tests := []struct {
+		feedID   string
+		expected []uint64
+	}{
+		{"home:1", []uint64{4}},
+		{"topic:go", []uint64{4, 3}},
+		{"user:1", []uint64{4, 3, 1}},
+	}
+	for _, test := range tests {
+		if actual := itemIDs(mustRange(t, storage, test.feedID)); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.feedID, test.expected, actual)
+		}
+	}
+}
+
+func TestReadExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1473000000, 0)
+	s := NewService(NewMemoryStorage(), NewMemoryGraph(), Options{Now: func() time.Time { return now }})
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1})
+	s.Publish(ctx, &Item{ID: 2, AuthorID: 10, Timestamp: 2, ExpireAt: timestampOf(now)})
+	s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3, ExpireAt: timestampOf(now) + 1})
+	s.Wait()
+
+	page, err := s.ReadFeed(ctx, 1, UserFeed(10), ReadOptions{})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{3, 1}) {
+		t.Errorf("expected: %v, got: %v (%v)", []uint64{3, 1}, readIDs(page.Items), err)
+	}
+}
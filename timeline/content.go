@@ -0,0 +1,59 @@
+package timeline
+
+import "strings"
+
+// ItemType is the kind of content of an item.
+type ItemType string
+
+const (
+	// Post is plain content, e.g. text.
+	Post ItemType = ""
+	// Photo items hold photos.
+	Photo ItemType = "photo"
+	// Video items hold videos.
+	Video ItemType = "video"
+	// Repost items share another item.
+	Repost ItemType = "repost"
+	// Live items are live streams.
+	Live ItemType = "live"
+)
+
+// contentFilters returns the filters of the item types and languages the
+// read options include or exclude.
+func contentFilters(options ReadOptions) []Filter {
+	filters := []Filter{}
+	if len(options.Types) > 0 || len(options.ExcludeTypes) > 0 {
+		filters = append(filters, Filter{Name: "type", Keep: func(item *Item) bool {
+			return (len(options.Types) == 0 || hasType(options.Types, item.Type)) && !hasType(options.ExcludeTypes, item.Type)
+		}})
+	}
+	if len(options.Languages) > 0 || len(options.ExcludeLanguages) > 0 {
+		filters = append(filters, Filter{Name: "language", Keep: func(item *Item) bool {
+			if len(options.Languages) > 0 && (item.Language == "" || !hasLanguage(options.Languages, item.Language)) {
+				return false
+			}
+			return item.Language == "" || !hasLanguage(options.ExcludeLanguages, item.Language)
+		}})
+	}
+	return filters
+}
+
+func hasType(types []ItemType, t ItemType) bool {
+	for _, other := range types {
+		if other == t {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLanguage returns true if the language tag matches one of languages,
+// itself or as a regional variant, ignoring case.
+func hasLanguage(languages []string, tag string) bool {
+	for _, language := range languages {
+		if strings.EqualFold(tag, language) || len(tag) > len(language) && tag[len(language)] == '-' && strings.EqualFold(tag[:len(language)], language) {
+			return true
+		}
+	}
+	return false
+}
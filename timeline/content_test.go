@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestContentFilters(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(PushDelivery)
+	defer s.Close()
+	items := []*Item{
+		{ID: 1, Type: Photo, Language: "en"},
+		{ID: 2, Type: Video, Language: "en-GB"},
+		{ID: 3, Type: Repost, Language: "fr"},
+		{ID: 4, Type: Live},
+		{ID: 5, Language: "EN-us"},
+	}
+	for _, item := range items {
+		item.AuthorID, item.Timestamp = 10, int64(item.ID)
+		s.Publish(ctx, item)
+	}
+	s.Wait()
+
+	tests := []struct {
+		options  ReadOptions
+		expected []uint64
+	}{
+		{ReadOptions{}, []uint64{5, 4, 3, 2, 1}},
+		{ReadOptions{Types: []ItemType{Photo, Video}}, []uint64{2, 1}},
+		{ReadOptions{ExcludeTypes: []ItemType{Repost, Live}}, []uint64{5, 2, 1}},
+		{ReadOptions{Types: []ItemType{Post}}, []uint64{5}},
+		{ReadOptions{Languages: []string{"en"}}, []uint64{5, 2, 1}},
+		{ReadOptions{Languages: []string{"en-GB", "fr"}}, []uint64{3, 2}},
+		{ReadOptions{ExcludeLanguages: []string{"en"}}, []uint64{4, 3}},
+		{ReadOptions{Types: []ItemType{Photo, Video}, ExcludeLanguages: []string{"en-gb"}}, []uint64{1}},
+		// Filters apply before the page is cut, so pages stay full.
+		{ReadOptions{Limit: 2, Languages: []string{"en"}}, []uint64{5, 2}},
+	}
+	for _, test := range tests {
+		page, err := s.Read(ctx, 1, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := readIDs(page.Items); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%+v, expected: %v, got: %v", test.options, test.expected, ids)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package timeline
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrBadCursor = errors.New("timeline: invalid cursor")
+
+const (
+	cursorVersion = 2
+	cursorMACSize = 16
+)
+
+// Cursor marks where a reader is in a timeline. Clients receive it as an
+// opaque signed string, so they cannot forge positions, and since it holds a
+// position rather than an offset, items arriving between pages cause neither
+// duplicates nor gaps.
+type Cursor struct {
+	// Position is the last entry returned; the next page continues after it.
+	Position Position
+	// Top is the newest entry when the reader fetched the first page, i.e.
+	// the snapshot the pagination started from.
+	Top Position
+	// Seen is the marshaled filter.Bloom of the identities returned so far,
+	// if the reader deduplicates across pages.
+	Seen []byte
+}
+
+// CursorCodec signs and verifies cursors with a secret key.
+type CursorCodec struct {
+	key []byte
+}
+
+// Instantiates a new codec signing with key, which should be at least 32
+// random bytes and shared by every server issuing and reading cursors.
+func NewCursorCodec(key []byte) *CursorCodec {
+	return &CursorCodec{key: append([]byte(nil), key...)}
+}
+
+// Returns the opaque string form of cursor.
+func (cc *CursorCodec) Encode(cursor Cursor) string {
+	data := []byte{cursorVersion}
+	for _, p := range []Position{cursor.Position, cursor.Top} {
+		var buf [binary.MaxVarintLen64]byte
+		data = append(data, buf[:binary.PutVarint(buf[:], p.Timestamp)]...)
+		data = append(data, buf[:binary.PutUvarint(buf[:], p.ItemID)]...)
+	}
+	var buf [binary.MaxVarintLen64]byte
+	data = append(data, buf[:binary.PutUvarint(buf[:], uint64(len(cursor.Seen)))]...)
+	data = append(data, cursor.Seen...)
+	data = append(data, cc.sign(data)...)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Parses a string returned by Encode, failing with ErrBadCursor if it is
+// malformed or was not signed with this codec's key.
+func (cc *CursorCodec) Decode(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) < 1+cursorMACSize || data[0] != cursorVersion {
+		return Cursor{}, ErrBadCursor
+	}
+	payload, mac := data[:len(data)-cursorMACSize], data[len(data)-cursorMACSize:]
+	if !hmac.Equal(mac, cc.sign(payload)) {
+		return Cursor{}, ErrBadCursor
+	}
+	var cursor Cursor
+	rest := payload[1:]
+	for _, p := range []*Position{&cursor.Position, &cursor.Top} {
+		timestamp, n := binary.Varint(rest)
+		if n <= 0 {
+			return Cursor{}, ErrBadCursor
+		}
+		rest = rest[n:]
+		itemID, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return Cursor{}, ErrBadCursor
+		}
+		rest = rest[n:]
+		*p = Position{Timestamp: timestamp, ItemID: itemID}
+	}
+	size, n := binary.Uvarint(rest)
+	if n <= 0 || uint64(len(rest)-n) != size {
+		return Cursor{}, ErrBadCursor
+	}
+	if size > 0 {
+		cursor.Seen = append([]byte(nil), rest[n:]...)
+	}
+	return cursor, nil
+}
+
+func (cc *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:cursorMACSize]
+}
@@ -0,0 +1,177 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	cursor := Cursor{Position: Position{Timestamp: -5, ItemID: 1 << 60}, Top: Position{Timestamp: 1473000000000, ItemID: 7}}
+	decoded, err := codec.Decode(codec.Encode(cursor))
+	if err != nil || !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("expected: %v, got: %v (%v)", cursor, decoded, err)
+	}
+
+	encoded := []byte(codec.Encode(cursor))
+	encoded[3] ^= 1
+	for _, bad := range []string{"", "!!", string(encoded), NewCursorCodec([]byte("other")).Encode(cursor)} {
+		if _, err := codec.Decode(bad); err != ErrBadCursor {
+			t.Errorf("%q, expected: %v, got: %v", bad, ErrBadCursor, err)
+		}
+	}
+}
+
+func readIDs(items []*Item) []uint64 {
+	ids := []uint64{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestReadPagination(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(PushDelivery)
+	defer s.Close()
+	for id := uint64(1); id <= 5; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+
+	page, err := s.Read(ctx, 1, ReadOptions{Limit: 2})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{5, 4}) || page.Next == "" {
+		t.Fatalf("expected: %v, got: %v (%v)", []uint64{5, 4}, readIDs(page.Items), err)
+	}
+
+	// Items arriving between pages shift no offsets.
+	s.Publish(ctx, &Item{ID: 6, AuthorID: 10, Timestamp: 6})
+	s.Wait()
+	page, _ = s.Read(ctx, 1, ReadOptions{Limit: 2, Cursor: page.Next})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{3, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{3, 2}, readIDs(page.Items))
+	}
+	page, _ = s.Read(ctx, 1, ReadOptions{Limit: 2, Cursor: page.Next})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{1}) || page.Next != "" {
+		t.Errorf("expected a last page of %v, got: %v, %q", []uint64{1}, readIDs(page.Items), page.Next)
+	}
+
+	if _, err := s.Read(ctx, 1, ReadOptions{Cursor: "forged"}); err != ErrBadCursor {
+		t.Errorf("expected: %v, got: %v", ErrBadCursor, err)
+	}
+}
+
+func TestReadNewer(t *testing.T) {
+	ctx := context.Background()
+	for _, delivery := range []Delivery{PushDelivery, PullDelivery} {
+		s, _ := newTestService(delivery)
+		for id := uint64(1); id <= 3; id++ {
+			s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+		}
+		s.Wait()
+		first, _ := s.Read(ctx, 1, ReadOptions{Limit: 10})
+
+		// The client goes offline while five items arrive.
+		for id := uint64(4); id <= 8; id++ {
+			s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+		}
+		s.Wait()
+
+		// Newer pages fill the gap from the bottom, each newest first.
+		page, err := s.Read(ctx, 1, ReadOptions{Limit: 2, Cursor: first.Prev, Direction: Newer})
+		if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{5, 4}) || !page.HasNewer {
+			t.Fatalf("delivery %d, expected: %v, got: %v (%v)", delivery, []uint64{5, 4}, readIDs(page.Items), err)
+		}
+		page, _ = s.Read(ctx, 1, ReadOptions{Limit: 10, Cursor: page.Prev, Direction: Newer})
+		if !reflect.DeepEqual(readIDs(page.Items), []uint64{8, 7, 6}) || page.HasNewer {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, []uint64{8, 7, 6}, readIDs(page.Items))
+		}
+
+		// Its Next cursor walks back down.
+		older, _ := s.Read(ctx, 1, ReadOptions{Limit: 2, Cursor: page.Next})
+		if !reflect.DeepEqual(readIDs(older.Items), []uint64{5, 4}) {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, []uint64{5, 4}, readIDs(older.Items))
+		}
+
+		// Polling at the top returns nothing, and a cursor to poll again.
+		empty, _ := s.Read(ctx, 1, ReadOptions{Cursor: page.Prev, Direction: Newer})
+		if len(empty.Items) != 0 || empty.Prev != page.Prev {
+			t.Errorf("delivery %d, expected an empty page, got: %v", delivery, readIDs(empty.Items))
+		}
+		s.Close()
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(PushDelivery)
+	defer s.Close()
+	base := time.Unix(1473000000, 0)
+	for day := 0; day < 7; day++ {
+		at := base.Add(time.Duration(day) * 24 * time.Hour)
+		s.Publish(ctx, &Item{ID: uint64(day + 1), AuthorID: 10, Timestamp: timestampOf(at)})
+	}
+	s.Wait()
+
+	// Day 3 at noon floors to the item of day 3.
+	page, err := s.Read(ctx, 1, ReadOptions{At: base.Add(3*24*time.Hour + 12*time.Hour), Limit: 2})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{4, 3}) {
+		t.Fatalf("expected: %v, got: %v (%v)", []uint64{4, 3}, readIDs(page.Items), err)
+	}
+	newer, _ := s.Read(ctx, 1, ReadOptions{Cursor: page.Prev, Direction: Newer, Limit: 2})
+	if !reflect.DeepEqual(readIDs(newer.Items), []uint64{6, 5}) {
+		t.Errorf("expected: %v, got: %v", []uint64{6, 5}, readIDs(newer.Items))
+	}
+
+	// An exact timestamp includes its item.
+	page, _ = s.Read(ctx, 1, ReadOptions{At: base, Limit: 2})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{1}) || page.Next != "" {
+		t.Errorf("expected: %v, got: %v", []uint64{1}, readIDs(page.Items))
+	}
+
+	// Before the first item the page is empty, but can be read upwards.
+	page, _ = s.Read(ctx, 1, ReadOptions{At: base.Add(-time.Hour), Limit: 2})
+	if len(page.Items) != 0 || page.Prev == "" {
+		t.Fatalf("expected an empty page with a cursor, got: %v", readIDs(page.Items))
+	}
+	newer, _ = s.Read(ctx, 1, ReadOptions{Cursor: page.Prev, Direction: Newer, Limit: 2})
+	if !reflect.DeepEqual(readIDs(newer.Items), []uint64{2, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2, 1}, readIDs(newer.Items))
+	}
+}
+
+func TestReadDedup(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	// Items from 100 on are reposts of the item 100 below them.
+	identity := func(item *Item) uint64 {
+		if item.ID >= 100 {
+			return item.ID - 100
+		}
+		return item.ID
+	}
+	s := NewService(storage, graph, Options{DedupCapacity: 100, Identity: identity})
+	defer s.Close()
+	for id := uint64(1); id <= 4; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+	repost := &Item{ID: 102, AuthorID: 30, Timestamp: 5}
+	storage.PutItems(ctx, repost)
+	storage.AppendItems(ctx, "topic:go", Entry{ItemID: 3, AuthorID: 10, Timestamp: 3}, EntryOf(repost))
+
+	page, err := s.Read(ctx, 1, ReadOptions{Limit: 2, Feeds: []string{"topic:go"}})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{102, 4}) {
+		t.Fatalf("expected: %v, got: %v (%v)", []uint64{102, 4}, readIDs(page.Items), err)
+	}
+	// Item 3 is in both timelines, and item 2 was reposted on the first
+	// page; the page is refilled past it.
+	page, _ = s.Read(ctx, 1, ReadOptions{Limit: 2, Feeds: []string{"topic:go"}, Cursor: page.Next})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{3, 1}) || page.Next != "" {
+		t.Errorf("expected a last page of %v, got: %v, %q", []uint64{3, 1}, readIDs(page.Items), page.Next)
+	}
+}
@@ -0,0 +1,128 @@
+package timeline
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Weights turn the engagement with an item into points for the time-decay
+// rankers. The zero value counts every engagement once.
+type Weights struct {
+	Likes    float64
+	Comments float64
+	Shares   float64
+}
+
+// Returns the points of item.
+func (w Weights) Points(item *Item) float64 {
+	if w == (Weights{}) {
+		w = Weights{Likes: 1, Comments: 1, Shares: 1}
+	}
+	return w.Likes*float64(item.Likes) + w.Comments*float64(item.Comments) + w.Shares*float64(item.Shares)
+}
+
+// ageHours returns how long ago the item was posted, in hours. Items from
+// the future, e.g. because of clock skew between servers, are new.
+func ageHours(item *Item, now time.Time) float64 {
+	age := float64(timestampOf(now)-item.Timestamp) / float64(time.Hour/time.Millisecond)
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// Gravity is the Hacker News ranking: points over age to the power of the
+// gravity, so that items sink at a rate that grows with gravity, however
+// many points they gathered.
+type Gravity struct {
+	weights Weights
+	gravity float64
+	now     func() time.Time
+}
+
+// Instantiates a new Hacker News ranker. A gravity <= 0 selects the usual
+// 1.8.
+func NewGravity(weights Weights, gravity float64) *Gravity {
+	if gravity <= 0 {
+		gravity = 1.8
+	}
+	return &Gravity{weights: weights, gravity: gravity, now: time.Now}
+}
+
+// Returns (points + 1) / (age in hours + 2)^gravity. Unlike on Hacker News,
+// where the submitter's own vote is not counted, an item without engagement
+// still scores above 0, so that fresh items come before old ones.
+func (g *Gravity) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	points := g.weights.Points(item)
+	if points < 0 {
+		points = 0
+	}
+	return (points + 1) / math.Pow(ageHours(item, g.now())+2, g.gravity)
+}
+
+func (g *Gravity) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	return ScoreEach(ctx, g, viewerID, items)
+}
+
+// Hot is the Reddit "hot" ranking: the order of magnitude of the points plus
+// the posting time over a scale, so that 10 times the points is worth one
+// scale of recency (12.5 hours by default). Scores do not depend on the
+// current time, so they can be computed once and stored.
+type Hot struct {
+	weights Weights
+	scale   time.Duration
+}
+
+// Instantiates a new Reddit hot ranker. A scale <= 0 selects 45000 seconds.
+func NewHot(weights Weights, scale time.Duration) *Hot {
+	if scale <= 0 {
+		scale = 45000 * time.Second
+	}
+	return &Hot{weights: weights, scale: scale}
+}
+
+// Returns sign(points) * log10(max(|points|, 1)) + timestamp / scale.
+// Negative points, e.g. from negative weights for reports, sink the item.
+func (h *Hot) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	points := h.weights.Points(item)
+	order := math.Log10(math.Max(math.Abs(points), 1))
+	if points < 0 {
+		order = -order
+	}
+	return order + float64(item.Timestamp)/float64(h.scale/time.Millisecond)
+}
+
+func (h *Hot) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	return ScoreEach(ctx, h, viewerID, items)
+}
+
+// ExponentialDecay scores items by their points, halved every half-life
+// since posting.
+type ExponentialDecay struct {
+	weights  Weights
+	halfLife time.Duration
+	now      func() time.Time
+}
+
+// Instantiates a new exponential decay ranker. A halfLife <= 0 selects 24h.
+func NewExponentialDecay(weights Weights, halfLife time.Duration) *ExponentialDecay {
+	if halfLife <= 0 {
+		halfLife = 24 * time.Hour
+	}
+	return &ExponentialDecay{weights: weights, halfLife: halfLife, now: time.Now}
+}
+
+// Returns (points + 1) * 2^(-age / halfLife), so that items without
+// engagement still decay from 1.
+func (e *ExponentialDecay) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	points := e.weights.Points(item)
+	if points < 0 {
+		points = 0
+	}
+	return (points + 1) * math.Exp2(-ageHours(item, e.now())/e.halfLife.Hours())
+}
+
+func (e *ExponentialDecay) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	return ScoreEach(ctx, e, viewerID, items)
+}
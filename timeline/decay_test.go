@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecayRankers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1473000000, 0)
+	clock := func() time.Time { return now }
+	hoursAgo := func(hours float64) int64 {
+		return timestampOf(now) - int64(hours*float64(time.Hour/time.Millisecond))
+	}
+
+	gravity := NewGravity(Weights{Likes: 1, Comments: 2}, 0)
+	gravity.now = clock
+	decay := NewExponentialDecay(Weights{}, 12*time.Hour)
+	decay.now = clock
+	hot := NewHot(Weights{Likes: 1, Shares: -1}, 0)
+
+	tests := []struct {
+		name     string
+		ranker   Ranker
+		item     *Item
+		expected float64
+	}{
+		{"gravity", gravity, &Item{Timestamp: hoursAgo(2), Likes: 5, Comments: 1, Shares: 100}, 8 / math.Pow(4, 1.8)},
+		{"gravity from the future", gravity, &Item{Timestamp: hoursAgo(-1)}, 1 / math.Pow(2, 1.8)},
+		{"decay one half-life", decay, &Item{Timestamp: hoursAgo(12), Likes: 1, Comments: 1, Shares: 1}, 2},
+		{"decay two half-lives", decay, &Item{Timestamp: hoursAgo(24)}, 0.25},
+		{"hot", hot, &Item{Timestamp: 45000000, Likes: 100}, 3},
+		{"hot negative", hot, &Item{Timestamp: 0, Likes: 1, Shares: 11}, -1},
+		{"hot none", hot, &Item{Timestamp: 90000000}, 2},
+	}
+	for _, test := range tests {
+		if actual := test.ranker.Score(ctx, 1, test.item); math.Abs(actual-test.expected) > 1e-9 {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, actual)
+		}
+	}
+
+	// Fresh items without engagement overtake stale popular ones.
+	items := []*Item{{ID: 1, Timestamp: hoursAgo(48), Likes: 20}, {ID: 2, Timestamp: hoursAgo(1)}}
+	rank(ctx, gravity, 1, items)
+	if items[0].ID != 2 {
+		t.Errorf("expected: %v, got: %v", 2, items[0].ID)
+	}
+}
@@ -0,0 +1,86 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+)
+
+// Assignments assign viewers to the variants of experiments, e.g. by hashing
+// their ID or from an experimentation service.
+// Implementations must be safe for concurrent use.
+type Assignments interface {
+	// Assign returns the variant the viewer is assigned to by experiment
+	// name. Experiments the viewer is not in are left out.
+	Assign(ctx context.Context, viewerID uint64) (map[string]string, error)
+}
+
+// Variant is the read configuration of the viewers assigned to a variant of
+// an experiment.
+type Variant struct {
+	// Ranker, if set, ranks the pages instead of the ranker of the kind of
+	// timeline or Options.Ranker. ReadOptions.Ranker still wins.
+	Ranker Ranker
+	// Filters leave items out of the pages.
+	Filters []Filter
+	// Injections insert external items into the pages, after those of
+	// ReadOptions.Injections.
+	Injections []Injection
+}
+
+// Experiments configure reads per viewer by experiment variant, so that
+// ranking, filtering and injection can be tested without separate builds.
+type Experiments struct {
+	Assignments Assignments
+	// Variants are the variants by name of the experiments by name.
+	// Variants assigned but not configured, e.g. control, read as usual but
+	// are still reported in Page.Variants.
+	// A viewer in several experiments gets the variants of each, in the
+	// order of the experiment names; the last ranker wins.
+	Variants map[string]map[string]Variant
+	// OnError, if set, is called when Assignments fail. Reads then go on
+	// without experiments rather than fail.
+	OnError func(err error)
+}
+
+// experiment applies the variants the viewer is assigned to to the read
+// options, and returns them with the variants' filters and every variant
+// assigned by experiment name, configured or not, or nil if none is.
+func (s *Service) experiment(ctx context.Context, viewerID uint64, options ReadOptions) (ReadOptions, []Filter, map[string]string) {
+	experiments := s.options.Experiments
+	if experiments.Assignments == nil {
+		return options, nil, nil
+	}
+	assigned, err := experiments.Assignments.Assign(ctx, viewerID)
+	if err != nil {
+		if experiments.OnError != nil {
+			experiments.OnError(err)
+		}
+		return options, nil, nil
+	}
+	names := make([]string, 0, len(assigned))
+	for name := range assigned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var filters []Filter
+	var applied map[string]string
+	explicit := options.Ranker != nil
+	for _, name := range names {
+		// Every assigned variant tags the page, control included, so that
+		// logs tell control traffic apart from viewers in no experiment.
+		if applied == nil {
+			applied = make(map[string]string)
+		}
+		applied[name] = assigned[name]
+		variant, found := experiments.Variants[name][assigned[name]]
+		if !found {
+			continue
+		}
+		if variant.Ranker != nil && !explicit {
+			options.Ranker = variant.Ranker
+		}
+		filters = append(filters, variant.Filters...)
+		options.Injections = append(options.Injections[:len(options.Injections):len(options.Injections)], variant.Injections...)
+	}
+	return options, filters, applied
+}
@@ -0,0 +1,85 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assignments assign viewers by ID, and fail for the others.
+type assignments map[uint64]map[string]string
+
+func (a assignments) Assign(ctx context.Context, viewerID uint64) (map[string]string, error) {
+	if assigned, found := a[viewerID]; found {
+		return assigned, nil
+	}
+	return nil, errors.New("unavailable")
+}
+
+func TestExperiments(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	for viewer := uint64(1); viewer <= 3; viewer++ {
+		graph.Follow(viewer, 10)
+		graph.Follow(viewer, 11)
+	}
+	var failures int
+	s := NewService(storage, graph, Options{Experiments: Experiments{
+		Assignments: assignments{
+			1: {"ranking": "affinity", "cards": "on"},
+			2: {"ranking": "control"},
+		},
+		Variants: map[string]map[string]Variant{
+			"ranking": {"affinity": {Ranker: affinity{11: 1}}},
+			"cards": {"on": {
+				Filters:    []Filter{{Name: "odd", Keep: func(item *Item) bool { return item.ID%2 == 0 }}},
+				Injections: []Injection{{Name: "card", Injector: &staticInjector{items: []uint64{100}}, Positions: []int{0}}},
+			}},
+		},
+		OnError: func(err error) { failures++ },
+	}})
+	defer s.Close()
+	for id := uint64(1); id <= 4; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10 + id/3, Timestamp: int64(id)})
+	}
+	s.Wait()
+
+	tests := []struct {
+		viewerID uint64
+		expected []uint64
+		variants map[string]string
+	}{
+		{1, []uint64{100, 4, 2}, map[string]string{"ranking": "affinity", "cards": "on"}},
+		{2, []uint64{4, 3, 2, 1}, map[string]string{"ranking": "control"}},
+		{3, []uint64{4, 3, 2, 1}, nil},
+	}
+	for _, test := range tests {
+		page, err := s.Read(ctx, test.viewerID, ReadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := readIDs(page.Items); !reflect.DeepEqual(ids, test.expected) || !reflect.DeepEqual(page.Variants, test.variants) {
+			t.Errorf("viewer %d, expected: %v %v, got: %v %v", test.viewerID, test.expected, test.variants, ids, page.Variants)
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected: %v, got: %v", 1, failures)
+	}
+
+	// Variants apply to other timelines, and explanations score with them.
+	page, _ := s.ReadFeed(ctx, 1, UserFeed(11), ReadOptions{})
+	if ids := readIDs(page.Items); !reflect.DeepEqual(ids, []uint64{100, 4}) || page.Variants["cards"] != "on" {
+		t.Errorf("expected: %v, got: %v %v", []uint64{100, 4}, ids, page.Variants)
+	}
+	explanation, _ := s.Explain(ctx, 1, HomeFeed(1), ReadOptions{}, ExplainOptions{})
+	if score := explanation.Candidates[0].Scores["read"]; score != 1 {
+		t.Errorf("expected: %v, got: %v", 1, score)
+	}
+	// An explicit ranker wins over the variant's.
+	page, _ = s.Read(ctx, 1, ReadOptions{Ranker: Chronological{}})
+	if ids := readIDs(page.Items); !reflect.DeepEqual(ids, []uint64{100, 4, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{100, 4, 2}, ids)
+	}
+}
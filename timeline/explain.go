@@ -0,0 +1,88 @@
+package timeline
+
+import "context"
+
+// ExplainOptions configure an explanation.
+type ExplainOptions struct {
+	// Rankers score every candidate by name, next to the ranker of the
+	// read, named "read", e.g. to compare a new ranker with the live one.
+	Rankers map[string]Ranker
+	// Features, if set, assembles the raw features of the candidates, e.g.
+	// RemoteOptions.ItemFeatures.
+	Features func(ctx context.Context, viewerID uint64, item *Item) Features
+}
+
+// Explanation tells how a page was assembled.
+type Explanation struct {
+	Page *Page
+	// Candidates are the entries the read went through, in timeline order
+	// from the cursor, whether the page shows them or not.
+	Candidates []ExplainedItem
+	// ranker is the ranker of the read, e.g. of an experiment variant.
+	ranker Ranker
+}
+
+// ExplainedItem is a candidate of a page.
+type ExplainedItem struct {
+	Entry Entry
+	// Item is nil if the item is missing from storage.
+	Item *Item
+	// Filter names what left the item out of the page: "missing", or the
+	// name of a Filter such as "hidden" (deleted or expired), "blocked",
+	// "pinned" or "duplicate". It is empty if the page shows the item.
+	Filter string
+	// Grouped is true if the item joined a group rather than taking a slot
+	// of the page.
+	Grouped  bool
+	Features Features
+	Scores   map[string]float64
+}
+
+// Reads a page of a timeline for the viewer, as Read does for
+// HomeFeed(viewerID) and ReadFeed for other timelines, and explains it, to
+// debug why an item shows up where it does, or not at all. It is a dry run:
+// nothing is written.
+func (s *Service) Explain(ctx context.Context, viewerID uint64, feedID string, options ReadOptions, explain ExplainOptions) (*Explanation, error) {
+	explanation := &Explanation{}
+	options.explain = explanation
+	var err error
+	if feedID == HomeFeed(viewerID) {
+		explanation.Page, err = s.Read(ctx, viewerID, options)
+	} else {
+		explanation.Page, err = s.ReadFeed(ctx, viewerID, feedID, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*Item{}
+	for _, candidate := range explanation.Candidates {
+		if candidate.Item != nil {
+			items = append(items, candidate.Item)
+		}
+	}
+	rankers := map[string]Ranker{"read": explanation.ranker}
+	for name, ranker := range explain.Rankers {
+		rankers[name] = ranker
+	}
+	scores := make(map[string][]float64, len(rankers))
+	for name, ranker := range rankers {
+		scores[name] = ranker.ScoreBatch(ctx, viewerID, items)
+	}
+	i := 0
+	for c := range explanation.Candidates {
+		candidate := &explanation.Candidates[c]
+		if candidate.Item == nil {
+			continue
+		}
+		candidate.Scores = make(map[string]float64, len(rankers))
+		for name := range rankers {
+			candidate.Scores[name] = scores[name][i]
+		}
+		if explain.Features != nil {
+			candidate.Features = explain.Features(ctx, viewerID, candidate.Item)
+		}
+		i++
+	}
+	return explanation, nil
+}
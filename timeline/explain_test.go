@@ -0,0 +1,52 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	graph.Follow(1, 11)
+	blocks := &countingBlockLists{list: &BlockList{Authors: map[uint64]bool{11: true}}}
+	s := NewService(storage, graph, Options{BlockLists: blocks})
+	defer s.Close()
+	for id := uint64(1); id <= 4; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10 + id/4, Timestamp: int64(id), Likes: int64(id)})
+	}
+	s.Wait()
+	s.Retract(ctx, 2)
+	storage.AppendItems(ctx, HomeFeed(1), Entry{ItemID: 5, AuthorID: 10, Timestamp: 5})
+
+	explanation, err := s.Explain(ctx, 1, HomeFeed(1), ReadOptions{}, ExplainOptions{
+		Rankers: map[string]Ranker{"likes": NewHot(Weights{Likes: 1}, 1<<62)},
+		Features: func(ctx context.Context, viewerID uint64, item *Item) Features {
+			return Features{"likes": float64(item.Likes)}
+		},
+	})
+	if err != nil || !reflect.DeepEqual(readIDs(explanation.Page.Items), []uint64{3, 1}) {
+		t.Fatalf("expected: %v, got: %v (%v)", []uint64{3, 1}, readIDs(explanation.Page.Items), err)
+	}
+	expected := []struct {
+		itemID uint64
+		filter string
+	}{{5, "missing"}, {4, "blocked"}, {3, ""}, {2, "hidden"}, {1, ""}}
+	if len(explanation.Candidates) != len(expected) {
+		t.Fatalf("expected: %v, got: %v", len(expected), len(explanation.Candidates))
+	}
+	for i, candidate := range explanation.Candidates {
+		if candidate.Entry.ItemID != expected[i].itemID || candidate.Filter != expected[i].filter {
+			t.Errorf("expected: %v, got: %v %q", expected[i], candidate.Entry.ItemID, candidate.Filter)
+		}
+	}
+	if candidate := explanation.Candidates[2]; candidate.Scores["read"] != 3 || candidate.Scores["likes"] <= 0 || candidate.Features["likes"] != 3 {
+		t.Errorf("expected the scores and features of item 3, got: %v %v", candidate.Scores, candidate.Features)
+	}
+	if candidate := explanation.Candidates[0]; candidate.Scores != nil {
+		t.Errorf("expected no scores for a missing item, got: %v", candidate.Scores)
+	}
+}
@@ -0,0 +1,136 @@
+package timeline
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeedKind is the kind of a timeline, e.g. the home timelines of users or
+// the timelines of hashtags.
+type FeedKind string
+
+const (
+	// Home timelines hold the posts of the users a user follows.
+	Home FeedKind = "home"
+	// Profile timelines hold the posts of a user.
+	Profile FeedKind = "user"
+	// Hashtag timelines hold the posts tagged with a hashtag.
+	Hashtag FeedKind = "tag"
+	// Geo timelines hold the posts made in an area, e.g. a geohash cell.
+	Geo FeedKind = "geo"
+	// Pins timelines hold the items pinned to another timeline.
+	Pins FeedKind = "pins"
+)
+
+// Returns the ID of the timeline of the kind with the key, "kind:key", as
+// Storage knows it.
+func FeedOf(kind FeedKind, key string) string {
+	return string(kind) + ":" + key
+}
+
+// Returns the kind and key of a timeline ID, and false if it has no kind.
+func ParseFeed(feedID string) (FeedKind, string, bool) {
+	i := strings.IndexByte(feedID, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return FeedKind(feedID[:i]), feedID[i+1:], true
+}
+
+// Returns the ID of the home timeline of a user, holding the items of the
+// users they follow.
+func HomeFeed(userID uint64) string {
+	return FeedOf(Home, strconv.FormatUint(userID, 10))
+}
+
+// Returns the ID of the user timeline of a user, holding the items they
+// posted.
+func UserFeed(userID uint64) string {
+	return FeedOf(Profile, strconv.FormatUint(userID, 10))
+}
+
+// Returns the ID of the timeline holding the items pinned to a timeline,
+// ordered by pin time.
+func PinsFeed(feedID string) string {
+	return FeedOf(Pins, feedID)
+}
+
+// KindPolicy configures the timelines of a kind.
+type KindPolicy struct {
+	// Route, if set, returns the keys of the timelines of the kind a new
+	// post goes to, e.g. its hashtags. Home and profile timelines are
+	// written by the delivery instead.
+	Route func(item *Item) []string
+	// MaxLen, if positive, caps the timelines of the kind: routed posts
+	// evict the oldest entries on write, and the compactor trims the rest.
+	MaxLen int
+	// TTL, if positive, is how long the compactor keeps entries.
+	TTL time.Duration
+	// Ranker, if set, ranks the reads of the kind instead of
+	// Options.Ranker.
+	Ranker Ranker
+}
+
+// Kinds are the policies of the kinds of timelines.
+type Kinds map[FeedKind]KindPolicy
+
+// Returns the policy of the kind of a timeline.
+func (k Kinds) Policy(feedID string) KindPolicy {
+	kind, _, _ := ParseFeed(feedID)
+	return k[kind]
+}
+
+// Returns the TTL of a timeline, as CompactorOptions.TTL expects.
+func (k Kinds) TTL(feedID string) time.Duration {
+	return k.Policy(feedID).TTL
+}
+
+// Returns the maximum length of a timeline, as CompactorOptions.MaxLen
+// expects.
+func (k Kinds) MaxLen(feedID string) int {
+	return k.Policy(feedID).MaxLen
+}
+
+// routes returns the routed timelines of item.
+func (k Kinds) routes(item *Item) []string {
+	feedIDs := []string{}
+	for kind, policy := range k {
+		if policy.Route == nil {
+			continue
+		}
+		for _, key := range policy.Route(item) {
+			feedIDs = append(feedIDs, FeedOf(kind, key))
+		}
+	}
+	return feedIDs
+}
+
+// route adds item to its routed timelines, capping them.
+func (s *Service) route(ctx context.Context, item *Item) error {
+	entry := EntryOf(item)
+	for _, feedID := range s.options.Kinds.routes(item) {
+		var err error
+		if maxLen := s.options.Kinds.MaxLen(feedID); maxLen > 0 {
+			_, err = s.storage.AppendCapped(ctx, feedID, maxLen, EvictOldest, entry)
+		} else {
+			err = s.storage.AppendItems(ctx, feedID, entry)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ranker returns the ranker of a read of a timeline of the kind.
+func (s *Service) ranker(options ReadOptions, kind FeedKind) Ranker {
+	if options.Ranker != nil {
+		return options.Ranker
+	}
+	if ranker := s.options.Kinds[kind].Ranker; ranker != nil {
+		return ranker
+	}
+	return s.options.Ranker
+}
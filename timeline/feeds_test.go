@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		feedID string
+		kind   FeedKind
+		key    string
+		ok     bool
+	}{
+		{HomeFeed(7), Home, "7", true},
+		{FeedOf(Hashtag, "golang"), Hashtag, "golang", true},
+		{PinsFeed(UserFeed(7)), Pins, "user:7", true},
+		{"legacy", "", "", false},
+	}
+	for _, test := range tests {
+		if kind, key, ok := ParseFeed(test.feedID); kind != test.kind || key != test.key || ok != test.ok {
+			t.Errorf("%s, expected: %v %v %v, got: %v %v %v", test.feedID, test.kind, test.key, test.ok, kind, key, ok)
+		}
+	}
+}
+
+func TestKinds(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	kinds := Kinds{
+		Hashtag: KindPolicy{
+			Route:  func(item *Item) []string { return item.Tags },
+			MaxLen: 2,
+			TTL:    time.Hour,
+			// Items of author 11 first.
+			Ranker: affinity{11: 1},
+		},
+		Geo: KindPolicy{Route: func(item *Item) []string {
+			if item.AuthorID == 11 {
+				return []string{"u4pruy"}
+			}
+			return nil
+		}},
+	}
+	s := NewService(storage, NewMemoryGraph(), Options{Kinds: kinds})
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1, Tags: []string{"go"}})
+	s.Publish(ctx, &Item{ID: 2, AuthorID: 11, Timestamp: 2, Tags: []string{"go", "rust"}})
+	s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3, Tags: []string{"go"}})
+	s.Wait()
+
+	tests := []struct {
+		feedID   string
+		expected []uint64
+	}{
+		{FeedOf(Hashtag, "go"), []uint64{2, 3}},
+		{FeedOf(Hashtag, "rust"), []uint64{2}},
+		{FeedOf(Geo, "u4pruy"), []uint64{2}},
+		{UserFeed(10), []uint64{3, 1}},
+	}
+	for _, test := range tests {
+		page, err := s.ReadFeed(ctx, 1, test.feedID, ReadOptions{})
+		if err != nil || !reflect.DeepEqual(readIDs(page.Items), test.expected) {
+			t.Errorf("%s, expected: %v, got: %v (%v)", test.feedID, test.expected, readIDs(page.Items), err)
+		}
+	}
+	if kinds.TTL(FeedOf(Hashtag, "go")) != time.Hour || kinds.MaxLen(UserFeed(10)) != 0 {
+		t.Errorf("expected the hashtag policy")
+	}
+
+	s.Retract(ctx, 2)
+	if ids := itemIDs(mustRange(t, storage, FeedOf(Hashtag, "rust"))); len(ids) != 0 {
+		t.Errorf("expected: %v, got: %v", []uint64{}, ids)
+	}
+}
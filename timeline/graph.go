@@ -0,0 +1,80 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Graph answers who follows whom.
+// Implementations must be safe for concurrent use.
+type Graph interface {
+	// Followers returns the users following userID.
+	Followers(ctx context.Context, userID uint64) ([]uint64, error)
+	// Followees returns the users userID follows.
+	Followees(ctx context.Context, userID uint64) ([]uint64, error)
+	// FollowerCount returns the number of users following userID.
+	FollowerCount(ctx context.Context, userID uint64) (int, error)
+}
+
+// MemoryGraph is a Graph kept in process memory.
+// Structure is thread safe.
+type MemoryGraph struct {
+	mu        sync.RWMutex
+	followers map[uint64]map[uint64]bool
+	followees map[uint64]map[uint64]bool
+}
+
+// Instantiates a new empty memory graph.
+func NewMemoryGraph() *MemoryGraph {
+	return &MemoryGraph{followers: make(map[uint64]map[uint64]bool), followees: make(map[uint64]map[uint64]bool)}
+}
+
+// Records that follower follows followee.
+func (g *MemoryGraph) Follow(follower, followee uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.followers[followee] == nil {
+		g.followers[followee] = make(map[uint64]bool)
+	}
+	if g.followees[follower] == nil {
+		g.followees[follower] = make(map[uint64]bool)
+	}
+	g.followers[followee][follower] = true
+	g.followees[follower][followee] = true
+}
+
+// Records that follower no longer follows followee.
+func (g *MemoryGraph) Unfollow(follower, followee uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.followers[followee], follower)
+	delete(g.followees[follower], followee)
+}
+
+func (g *MemoryGraph) Followers(ctx context.Context, userID uint64) ([]uint64, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return sortedIDs(g.followers[userID]), nil
+}
+
+func (g *MemoryGraph) Followees(ctx context.Context, userID uint64) ([]uint64, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return sortedIDs(g.followees[userID]), nil
+}
+
+func (g *MemoryGraph) FollowerCount(ctx context.Context, userID uint64) (int, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.followers[userID]), nil
+}
+
+func sortedIDs(set map[uint64]bool) []uint64 {
+	ids := make([]uint64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,106 @@
+package timeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Grouping collapses similar items of a page into one, e.g. "A, B and 3
+// others liked your photo" or "X posted 5 photos". Groups take one slot of
+// the page. Groups do not span pages: the same activity can show up as a
+// group on two pages.
+type Grouping struct {
+	// Key returns the group of an item, or "" to leave it alone. See
+	// GroupByObject and GroupByAuthor.
+	Key func(item *Item) string
+	// Window, if positive, bounds the time between the first item of a
+	// group met on the page and the items joining it, so that the same
+	// activity days apart makes separate groups.
+	Window time.Duration
+}
+
+// Groups the items with the same verb on the same object, e.g. every like of
+// a photo.
+func GroupByObject(item *Item) string {
+	if item.Verb == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", item.Verb, item.ObjectID)
+}
+
+// Groups the items with the same verb by the same author, e.g. the photos
+// they posted.
+func GroupByAuthor(item *Item) string {
+	if item.Verb == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:author:%d", item.Verb, item.AuthorID)
+}
+
+// grouper tracks the groups of a page being read.
+type grouper struct {
+	grouping Grouping
+	slots    map[string][]int // slots in the page of the groups by key
+	children map[int][]*Item  // items joining the item of a slot
+}
+
+func newGrouper(grouping Grouping) *grouper {
+	return &grouper{grouping: grouping, slots: make(map[string][]int), children: make(map[int][]*Item)}
+}
+
+// find returns the key of item and the slot of the group of the page it
+// joins, if there is one.
+func (g *grouper) find(items []*Item, item *Item) (string, int, bool) {
+	if g.grouping.Key == nil {
+		return "", 0, false
+	}
+	key := g.grouping.Key(item)
+	if key == "" {
+		return "", 0, false
+	}
+	window := int64(g.grouping.Window / time.Millisecond)
+	for _, slot := range g.slots[key] {
+		distance := items[slot].Timestamp - item.Timestamp
+		if distance < 0 {
+			distance = -distance
+		}
+		if window <= 0 || distance <= window {
+			return key, slot, true
+		}
+	}
+	return key, 0, false
+}
+
+// fits returns whether item would join a group of the page.
+func (g *grouper) fits(items []*Item, item *Item) bool {
+	_, _, found := g.find(items, item)
+	return found
+}
+
+// join adds item to a group of the page if it has one to join, and returns
+// whether it did. Otherwise item starts a group in the next slot of items.
+func (g *grouper) join(items []*Item, item *Item) bool {
+	key, slot, found := g.find(items, item)
+	if found {
+		g.children[slot] = append(g.children[slot], item)
+	} else if key != "" {
+		g.slots[key] = append(g.slots[key], len(items))
+	}
+	return found
+}
+
+// collapse replaces the items starting groups with copies of the newest
+// item of the group, holding the group as Children.
+func (g *grouper) collapse(items []*Item, direction Direction) {
+	for slot, children := range g.children {
+		group := append([]*Item{items[slot]}, children...)
+		if direction == Newer {
+			for i, j := 0, len(group)-1; i < j; i, j = i+1, j-1 {
+				group[i], group[j] = group[j], group[i]
+			}
+		}
+		newest := *group[0]
+		newest.Children = group
+		items[slot] = &newest
+	}
+}
@@ -0,0 +1,48 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGrouping(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	s := NewService(storage, NewMemoryGraph(), Options{})
+	defer s.Close()
+	minute := int64(time.Minute / time.Millisecond)
+	items := []*Item{
+		{ID: 1, AuthorID: 6, Timestamp: 0, Verb: "post"},
+		{ID: 9, AuthorID: 9, Timestamp: 48 * 60 * minute, Verb: "like", ObjectID: 7},
+	}
+	for id := uint64(2); id <= 5; id++ {
+		items = append(items, &Item{ID: id, AuthorID: id, Timestamp: int64(id) * minute, Verb: "like", ObjectID: 7})
+	}
+	storage.PutItems(ctx, items...)
+	for _, item := range items {
+		storage.AppendItems(ctx, "notifications:1", EntryOf(item))
+	}
+
+	// The like two days later makes its own group, and the full page still
+	// takes the likes joining the second one.
+	grouping := Grouping{Key: GroupByObject, Window: time.Hour}
+	page, err := s.ReadFeed(ctx, 1, "notifications:1", ReadOptions{Limit: 2, Grouping: grouping})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{9, 5}) {
+		t.Fatalf("expected: %v, got: %v (%v)", []uint64{9, 5}, readIDs(page.Items), err)
+	}
+	if page.Items[0].Children != nil || !reflect.DeepEqual(readIDs(page.Items[1].Children), []uint64{5, 4, 3, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{5, 4, 3, 2}, readIDs(page.Items[1].Children))
+	}
+	page, _ = s.ReadFeed(ctx, 1, "notifications:1", ReadOptions{Limit: 2, Grouping: grouping, Cursor: page.Next})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{1}) || page.Next != "" {
+		t.Errorf("expected a last page of %v, got: %v, %q", []uint64{1}, readIDs(page.Items), page.Next)
+	}
+
+	// Groups are newest first when reading newer items too.
+	page, _ = s.ReadFeed(ctx, 1, "notifications:1", ReadOptions{Grouping: grouping, Cursor: page.Prev, Direction: Newer})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{9, 5}) || !reflect.DeepEqual(readIDs(page.Items[1].Children), []uint64{5, 4, 3, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{9, 5}, readIDs(page.Items))
+	}
+}
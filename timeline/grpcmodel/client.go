@@ -0,0 +1,75 @@
+// Package grpcmodel calls ranking model servers over gRPC: Client is a
+// timeline.ModelClient for servers implementing the Ranker service of
+// ranking.proto, and RegisterRankerServer serves it from Go.
+package grpcmodel
+
+import (
+	stdcontext "context"
+
+	"feed/timeline"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Client is a timeline.ModelClient calling the Ranker service over a gRPC
+// connection.
+// Structure is thread safe.
+type Client struct {
+	conn    *grpc.ClientConn
+	options []grpc.CallOption
+}
+
+// Instantiates a new client calling the Ranker service over conn, e.g. from
+// grpc.Dial, with options on every call.
+func NewClient(conn *grpc.ClientConn, options ...grpc.CallOption) *Client {
+	return &Client{conn: conn, options: options}
+}
+
+// Returns the scores of the request's candidates, in the same order. The
+// deadline of ctx, e.g. the budget of a timeline.RemoteRanker, bounds the
+// call.
+func (c *Client) Score(ctx stdcontext.Context, request *timeline.ModelRequest) ([]float64, error) {
+	in := &ScoreRequest{ViewerId: request.ViewerID, Viewer: request.Viewer, Candidates: make([]*Candidate, len(request.Candidates))}
+	for i, candidate := range request.Candidates {
+		in.Candidates[i] = &Candidate{ItemId: candidate.ItemID, Features: candidate.Features}
+	}
+	out := &ScoreResponse{}
+	if err := grpc.Invoke(ctx, "/feed.ranking.Ranker/Score", in, out, c.conn, c.options...); err != nil {
+		return nil, err
+	}
+	return out.Scores, nil
+}
+
+// RankerServer is the server side of the Ranker service.
+type RankerServer interface {
+	Score(ctx context.Context, request *ScoreRequest) (*ScoreResponse, error)
+}
+
+// Registers server as the Ranker service of s.
+func RegisterRankerServer(s *grpc.Server, server RankerServer) {
+	s.RegisterService(&rankerService, server)
+}
+
+var rankerService = grpc.ServiceDesc{
+	ServiceName: "feed.ranking.Ranker",
+	HandlerType: (*RankerServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Score",
+		Handler: func(server interface{}, ctx context.Context, decode func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := &ScoreRequest{}
+			if err := decode(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return server.(RankerServer).Score(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: server, FullMethod: "/feed.ranking.Ranker/Score"}
+			return interceptor(ctx, in, info, func(ctx context.Context, request interface{}) (interface{}, error) {
+				return server.(RankerServer).Score(ctx, request.(*ScoreRequest))
+			})
+		},
+	}},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ranking.proto",
+}
@@ -0,0 +1,57 @@
+package grpcmodel
+
+import (
+	stdcontext "context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"feed/timeline"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// likesServer scores candidates by their "likes" feature, scaled by the
+// viewer's "scale".
+type likesServer struct{}
+
+func (likesServer) Score(ctx context.Context, request *ScoreRequest) (*ScoreResponse, error) {
+	scores := make([]float64, len(request.Candidates))
+	for i, candidate := range request.Candidates {
+		scores[i] = candidate.Features["likes"] * request.Viewer["scale"] * float64(request.ViewerId)
+	}
+	return &ScoreResponse{Scores: scores}, nil
+}
+
+func TestClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	server := grpc.NewServer()
+	RegisterRankerServer(server, likesServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ranker := timeline.NewRemoteRanker(NewClient(conn), timeline.RemoteOptions{
+		Budget: 5 * time.Second,
+		ViewerFeatures: func(ctx stdcontext.Context, viewerID uint64) timeline.Features {
+			return timeline.Features{"scale": 10}
+		},
+		ItemFeatures: func(ctx stdcontext.Context, viewerID uint64, item *timeline.Item) timeline.Features {
+			return timeline.Features{"likes": float64(item.Likes)}
+		},
+		OnFallback: func(err error) { t.Error(err) },
+	})
+	items := []*timeline.Item{{ID: 1, Likes: 3}, {ID: 2}, {ID: 3, Likes: 1}}
+	if scores := ranker.ScoreBatch(stdcontext.Background(), 2, items); !reflect.DeepEqual(scores, []float64{60, 0, 20}) {
+		t.Errorf("expected: %v, got: %v", []float64{60, 0, 20}, scores)
+	}
+}
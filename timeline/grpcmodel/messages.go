@@ -0,0 +1,32 @@
+package grpcmodel
+
+import "github.com/golang/protobuf/proto"
+
+// The messages of ranking.proto, as protoc-gen-go writes them.
+
+type ScoreRequest struct {
+	ViewerId   uint64             `protobuf:"varint,1,opt,name=viewer_id,proto3"`
+	Viewer     map[string]float64 `protobuf:"bytes,2,rep,name=viewer" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	Candidates []*Candidate       `protobuf:"bytes,3,rep,name=candidates"`
+}
+
+func (m *ScoreRequest) Reset()         { *m = ScoreRequest{} }
+func (m *ScoreRequest) String() string { return proto.CompactTextString(m) }
+func (*ScoreRequest) ProtoMessage()    {}
+
+type Candidate struct {
+	ItemId   uint64             `protobuf:"varint,1,opt,name=item_id,proto3"`
+	Features map[string]float64 `protobuf:"bytes,2,rep,name=features" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+}
+
+func (m *Candidate) Reset()         { *m = Candidate{} }
+func (m *Candidate) String() string { return proto.CompactTextString(m) }
+func (*Candidate) ProtoMessage()    {}
+
+type ScoreResponse struct {
+	Scores []float64 `protobuf:"fixed64,1,rep,packed,name=scores"`
+}
+
+func (m *ScoreResponse) Reset()         { *m = ScoreResponse{} }
+func (m *ScoreResponse) String() string { return proto.CompactTextString(m) }
+func (*ScoreResponse) ProtoMessage()    {}
@@ -0,0 +1,230 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Injector supplies external items to insert into pages, e.g. ads,
+// suggested follows or topic cards.
+// Implementations must be safe for concurrent use.
+type Injector interface {
+	// Inject returns up to n items to insert into the page of organic items
+	// for the viewer, best first. n exceeds the slots of the page, so that
+	// items left out by frequency caps or collisions can be replaced.
+	Inject(ctx context.Context, viewerID uint64, organic []*Item, n int) ([]*Item, error)
+}
+
+// Injection inserts the items of an Injector at slots of ranked pages.
+type Injection struct {
+	// Name tells the inserted items apart, see Item.Injection.
+	Name     string
+	Injector Injector
+	// Positions are the indexes in the page served of the slots, e.g. 2 for
+	// the third item. Every, if positive, adds a slot every Every positions
+	// after the last of them, e.g. 4, 9, 14 and so on for 5 without
+	// Positions. Items are only inserted above organic ones, so short pages
+	// have fewer slots.
+	Positions []int
+	Every     int
+	// MaxPerPage, if positive, caps the items inserted into a page.
+	MaxPerPage int
+	// Frequency, if its Max is positive, caps how often an item is inserted
+	// for a viewer.
+	Frequency Frequency
+	// OnError, if set, is called when the injector or Options.Impressions
+	// fail. Pages are then served without the injection rather than fail.
+	OnError func(err error)
+}
+
+// Frequency caps the impressions of an item to Max per viewer over Window.
+type Frequency struct {
+	Max    int
+	Window time.Duration
+}
+
+// Impressions keep when injected items were served to users, for frequency
+// caps.
+// Implementations must be safe for concurrent use.
+type Impressions interface {
+	// Impressions returns the number of times each of the items was served
+	// to the user since since. Items never served are left out.
+	Impressions(ctx context.Context, userID uint64, itemIDs []uint64, since time.Time) (map[uint64]int, error)
+	// RecordImpressions records that the items were served to the user at
+	// at.
+	RecordImpressions(ctx context.Context, userID uint64, itemIDs []uint64, at time.Time) error
+}
+
+// MemoryImpressions are Impressions kept in process memory. Every impression
+// is kept, so they suit tests and small deployments.
+// Structure is thread safe.
+type MemoryImpressions struct {
+	mu          sync.RWMutex
+	impressions map[string][]time.Time
+}
+
+// Instantiates new empty memory impressions.
+func NewMemoryImpressions() *MemoryImpressions {
+	return &MemoryImpressions{impressions: make(map[string][]time.Time)}
+}
+
+func impressionKey(userID, itemID uint64) string {
+	return strconv.FormatUint(userID, 10) + "/" + strconv.FormatUint(itemID, 10)
+}
+
+func (mi *MemoryImpressions) Impressions(ctx context.Context, userID uint64, itemIDs []uint64, since time.Time) (map[uint64]int, error) {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	counts := make(map[uint64]int)
+	for _, itemID := range itemIDs {
+		for _, at := range mi.impressions[impressionKey(userID, itemID)] {
+			if !at.Before(since) {
+				counts[itemID]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (mi *MemoryImpressions) RecordImpressions(ctx context.Context, userID uint64, itemIDs []uint64, at time.Time) error {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	for _, itemID := range itemIDs {
+		key := impressionKey(userID, itemID)
+		mi.impressions[key] = append(mi.impressions[key], at)
+	}
+	return nil
+}
+
+// slot is a position of the page an injection inserts an item at.
+type slot struct {
+	position  int
+	injection int
+}
+
+// inject inserts the items of the injections into the ranked page of organic
+// items and returns it. Slots are filled from the top: a slot taken by an
+// earlier injection, listed first, moves to the next position. Items
+// already on the page, as organic or inserted ones, and those over their
+// frequency cap are skipped for the injector's next ones. Impressions are
+// recorded unless record is false.
+func (s *Service) inject(ctx context.Context, viewerID uint64, organic []*Item, injections []Injection, record bool) []*Item {
+	slots := []slot{}
+	counts := make([]int, len(injections))
+	for i, injection := range injections {
+		last := -1
+		for _, position := range injection.Positions {
+			slots = append(slots, slot{position, i})
+			if position > last {
+				last = position
+			}
+		}
+		if injection.Every > 0 {
+			// A page cannot hold more slots than twice its organic items.
+			for position := last + injection.Every; position < 2*len(organic); position += injection.Every {
+				slots = append(slots, slot{position, i})
+			}
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].position < slots[j].position })
+	for _, slot := range slots {
+		counts[slot.injection]++
+	}
+
+	now := s.options.Now()
+	onPage := make(map[uint64]bool, len(organic))
+	for _, item := range organic {
+		onPage[item.ID] = true
+	}
+	candidates := make([][]*Item, len(injections))
+	inserted := make([]int, len(injections))
+	for i, injection := range injections {
+		if counts[i] == 0 {
+			continue
+		}
+		if injection.MaxPerPage > 0 && counts[i] > injection.MaxPerPage {
+			counts[i] = injection.MaxPerPage
+		}
+		items, err := injection.Injector.Inject(ctx, viewerID, organic, 2*counts[i])
+		if err == nil && injection.Frequency.Max > 0 {
+			items, err = s.underCap(ctx, viewerID, items, injection.Frequency, now)
+		}
+		if err != nil {
+			if injection.OnError != nil {
+				injection.OnError(err)
+			}
+			continue
+		}
+		candidates[i] = items
+	}
+
+	page := append([]*Item{}, organic...)
+	served := make([][]uint64, len(injections))
+	next := 0
+	for _, slot := range slots {
+		position := slot.position
+		if position < next {
+			position = next
+		}
+		if position >= len(page) {
+			break
+		}
+		i := slot.injection
+		if injections[i].MaxPerPage > 0 && inserted[i] >= injections[i].MaxPerPage {
+			continue
+		}
+		for len(candidates[i]) > 0 && onPage[candidates[i][0].ID] {
+			candidates[i] = candidates[i][1:]
+		}
+		if len(candidates[i]) == 0 {
+			continue
+		}
+		// Copy, as injectors may hand out shared items.
+		item := *candidates[i][0]
+		candidates[i] = candidates[i][1:]
+		item.Injection = injections[i].Name
+		onPage[item.ID] = true
+		page = append(page, nil)
+		copy(page[position+1:], page[position:])
+		page[position] = &item
+		next = position + 1
+		inserted[i]++
+		served[i] = append(served[i], item.ID)
+	}
+
+	if record {
+		for i, itemIDs := range served {
+			if len(itemIDs) == 0 || injections[i].Frequency.Max <= 0 {
+				continue
+			}
+			err := s.options.Impressions.RecordImpressions(ctx, viewerID, itemIDs, now)
+			if err != nil && injections[i].OnError != nil {
+				injections[i].OnError(err)
+			}
+		}
+	}
+	return page
+}
+
+// underCap returns the items the viewer was served fewer times than the
+// frequency allows, in the same order.
+func (s *Service) underCap(ctx context.Context, viewerID uint64, items []*Item, frequency Frequency, now time.Time) ([]*Item, error) {
+	ids := make([]uint64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	impressions, err := s.options.Impressions.Impressions(ctx, viewerID, ids, now.Add(-frequency.Window))
+	if err != nil {
+		return nil, err
+	}
+	kept := []*Item{}
+	for _, item := range items {
+		if impressions[item.ID] < frequency.Max {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,94 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// staticInjector injects copies of its items, or fails with err.
+type staticInjector struct {
+	items []uint64
+	err   error
+}
+
+func (si *staticInjector) Inject(ctx context.Context, viewerID uint64, organic []*Item, n int) ([]*Item, error) {
+	items := []*Item{}
+	for _, id := range si.items {
+		if len(items) < n {
+			items = append(items, &Item{ID: id})
+		}
+	}
+	return items, si.err
+}
+
+func TestInject(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	s := NewService(storage, graph, Options{Now: func() time.Time { return now }})
+	defer s.Close()
+	for id := uint64(1); id <= 6; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+
+	ads := Injection{Name: "ad", Injector: &staticInjector{items: []uint64{100, 101, 102}}, Every: 3}
+	// Item 6 is organic already, so it is skipped.
+	cards := Injection{Name: "card", Injector: &staticInjector{items: []uint64{6, 200}}, Positions: []int{2}, MaxPerPage: 1}
+	var failures []error
+	broken := Injection{Name: "broken", Injector: &staticInjector{err: errors.New("unavailable")}, Positions: []int{0}, OnError: func(err error) {
+		failures = append(failures, err)
+	}}
+	tests := []struct {
+		name       string
+		injections []Injection
+		expected   []uint64
+	}{
+		{"every", []Injection{ads}, []uint64{6, 5, 100, 4, 3, 101, 2, 1}},
+		// The card takes the first slot, so the ad moves to the next one.
+		{"collision", []Injection{cards, ads}, []uint64{6, 5, 200, 100, 4, 101, 3, 2, 102, 1}},
+		{"failure", []Injection{broken}, []uint64{6, 5, 4, 3, 2, 1}},
+	}
+	for _, test := range tests {
+		page, err := s.Read(ctx, 1, ReadOptions{Injections: test.injections})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := readIDs(page.Items); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, ids)
+		}
+		for _, item := range page.Items {
+			if organic := item.ID <= 6; organic != (item.Injection == "") {
+				t.Errorf("%s, unexpected injection of item %d: %q", test.name, item.ID, item.Injection)
+			}
+		}
+	}
+	if len(failures) != 1 {
+		t.Errorf("expected: %v, got: %v", 1, len(failures))
+	}
+
+	// An ad is served at most twice a minute.
+	ads.Frequency = Frequency{Max: 2, Window: time.Minute}
+	expected := [][]uint64{{100, 101}, {100, 101}, {102}}
+	for i, ids := range expected {
+		page, _ := s.Read(ctx, 1, ReadOptions{Injections: []Injection{ads}})
+		injected := []uint64{}
+		for _, item := range page.Items {
+			if item.Injection != "" {
+				injected = append(injected, item.ID)
+			}
+		}
+		if !reflect.DeepEqual(injected, ids) {
+			t.Errorf("read %d, expected: %v, got: %v", i, ids, injected)
+		}
+	}
+	now = now.Add(time.Minute + time.Second)
+	if page, _ := s.Read(ctx, 1, ReadOptions{Injections: []Injection{ads}}); page.Items[2].ID != 100 {
+		t.Errorf("expected: %v, got: %v", 100, page.Items[2].ID)
+	}
+}
@@ -0,0 +1,134 @@
+package timeline
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// Markers keep where users stopped reading their timelines.
+// Implementations must be safe for concurrent use.
+type Markers interface {
+	// Marker returns the position up to which the user read a timeline,
+	// and false if they never read it.
+	Marker(ctx context.Context, userID uint64, feedID string) (Position, bool, error)
+	// SetMarker moves the marker of the user on a timeline to position,
+	// unless it is past it already, so that markers never move back.
+	SetMarker(ctx context.Context, userID uint64, feedID string, position Position) error
+}
+
+// MemoryMarkers are Markers kept in process memory.
+// Structure is thread safe.
+type MemoryMarkers struct {
+	mu      sync.RWMutex
+	markers map[string]Position
+}
+
+// Instantiates new empty memory markers.
+func NewMemoryMarkers() *MemoryMarkers {
+	return &MemoryMarkers{markers: make(map[string]Position)}
+}
+
+func markerKey(userID uint64, feedID string) string {
+	return strconv.FormatUint(userID, 10) + "/" + feedID
+}
+
+func (mm *MemoryMarkers) Marker(ctx context.Context, userID uint64, feedID string) (Position, bool, error) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	position, found := mm.markers[markerKey(userID, feedID)]
+	return position, found, nil
+}
+
+func (mm *MemoryMarkers) SetMarker(ctx context.Context, userID uint64, feedID string, position Position) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	key := markerKey(userID, feedID)
+	if old, found := mm.markers[key]; !found || position.Before(old) {
+		mm.markers[key] = position
+	}
+	return nil
+}
+
+// UnreadOptions configure an unread count.
+type UnreadOptions struct {
+	// Max bounds the count, so that counting stays cheap; badges show
+	// "Max+" beyond it. Defaults to 99.
+	Max int
+	// Grouping counts the items it groups as one, as pages read with it
+	// show them.
+	Grouping Grouping
+}
+
+// Unread is the state of the unread badge of a timeline.
+type Unread struct {
+	// Count is the number of unread items, up to UnreadOptions.Max.
+	Count int
+	// More is true if there are more than Count unread items.
+	More bool
+}
+
+// Returns the badge text: "" when everything is read, the count otherwise,
+// with a "+" if there are more.
+func (u Unread) Badge() string {
+	if u.Count == 0 {
+		return ""
+	}
+	badge := strconv.Itoa(u.Count)
+	if u.More {
+		badge += "+"
+	}
+	return badge
+}
+
+// Marks a timeline read by the viewer up to the newest item of the page
+// cursor was returned with as Prev. HomeFeed(viewerID) is the viewer's home
+// timeline whatever the delivery.
+func (s *Service) MarkRead(ctx context.Context, viewerID uint64, feedID string, cursor string) error {
+	decoded, err := s.cursors.Decode(cursor)
+	if err != nil {
+		return err
+	}
+	return s.options.Markers.SetMarker(ctx, viewerID, feedID, decoded.Position)
+}
+
+// Returns the unread state of a timeline of the viewer: the number of items
+// newer than their read marker, counted as a page shows them, i.e. without
+// the items left out by reads and with groups counting once. Every item is
+// unread on a timeline never marked read.
+func (s *Service) Unread(ctx context.Context, viewerID uint64, feedID string, options UnreadOptions) (Unread, error) {
+	if options.Max <= 0 {
+		options.Max = 99
+	}
+	marker, found, err := s.options.Markers.Marker(ctx, viewerID, feedID)
+	if err != nil {
+		return Unread{}, err
+	}
+	feedIDs, err := s.timelineFeeds(ctx, viewerID, feedID)
+	if err != nil {
+		return Unread{}, err
+	}
+	read := ReadOptions{Limit: options.Max + 1, Grouping: options.Grouping, Ranker: Chronological{}}
+	if found {
+		read.Cursor = s.cursors.Encode(Cursor{Position: marker, Top: marker})
+		read.Direction = Newer
+	}
+	page, err := s.read(ctx, viewerID, feedIDs, read, nil)
+	if err != nil {
+		return Unread{}, err
+	}
+	unread := Unread{Count: len(page.Items)}
+	if unread.Count > options.Max {
+		unread.Count, unread.More = options.Max, true
+	}
+	return unread, nil
+}
+
+// timelineFeeds returns the timelines making up a timeline of the viewer:
+// those of homeFeeds for HomeFeed(viewerID), feedID itself otherwise.
+func (s *Service) timelineFeeds(ctx context.Context, viewerID uint64, feedID string) ([]string, error) {
+	if feedID == HomeFeed(viewerID) {
+		return s.homeFeeds(ctx, viewerID)
+	}
+	return []string{feedID}, nil
+}
@@ -0,0 +1,60 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUnread(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(PushDelivery)
+	defer s.Close()
+	home := HomeFeed(1)
+	for id := uint64(1); id <= 3; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+
+	unread := func(options UnreadOptions) Unread {
+		u, err := s.Unread(ctx, 1, home, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	if u := unread(UnreadOptions{}); u != (Unread{Count: 3}) || u.Badge() != "3" {
+		t.Errorf("expected: %v, got: %v", Unread{Count: 3}, u)
+	}
+	first, _ := s.Read(ctx, 1, ReadOptions{})
+	older, _ := s.Read(ctx, 1, ReadOptions{Limit: 1, Cursor: first.Next})
+	s.MarkRead(ctx, 1, home, first.Prev)
+	// Markers never move back.
+	s.MarkRead(ctx, 1, home, older.Prev)
+	if u := unread(UnreadOptions{}); u != (Unread{}) || u.Badge() != "" {
+		t.Errorf("expected: %v, got: %v", Unread{}, u)
+	}
+
+	// Two likes of the same photo group into one.
+	s.Publish(ctx, &Item{ID: 4, AuthorID: 10, Timestamp: 4, Verb: "like", ObjectID: 9})
+	s.Publish(ctx, &Item{ID: 5, AuthorID: 10, Timestamp: 5, Verb: "like", ObjectID: 9})
+	s.Publish(ctx, &Item{ID: 6, AuthorID: 10, Timestamp: 6})
+	s.Wait()
+	tests := []struct {
+		options  UnreadOptions
+		expected Unread
+		badge    string
+	}{
+		{UnreadOptions{}, Unread{Count: 3}, "3"},
+		{UnreadOptions{Max: 2}, Unread{Count: 2, More: true}, "2+"},
+		{UnreadOptions{Grouping: Grouping{Key: GroupByObject}}, Unread{Count: 2}, "2"},
+	}
+	for _, test := range tests {
+		if u := unread(test.options); u != test.expected || u.Badge() != test.badge {
+			t.Errorf("%+v, expected: %v, got: %v", test.options, test.expected, u)
+		}
+	}
+
+	if err := s.MarkRead(ctx, 1, home, "forged"); err != ErrBadCursor {
+		t.Errorf("expected: %v, got: %v", ErrBadCursor, err)
+	}
+}
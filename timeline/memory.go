@@ -0,0 +1,232 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"feed/treeset"
+)
+
+// byPosition orders entries newest first.
+func byPosition(a, b interface{}) int {
+	x, y := a.(Entry).Position(), b.(Entry).Position()
+	switch {
+	case x == y:
+		return 0
+	case x.Before(y):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// memoryTimeline is a timeline kept as a treeset of entries, with indexes
+// from item ID to entry and from author to item IDs, so entries can be
+// deleted by item and by author.
+type memoryTimeline struct {
+	entries  *treeset.Set
+	byItem   map[uint64]Entry
+	byAuthor map[uint64]map[uint64]bool
+}
+
+// MemoryStorage is a Storage kept in process memory, for tests and for
+// small deployments that can afford to lose timelines on restart.
+// Structure is thread safe.
+type MemoryStorage struct {
+	mu        sync.RWMutex
+	timelines map[string]*memoryTimeline
+	items     map[uint64]*Item
+}
+
+// Instantiates a new empty memory storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{timelines: make(map[string]*memoryTimeline), items: make(map[uint64]*Item)}
+}
+
+func (ms *MemoryStorage) timeline(feedID string, create bool) *memoryTimeline {
+	tl := ms.timelines[feedID]
+	if tl == nil && create {
+		tl = &memoryTimeline{
+			entries:  treeset.NewWith(byPosition),
+			byItem:   make(map[uint64]Entry),
+			byAuthor: make(map[uint64]map[uint64]bool),
+		}
+		ms.timelines[feedID] = tl
+	}
+	return tl
+}
+
+func (tl *memoryTimeline) remove(itemID uint64) {
+	if old, found := tl.byItem[itemID]; found {
+		tl.entries.Remove(old)
+		delete(tl.byItem, itemID)
+		delete(tl.byAuthor[old.AuthorID], itemID)
+		if len(tl.byAuthor[old.AuthorID]) == 0 {
+			delete(tl.byAuthor, old.AuthorID)
+		}
+	}
+}
+
+func (tl *memoryTimeline) append(entries []Entry) {
+	for _, entry := range entries {
+		tl.remove(entry.ItemID)
+		tl.entries.Add(entry)
+		tl.byItem[entry.ItemID] = entry
+		if tl.byAuthor[entry.AuthorID] == nil {
+			tl.byAuthor[entry.AuthorID] = make(map[uint64]bool)
+		}
+		tl.byAuthor[entry.AuthorID][entry.ItemID] = true
+	}
+}
+
+func (ms *MemoryStorage) AppendItems(ctx context.Context, feedID string, entries ...Entry) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.timeline(feedID, true).append(entries)
+	return nil
+}
+
+// Evicting the lowest score scans the timeline, in O(n) per entry evicted.
+func (ms *MemoryStorage) AppendCapped(ctx context.Context, feedID string, maxLen int, eviction Eviction, entries ...Entry) ([]Entry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	tl := ms.timeline(feedID, true)
+	tl.append(entries)
+	evicted := []Entry{}
+	for tl.entries.Size() > maxLen {
+		var victim Entry
+		if eviction == EvictLowestScore {
+			// Walk newest to oldest, so that the oldest of equal scores wins.
+			newest, _ := tl.entries.Min()
+			victim = newest.(Entry)
+			tl.entries.Each(func(index int, value interface{}) {
+				if entry := value.(Entry); entry.Score <= victim.Score {
+					victim = entry
+				}
+			})
+		} else {
+			oldest, _ := tl.entries.Max()
+			victim = oldest.(Entry)
+		}
+		tl.remove(victim.ItemID)
+		evicted = append(evicted, victim)
+	}
+	return evicted, nil
+}
+
+func (ms *MemoryStorage) RangeByCursor(ctx context.Context, feedID string, from *Position, direction Direction, limit int) ([]Entry, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	entries := []Entry{}
+	tl := ms.timeline(feedID, false)
+	if tl == nil || limit <= 0 {
+		return entries, nil
+	}
+	// Older walks the set forwards from the position, Newer backwards.
+	it := tl.entries.Iterator()
+	step := it.Next
+	if direction == Newer {
+		step = it.Prev
+		it.End()
+	}
+	if from != nil {
+		anchor := Entry{Timestamp: from.Timestamp, ItemID: from.ItemID}
+		if it.Seek(anchor) && direction == Older && it.Value().(Entry).Position() != *from {
+			it.Prev()
+		}
+	}
+	for ok := step(); ok && len(entries) < limit; ok = step() {
+		entries = append(entries, it.Value().(Entry))
+	}
+	return entries, nil
+}
+
+func (ms *MemoryStorage) Trim(ctx context.Context, feedID string, maxLen int) ([]Entry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	removed := []Entry{}
+	tl := ms.timeline(feedID, false)
+	if tl == nil {
+		return removed, nil
+	}
+	for tl.entries.Size() > maxLen {
+		oldest, _ := tl.entries.Max()
+		tl.remove(oldest.(Entry).ItemID)
+		removed = append(removed, oldest.(Entry))
+	}
+	return removed, nil
+}
+
+func (ms *MemoryStorage) Delete(ctx context.Context, feedID string, itemIDs ...uint64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if tl := ms.timeline(feedID, false); tl != nil {
+		for _, itemID := range itemIDs {
+			tl.remove(itemID)
+		}
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) DeleteByAuthor(ctx context.Context, feedID string, authorID uint64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if tl := ms.timeline(feedID, false); tl != nil {
+		for itemID := range tl.byAuthor[authorID] {
+			tl.remove(itemID)
+		}
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) PutItems(ctx context.Context, items ...*Item) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, item := range items {
+		copied := *item
+		ms.items[item.ID] = &copied
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) UpdateItem(ctx context.Context, item *Item) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	old, found := ms.items[item.ID]
+	if !found || old.DeletedAt != 0 || old.Version >= item.Version {
+		return false, nil
+	}
+	copied := *item
+	ms.items[item.ID] = &copied
+	return true, nil
+}
+
+func (ms *MemoryStorage) MultiGet(ctx context.Context, itemIDs ...uint64) (map[uint64]*Item, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	items := make(map[uint64]*Item, len(itemIDs))
+	for _, itemID := range itemIDs {
+		if item, found := ms.items[itemID]; found {
+			copied := *item
+			items[itemID] = &copied
+		}
+	}
+	return items, nil
+}
+
+func (ms *MemoryStorage) ListFeeds(ctx context.Context, after string, limit int) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	feedIDs := []string{}
+	for feedID := range ms.timelines {
+		if feedID > after {
+			feedIDs = append(feedIDs, feedID)
+		}
+	}
+	sort.Strings(feedIDs)
+	if limit >= 0 && len(feedIDs) > limit {
+		feedIDs = feedIDs[:limit]
+	}
+	return feedIDs, nil
+}
@@ -0,0 +1,129 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func itemIDs(entries []Entry) []uint64 {
+	ids := []uint64{}
+	for _, entry := range entries {
+		ids = append(ids, entry.ItemID)
+	}
+	return ids
+}
+
+func TestMemoryStorageRange(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	// Items 1..5 at timestamps 10..50, plus item 6 sharing timestamp 30.
+	for id := uint64(1); id <= 5; id++ {
+		s.AppendItems(ctx, "home:1", Entry{ItemID: id, AuthorID: 7, Timestamp: int64(id) * 10})
+	}
+	s.AppendItems(ctx, "home:1", Entry{ItemID: 6, AuthorID: 7, Timestamp: 30})
+
+	at := func(timestamp int64, itemID uint64) *Position {
+		return &Position{Timestamp: timestamp, ItemID: itemID}
+	}
+	tests := []struct {
+		name      string
+		from      *Position
+		direction Direction
+		limit     int
+		expected  []uint64
+	}{
+		{"newest", nil, Older, 3, []uint64{5, 4, 6}},
+		{"older than 6", at(30, 6), Older, 10, []uint64{3, 2, 1}},
+		{"older than a gap", at(35, 0), Older, 2, []uint64{6, 3}},
+		{"older than the oldest", at(10, 1), Older, 10, []uint64{}},
+		{"oldest", nil, Newer, 2, []uint64{1, 2}},
+		{"newer than 3", at(30, 3), Newer, 10, []uint64{6, 4, 5}},
+		{"newer than the newest", at(50, 5), Newer, 10, []uint64{}},
+		{"newer than a gap", at(5, 0), Newer, 1, []uint64{1}},
+		{"zero limit", nil, Older, 0, []uint64{}},
+	}
+	for _, test := range tests {
+		entries, err := s.RangeByCursor(ctx, "home:1", test.from, test.direction, test.limit)
+		if err != nil || !reflect.DeepEqual(itemIDs(entries), test.expected) {
+			t.Errorf("%s, expected: %v, got: %v (%v)", test.name, test.expected, itemIDs(entries), err)
+		}
+	}
+
+	if entries, _ := s.RangeByCursor(ctx, "home:2", nil, Older, 10); len(entries) != 0 {
+		t.Errorf("expected an unknown timeline to be empty, got: %v", entries)
+	}
+}
+
+func TestMemoryStorageTrimAndDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for id := uint64(1); id <= 5; id++ {
+		s.AppendItems(ctx, "home:1", Entry{ItemID: id, Timestamp: int64(id)})
+	}
+	// Re-appending moves the entry rather than duplicating it.
+	s.AppendItems(ctx, "home:1", Entry{ItemID: 1, Timestamp: 100})
+
+	removed, err := s.Trim(ctx, "home:1", 3)
+	if err != nil || !reflect.DeepEqual(itemIDs(removed), []uint64{2, 3}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2, 3}, itemIDs(removed))
+	}
+	s.Delete(ctx, "home:1", 4, 42)
+	entries, _ := s.RangeByCursor(ctx, "home:1", nil, Older, 10)
+	if !reflect.DeepEqual(itemIDs(entries), []uint64{1, 5}) {
+		t.Errorf("expected: %v, got: %v", []uint64{1, 5}, itemIDs(entries))
+	}
+}
+
+func TestMemoryStorageDeleteByAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for id := uint64(1); id <= 6; id++ {
+		s.AppendItems(ctx, "home:1", Entry{ItemID: id, AuthorID: 10 + id%2, Timestamp: int64(id)})
+	}
+	// Re-appending under another author moves the entry between authors.
+	s.AppendItems(ctx, "home:1", Entry{ItemID: 5, AuthorID: 10, Timestamp: 5})
+	s.Trim(ctx, "home:1", 5)
+
+	s.DeleteByAuthor(ctx, "home:1", 11)
+	s.DeleteByAuthor(ctx, "home:2", 11)
+	if ids := itemIDs(mustRange(t, s, "home:1")); !reflect.DeepEqual(ids, []uint64{6, 5, 4, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{6, 5, 4, 2}, ids)
+	}
+}
+
+func TestMemoryStorageAppendCapped(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	scores := []float64{0, 5, 1, 5, 1, 3}
+	for id := uint64(1); id <= 5; id++ {
+		s.AppendItems(ctx, "a", Entry{ItemID: id, Timestamp: int64(id), Score: scores[id]})
+		s.AppendItems(ctx, "b", Entry{ItemID: id, Timestamp: int64(id), Score: scores[id]})
+	}
+
+	evicted, _ := s.AppendCapped(ctx, "a", 4, EvictOldest, Entry{ItemID: 6, Timestamp: 0})
+	if !reflect.DeepEqual(itemIDs(evicted), []uint64{6, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{6, 1}, itemIDs(evicted))
+	}
+	// Items 2 and 4 tie for the lowest score; 2 is older.
+	evicted, _ = s.AppendCapped(ctx, "b", 3, EvictLowestScore, Entry{ItemID: 6, Timestamp: 6, Score: scores[5]})
+	if !reflect.DeepEqual(itemIDs(evicted), []uint64{2, 4, 5}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2, 4, 5}, itemIDs(evicted))
+	}
+	if ids := itemIDs(mustRange(t, s, "b")); !reflect.DeepEqual(ids, []uint64{6, 3, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{6, 3, 1}, ids)
+	}
+}
+
+func TestMemoryStorageItems(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	item := &Item{ID: 1, AuthorID: 2, Timestamp: 3, Payload: []byte("hello")}
+	s.PutItems(ctx, item, &Item{ID: 2})
+	item.AuthorID = 99 // the storage keeps its own copy
+
+	items, err := s.MultiGet(ctx, 1, 3)
+	if err != nil || len(items) != 1 || items[1].AuthorID != 2 || string(items[1].Payload) != "hello" {
+		t.Errorf("unexpected items: %v (%v)", items, err)
+	}
+}
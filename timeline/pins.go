@@ -0,0 +1,109 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrTooManyPins = errors.New("timeline: too many pinned items")
+
+// Pins item to the top of a timeline, e.g. a profile or topic timeline,
+// regardless of its position or score. Pinning a pinned item moves it to the
+// top of the pins. Fails with ErrNotFound if the item is not stored, and with
+// ErrTooManyPins if Options.MaxPinned items are pinned already.
+// The cap is enforced per process: servers pinning to the same timeline at
+// once can overshoot it.
+func (s *Service) Pin(ctx context.Context, feedID string, itemID uint64) error {
+	stored, err := s.storage.MultiGet(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	item, found := stored[itemID]
+	if !found {
+		return ErrNotFound
+	}
+	s.pinMu.Lock()
+	defer s.pinMu.Unlock()
+	pins, err := s.storage.RangeByCursor(ctx, PinsFeed(feedID), nil, Older, s.options.MaxPinned+1)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, pin := range pins {
+		if pin.ItemID != itemID {
+			count++
+		}
+	}
+	if count >= s.options.MaxPinned {
+		return ErrTooManyPins
+	}
+	pin := Entry{ItemID: item.ID, AuthorID: item.AuthorID, Timestamp: timestampOf(s.options.Now())}
+	return s.storage.AppendItems(ctx, PinsFeed(feedID), pin)
+}
+
+// Unpins item from a timeline. Unpinning an item that is not pinned does
+// nothing.
+func (s *Service) Unpin(ctx context.Context, feedID string, itemID uint64) error {
+	return s.storage.Delete(ctx, PinsFeed(feedID), itemID)
+}
+
+// Returns the items pinned to a timeline, most recently pinned first,
+// leaving out deleted and expired ones.
+func (s *Service) Pinned(ctx context.Context, feedID string) ([]*Item, error) {
+	pins, err := s.storage.RangeByCursor(ctx, PinsFeed(feedID), nil, Older, s.options.MaxPinned)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.storage.MultiGet(ctx, entryIDs(pins)...)
+	if err != nil {
+		return nil, err
+	}
+	now := timestampOf(s.options.Now())
+	items := []*Item{}
+	for _, pin := range pins {
+		if item, found := stored[pin.ItemID]; found && item.Visible(now) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Returns a page of a single timeline for the viewer, e.g. a user or topic
+// timeline, merged with options.Feeds. Its pinned items come first on the
+// newest page, and are left out of the timeline's items on every page. The
+// page is read with the experiment variants of the viewer, as Read does.
+func (s *Service) ReadFeed(ctx context.Context, viewerID uint64, feedID string, options ReadOptions) (*Page, error) {
+	pinned, err := s.Pinned(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+	isPinned := make(map[uint64]bool, len(pinned))
+	for _, item := range pinned {
+		isPinned[item.ID] = true
+	}
+	notPinned := Filter{Name: "pinned", Keep: func(item *Item) bool {
+		return !isPinned[item.ID]
+	}}
+	options, filters, variants := s.experiment(ctx, viewerID, options)
+	kind, _, _ := ParseFeed(feedID)
+	options.Ranker = s.ranker(options, kind)
+	feedIDs := append([]string{feedID}, options.Feeds...)
+	page, err := s.read(ctx, viewerID, feedIDs, options, append([]Filter{notPinned}, filters...))
+	if err != nil {
+		return nil, err
+	}
+	page.Variants = variants
+	if options.Cursor == "" && options.At.IsZero() {
+		blocked, err := s.blockFilter(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		page.Pinned = []*Item{}
+		for _, item := range pinned {
+			if blocked == nil || blocked(item) {
+				page.Pinned = append(page.Pinned, item)
+			}
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,54 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPins(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1473000000, 0)
+	clock := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	s := NewService(NewMemoryStorage(), NewMemoryGraph(), Options{MaxPinned: 2, Now: clock})
+	defer s.Close()
+	for id := uint64(1); id <= 5; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+	feedID := UserFeed(10)
+
+	if err := s.Pin(ctx, feedID, 2); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	s.Pin(ctx, feedID, 4)
+	if err := s.Pin(ctx, feedID, 1); err != ErrTooManyPins {
+		t.Errorf("expected: %v, got: %v", ErrTooManyPins, err)
+	}
+	if err := s.Pin(ctx, feedID, 99); err != ErrNotFound {
+		t.Errorf("expected: %v, got: %v", ErrNotFound, err)
+	}
+
+	// Pinned items lead the newest page and are left out of every page.
+	page, err := s.ReadFeed(ctx, 1, feedID, ReadOptions{Limit: 2})
+	if err != nil || !reflect.DeepEqual(readIDs(page.Pinned), []uint64{4, 2}) || !reflect.DeepEqual(readIDs(page.Items), []uint64{5, 3}) {
+		t.Fatalf("expected: %v %v, got: %v %v (%v)", []uint64{4, 2}, []uint64{5, 3}, readIDs(page.Pinned), readIDs(page.Items), err)
+	}
+	page, _ = s.ReadFeed(ctx, 1, feedID, ReadOptions{Limit: 2, Cursor: page.Next})
+	if page.Pinned != nil || !reflect.DeepEqual(readIDs(page.Items), []uint64{1}) {
+		t.Errorf("expected: %v, got: %v %v", []uint64{1}, readIDs(page.Pinned), readIDs(page.Items))
+	}
+
+	// Pinning again moves to the top without counting twice.
+	if err := s.Pin(ctx, feedID, 2); err != nil {
+		t.Errorf("expected: %v, got: %v", nil, err)
+	}
+	s.Unpin(ctx, feedID, 4)
+	if pinned, _ := s.Pinned(ctx, feedID); !reflect.DeepEqual(readIDs(pinned), []uint64{2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2}, readIDs(pinned))
+	}
+}
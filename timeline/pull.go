@@ -0,0 +1,130 @@
+package timeline
+
+import (
+	"context"
+	"sync"
+
+	"feed/treeset"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// Puller assembles home timelines at read time (fan-out on read): instead of
+// reading a materialized home timeline it merges the user timelines of
+// everyone the viewer follows. This costs more per read but no storage per
+// follower, which suits users who read rarely.
+// Structure is thread safe.
+type Puller struct {
+	storage     Storage
+	graph       Graph
+	concurrency int
+}
+
+// Instantiates a new puller reading up to concurrency user timelines at once.
+func NewPuller(storage Storage, graph Graph, concurrency int) *Puller {
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+	return &Puller{storage: storage, graph: graph, concurrency: concurrency}
+}
+
+// Returns up to limit entries of the viewer's home timeline on the direction
+// side of from, closest to it first, as Storage.RangeByCursor does, merged
+// from the user timelines of the viewer and everyone they follow.
+func (p *Puller) Read(ctx context.Context, viewerID uint64, from *Position, direction Direction, limit int) ([]Entry, error) {
+	followees, err := p.graph.Followees(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	feedIDs := []string{UserFeed(viewerID)}
+	for _, followee := range followees {
+		feedIDs = append(feedIDs, UserFeed(followee))
+	}
+	return p.readMerged(ctx, feedIDs, from, direction, limit)
+}
+
+// readMerged returns up to limit entries on the direction side of from,
+// merged from the timelines.
+func (p *Puller) readMerged(ctx context.Context, feedIDs []string, from *Position, direction Direction, limit int) ([]Entry, error) {
+	pages, err := p.readAll(ctx, feedIDs, from, direction, limit)
+	if err != nil {
+		return nil, err
+	}
+	return mergePages(pages, direction, limit), nil
+}
+
+// readAll reads a page of each timeline, up to p.concurrency at once.
+func (p *Puller) readAll(ctx context.Context, feedIDs []string, from *Position, direction Direction, limit int) ([][]Entry, error) {
+	pages := make([][]Entry, len(feedIDs))
+	errs := make([]error, len(feedIDs))
+	slots := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i, feedID := range feedIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, feedID string) {
+			defer wg.Done()
+			pages[i], errs[i] = p.storage.RangeByCursor(ctx, feedID, from, direction, limit)
+			<-slots
+		}(i, feedID)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// celebrities returns those of users followed by more than threshold users,
+// in order, counting followers up to p.concurrency at once.
+func (p *Puller) celebrities(ctx context.Context, users []uint64, threshold int) ([]uint64, error) {
+	counts := make([]int, len(users))
+	errs := make([]error, len(users))
+	slots := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i, user := range users {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, user uint64) {
+			defer wg.Done()
+			counts[i], errs[i] = p.graph.FollowerCount(ctx, user)
+			<-slots
+		}(i, user)
+	}
+	wg.Wait()
+	celebrities := []uint64{}
+	for i, user := range users {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if counts[i] > threshold {
+			celebrities = append(celebrities, user)
+		}
+	}
+	return celebrities, nil
+}
+
+// mergePages k-way merges pages that are each ordered as direction reads
+// them into the first limit entries in that order, keeping an entry found in
+// several pages once.
+func mergePages(pages [][]Entry, direction Direction, limit int) []Entry {
+	order := utils.Comparator(byPosition)
+	if direction == Newer {
+		order = treeset.Reverse(byPosition)
+	}
+	sets := make([]*treeset.Set, 0, len(pages))
+	for _, page := range pages {
+		values := make([]interface{}, len(page))
+		for i, entry := range page {
+			values[i] = entry
+		}
+		sets = append(sets, treeset.NewFromSorted(order, values...))
+	}
+	entries := []Entry{}
+	for _, value := range treeset.MergeIterator(order, true, sets...).Take(limit) {
+		entries = append(entries, value.(Entry))
+	}
+	return entries
+}
@@ -0,0 +1,89 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPullerRead(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 2)
+	graph.Follow(1, 3)
+	// Author 2 posts at even, author 3 at odd timestamps; 4 is not followed.
+	for id := uint64(1); id <= 10; id++ {
+		author := 2 + id%2
+		storage.AppendItems(ctx, UserFeed(author), Entry{ItemID: id, AuthorID: author, Timestamp: int64(id)})
+		storage.AppendItems(ctx, UserFeed(4), Entry{ItemID: 100 + id, AuthorID: 4, Timestamp: int64(id)})
+	}
+	storage.AppendItems(ctx, UserFeed(1), Entry{ItemID: 11, AuthorID: 1, Timestamp: 11})
+
+	p := NewPuller(storage, graph, 2)
+	entries, err := p.Read(ctx, 1, nil, Older, 4)
+	if err != nil || !reflect.DeepEqual(itemIDs(entries), []uint64{11, 10, 9, 8}) {
+		t.Errorf("expected: %v, got: %v (%v)", []uint64{11, 10, 9, 8}, itemIDs(entries), err)
+	}
+	last := entries[len(entries)-1].Position()
+	entries, _ = p.Read(ctx, 1, &last, Older, 10)
+	if !reflect.DeepEqual(itemIDs(entries), []uint64{7, 6, 5, 4, 3, 2, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{7, 6, 5, 4, 3, 2, 1}, itemIDs(entries))
+	}
+	if entries, _ := p.Read(ctx, 5, nil, Older, 10); len(entries) != 0 {
+		t.Errorf("expected an empty home timeline, got: %v", itemIDs(entries))
+	}
+}
+
+func TestMergePagesDedups(t *testing.T) {
+	a := []Entry{{ItemID: 3, Timestamp: 3}, {ItemID: 1, Timestamp: 1}}
+	b := []Entry{{ItemID: 3, Timestamp: 3}, {ItemID: 2, Timestamp: 2}}
+	if ids := itemIDs(mergePages([][]Entry{a, b, nil}, Older, 10)); !reflect.DeepEqual(ids, []uint64{3, 2, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{3, 2, 1}, ids)
+	}
+}
+
+// countingGraph tracks the follower counts looked up at once.
+type countingGraph struct {
+	*MemoryGraph
+	mu                sync.Mutex
+	inFlight, maxSeen int
+}
+
+func (cg *countingGraph) FollowerCount(ctx context.Context, userID uint64) (int, error) {
+	cg.mu.Lock()
+	cg.inFlight++
+	if cg.inFlight > cg.maxSeen {
+		cg.maxSeen = cg.inFlight
+	}
+	cg.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	cg.mu.Lock()
+	cg.inFlight--
+	cg.mu.Unlock()
+	return cg.MemoryGraph.FollowerCount(ctx, userID)
+}
+
+func TestPullerCelebrities(t *testing.T) {
+	graph := &countingGraph{MemoryGraph: NewMemoryGraph()}
+	users := []uint64{}
+	for user := uint64(1); user <= 20; user++ {
+		users = append(users, user)
+		for follower := uint64(0); follower < user%4; follower++ {
+			graph.Follow(100+follower, user)
+		}
+	}
+	p := NewPuller(NewMemoryStorage(), graph, 4)
+	celebrities, err := p.celebrities(context.Background(), users, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint64{3, 7, 11, 15, 19}; !reflect.DeepEqual(celebrities, expected) {
+		t.Errorf("expected: %v, got: %v", expected, celebrities)
+	}
+	if graph.maxSeen < 2 || graph.maxSeen > 4 {
+		t.Errorf("expected concurrent lookups bounded by 4, got: %v", graph.maxSeen)
+	}
+}
@@ -0,0 +1,453 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("timeline: pusher is closed")
+
+// PushOptions configure a Pusher. Zero values select the defaults.
+type PushOptions struct {
+	// BatchSize is the number of timelines one worker writes per task.
+	// Defaults to 100.
+	BatchSize int
+	// Concurrency is the number of batches written at once. Defaults to 8.
+	Concurrency int
+	// MaxAttempts bounds the writes to one timeline, including the first.
+	// Defaults to 3.
+	MaxAttempts int
+	// RetryBackoff is the pause before the second attempt, doubled for each
+	// attempt after it. Defaults to 50ms.
+	RetryBackoff time.Duration
+	// QueueSize is the number of posts waiting for fan-out before Publish
+	// blocks. Defaults to 1024.
+	QueueSize int
+	// MaxFollowers, if positive, skips the fan-out of posts by authors with
+	// more followers, whose posts readers pull instead (see Service), and
+	// likewise their retraction and updates.
+	MaxFollowers int
+	// Cap bounds the length of home timelines on write.
+	Cap Cap
+	// BackfillItems bounds the items Backfill copies. Defaults to 50.
+	BackfillItems int
+	// BackfillAge bounds the age of the items Backfill copies. Defaults to
+	// 7 days.
+	BackfillAge time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// OnError, if set, is called for every timeline that could not be
+	// written after MaxAttempts, for posts whose followers could not be
+	// listed (with an empty feedID), and for every entry that could not be
+	// archived.
+	OnError func(feedID string, entry Entry, err error)
+}
+
+// Truncation selects what happens to the entries beyond a Cap.
+type Truncation int
+
+const (
+	// DropOldest deletes the oldest entries.
+	DropOldest Truncation = iota
+	// DropLowestScore deletes the entries with the lowest Cap.Score.
+	DropLowestScore
+	// ArchiveOldest moves the oldest entries to Cap.Archiver.
+	ArchiveOldest
+)
+
+// Archiver keeps the entries truncated from timelines in cold storage.
+// Implementations must be safe for concurrent use.
+type Archiver interface {
+	Archive(ctx context.Context, feedID string, entries []Entry) error
+}
+
+// Cap bounds the length of timelines, enforced atomically with every write
+// by Storage.AppendCapped.
+type Cap struct {
+	// MaxLen is the number of entries a timeline keeps; 0 is unbounded.
+	MaxLen     int
+	Truncation Truncation
+	// Score, for DropLowestScore, scores items when they are published,
+	// e.g. with a Hot ranker, which does not depend on the time of reading.
+	Score func(item *Item) float64
+	// Archiver receives the truncated entries for ArchiveOldest.
+	Archiver Archiver
+}
+
+func (o *PushOptions) setDefaults() {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 8
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 50 * time.Millisecond
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1024
+	}
+	if o.BackfillItems <= 0 {
+		o.BackfillItems = 50
+	}
+	if o.BackfillAge <= 0 {
+		o.BackfillAge = 7 * 24 * time.Hour
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+}
+
+// Pusher delivers posts by fan-out on write: a post is stored and added to
+// the author's user timeline right away, then copied asynchronously into the
+// home timeline of the author and of every follower.
+// Structure is thread safe.
+type Pusher struct {
+	storage Storage
+	graph   Graph
+	options PushOptions
+
+	posts   chan pushTask
+	batches chan pushBatch
+	pending sync.WaitGroup // tasks not done yet
+	workers sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// pushKind selects what a pushTask does.
+type pushKind int
+
+const (
+	// deliverTask adds the post to the home timelines of the author and
+	// their followers.
+	deliverTask pushKind = iota
+	// retractTask removes the post from them.
+	retractTask
+	// backfillTask copies the recent posts of the author into the home
+	// timeline of a new follower.
+	backfillTask
+	// purgeTask removes the posts of the author from the home timeline of
+	// a former follower.
+	purgeTask
+	// updateTask sets the expiry of the entries of the post in the home
+	// timelines holding it.
+	updateTask
+)
+
+type pushTask struct {
+	kind     pushKind
+	entry    Entry
+	follower uint64 // for backfillTask and purgeTask
+}
+
+type pushBatch struct {
+	pushTask
+	recipients []uint64
+}
+
+// Instantiates a new pusher and starts its workers. It panics if
+// options.Cap archives without an Archiver.
+func NewPusher(storage Storage, graph Graph, options PushOptions) *Pusher {
+	if options.Cap.MaxLen > 0 && options.Cap.Truncation == ArchiveOldest && options.Cap.Archiver == nil {
+		panic("timeline: Cap.Truncation ArchiveOldest requires a Cap.Archiver")
+	}
+	options.setDefaults()
+	p := &Pusher{
+		storage: storage,
+		graph:   graph,
+		options: options,
+		posts:   make(chan pushTask, options.QueueSize),
+		batches: make(chan pushBatch, options.Concurrency),
+	}
+	p.workers.Add(1 + options.Concurrency)
+	go p.dispatch()
+	for i := 0; i < options.Concurrency; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Stores item, adds it to the author's user timeline and queues it for
+// fan-out. Blocks while the queue is full, until ctx is done.
+func (p *Pusher) Publish(ctx context.Context, item *Item) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if err := p.storage.PutItems(ctx, item); err != nil {
+		return err
+	}
+	entry := EntryOf(item)
+	if p.options.Cap.Score != nil {
+		entry.Score = p.options.Cap.Score(item)
+	}
+	if err := p.storage.AppendItems(ctx, UserFeed(item.AuthorID), entry); err != nil {
+		return err
+	}
+	return p.enqueue(ctx, pushTask{entry: entry})
+}
+
+// Removes item from the author's user timeline and queues its removal from
+// the home timelines it was delivered to. Like Publish, blocks while the
+// queue is full, until ctx is done.
+func (p *Pusher) Retract(ctx context.Context, item *Item) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if err := p.storage.Delete(ctx, UserFeed(item.AuthorID), item.ID); err != nil {
+		return err
+	}
+	return p.enqueue(ctx, pushTask{kind: retractTask, entry: EntryOf(item)})
+}
+
+// Queues the rewrite of the expiry of the entries of item, whose ExpireAt
+// was edited, in the home timelines it was delivered to. Like Publish,
+// blocks while the queue is full, until ctx is done.
+func (p *Pusher) Update(ctx context.Context, item *Item) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	return p.enqueue(ctx, pushTask{kind: updateTask, entry: EntryOf(item)})
+}
+
+// Queues the copy of the recent posts of followeeID into the home timeline
+// of followerID, who just followed them, so that the follow shows at once
+// rather than with their next post. Followees above MaxFollowers are
+// skipped, as their posts are pulled.
+func (p *Pusher) Backfill(ctx context.Context, followerID, followeeID uint64) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	return p.enqueue(ctx, pushTask{kind: backfillTask, entry: Entry{AuthorID: followeeID}, follower: followerID})
+}
+
+// Queues the removal of the posts of authorID from the home timeline of
+// userID, who unfollowed or blocked them.
+func (p *Pusher) Purge(ctx context.Context, userID, authorID uint64) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	return p.enqueue(ctx, pushTask{kind: purgeTask, entry: Entry{AuthorID: authorID}, follower: userID})
+}
+
+// enqueue queues a task. Callers hold p.mu for reading, so that the task
+// is counted before Wait can start waiting.
+func (p *Pusher) enqueue(ctx context.Context, task pushTask) error {
+	p.pending.Add(1)
+	select {
+	case p.posts <- task:
+		return nil
+	case <-ctx.Done():
+		p.pending.Done()
+		return ctx.Err()
+	}
+}
+
+// Blocks until every task queued so far is done: posts published delivered,
+// posts retracted removed, updates, backfills and purges done.
+// Tasks queued meanwhile wait for it to return.
+func (p *Pusher) Wait() {
+	// Hold off enqueue, as the count must not go up from zero while waiting.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending.Wait()
+}
+
+// Delivers the queued posts and stops the workers. Publish fails afterwards.
+func (p *Pusher) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.posts)
+	p.mu.Unlock()
+	p.workers.Wait()
+}
+
+// dispatch splits every post into batches of recipients.
+func (p *Pusher) dispatch() {
+	defer p.workers.Done()
+	defer close(p.batches)
+	for task := range p.posts {
+		entry := task.entry
+		if task.kind == backfillTask || task.kind == purgeTask {
+			p.dispatchFollower(task)
+			continue
+		}
+		recipients, err := p.recipients(entry.AuthorID)
+		if err != nil {
+			p.fail("", entry, err)
+			p.pending.Done()
+			continue
+		}
+		for len(recipients) > 0 {
+			n := p.options.BatchSize
+			if n > len(recipients) {
+				n = len(recipients)
+			}
+			p.pending.Add(1)
+			p.batches <- pushBatch{pushTask: task, recipients: recipients[:n]}
+			recipients = recipients[n:]
+		}
+		p.pending.Done()
+	}
+}
+
+// recipients returns the users whose home timelines get the posts of the
+// author: the author and, unless there are more than MaxFollowers of them,
+// their followers. Followers are counted before they are listed, so that
+// authors whose posts are pulled cost a count per post.
+func (p *Pusher) recipients(authorID uint64) ([]uint64, error) {
+	recipients := []uint64{authorID}
+	if p.options.MaxFollowers > 0 {
+		count, err := p.graph.FollowerCount(context.Background(), authorID)
+		if err != nil {
+			return nil, err
+		}
+		if count > p.options.MaxFollowers {
+			return recipients, nil
+		}
+	}
+	followers, err := p.graph.Followers(context.Background(), authorID)
+	if err != nil {
+		return nil, err
+	}
+	return append(recipients, followers...), nil
+}
+
+// dispatchFollower hands a task on the home timeline of a follower to the
+// workers. Backfills of authors whose posts are pulled are skipped.
+func (p *Pusher) dispatchFollower(task pushTask) {
+	defer p.pending.Done()
+	if task.kind == backfillTask && p.options.MaxFollowers > 0 {
+		count, err := p.graph.FollowerCount(context.Background(), task.entry.AuthorID)
+		if err != nil {
+			p.fail("", task.entry, err)
+			return
+		}
+		if count > p.options.MaxFollowers {
+			return
+		}
+	}
+	p.pending.Add(1)
+	p.batches <- pushBatch{pushTask: task, recipients: []uint64{task.follower}}
+}
+
+func (p *Pusher) work() {
+	defer p.workers.Done()
+	for batch := range p.batches {
+		entry := batch.entry
+		for _, userID := range batch.recipients {
+			feedID := HomeFeed(userID)
+			switch batch.kind {
+			case backfillTask:
+				p.backfill(feedID, entry.AuthorID)
+			case purgeTask:
+				p.deliver(feedID, entry, func() error {
+					return p.storage.DeleteByAuthor(context.Background(), feedID, entry.AuthorID)
+				})
+			case updateTask:
+				p.deliver(feedID, entry, func() error {
+					return reexpire(context.Background(), p.storage, feedID, entry)
+				})
+			case retractTask:
+				p.deliver(feedID, entry, func() error {
+					return p.storage.Delete(context.Background(), feedID, entry.ItemID)
+				})
+			default:
+				p.deliver(feedID, entry, func() error {
+					return p.append(feedID, entry)
+				})
+			}
+		}
+		p.pending.Done()
+	}
+}
+
+// backfill copies the recent posts of an author into a home timeline.
+func (p *Pusher) backfill(feedID string, authorID uint64) {
+	entries, err := p.storage.RangeByCursor(context.Background(), UserFeed(authorID), nil, Older, p.options.BackfillItems)
+	if err != nil {
+		p.fail(feedID, Entry{AuthorID: authorID}, err)
+		return
+	}
+	now := p.options.Now()
+	since := timestampOf(now.Add(-p.options.BackfillAge))
+	recent := []Entry{}
+	for _, entry := range entries {
+		if entry.Timestamp >= since && !entry.Expired(timestampOf(now)) {
+			recent = append(recent, entry)
+		}
+	}
+	if len(recent) == 0 {
+		return
+	}
+	p.deliver(feedID, Entry{AuthorID: authorID}, func() error {
+		return p.append(feedID, recent...)
+	})
+}
+
+// deliver runs write on a timeline, retrying with exponential backoff, and
+// reports entry as failed if every attempt fails.
+func (p *Pusher) deliver(feedID string, entry Entry, write func() error) {
+	backoff := p.options.RetryBackoff
+	var err error
+	for attempt := 1; attempt <= p.options.MaxAttempts; attempt++ {
+		if err = write(); err == nil {
+			return
+		}
+		if attempt < p.options.MaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	p.fail(feedID, entry, err)
+}
+
+// append appends entries to a home timeline, applying the cap.
+func (p *Pusher) append(feedID string, entries ...Entry) error {
+	limit := p.options.Cap
+	if limit.MaxLen <= 0 {
+		return p.storage.AppendItems(context.Background(), feedID, entries...)
+	}
+	eviction := EvictOldest
+	if limit.Truncation == DropLowestScore {
+		eviction = EvictLowestScore
+	}
+	evicted, err := p.storage.AppendCapped(context.Background(), feedID, limit.MaxLen, eviction, entries...)
+	if err != nil || len(evicted) == 0 || limit.Truncation != ArchiveOldest {
+		return err
+	}
+	// The entries are out of the timeline already: archiving is not retried
+	// with the append, and failures only reported.
+	if err := limit.Archiver.Archive(context.Background(), feedID, evicted); err != nil {
+		for _, lost := range evicted {
+			p.fail(feedID, lost, err)
+		}
+	}
+	return nil
+}
+
+func (p *Pusher) fail(feedID string, entry Entry, err error) {
+	if p.options.OnError != nil {
+		p.options.OnError(feedID, entry, err)
+	}
+}
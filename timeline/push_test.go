@@ -0,0 +1,226 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyStorage fails the first failures appends to each home timeline.
+type flakyStorage struct {
+	*MemoryStorage
+	mu       sync.Mutex
+	failures int
+	attempts map[string]int
+}
+
+func (fs *flakyStorage) AppendItems(ctx context.Context, feedID string, entries ...Entry) error {
+	fs.mu.Lock()
+	fs.attempts[feedID]++
+	failed := strings.HasPrefix(feedID, "home:") && fs.attempts[feedID] <= fs.failures
+	fs.mu.Unlock()
+	if failed {
+		return errors.New("unavailable")
+	}
+	return fs.MemoryStorage.AppendItems(ctx, feedID, entries...)
+}
+
+func TestPusherFanOut(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	for follower := uint64(2); follower <= 250; follower++ {
+		graph.Follow(follower, 1)
+	}
+	p := NewPusher(storage, graph, PushOptions{BatchSize: 16, Concurrency: 4})
+	defer p.Close()
+
+	for id := uint64(1); id <= 3; id++ {
+		if err := p.Publish(ctx, &Item{ID: id, AuthorID: 1, Timestamp: int64(id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Wait()
+
+	for _, feedID := range []string{UserFeed(1), HomeFeed(1), HomeFeed(2), HomeFeed(250)} {
+		if ids := itemIDs(mustRange(t, storage, feedID)); !reflect.DeepEqual(ids, []uint64{3, 2, 1}) {
+			t.Errorf("%s, expected: %v, got: %v", feedID, []uint64{3, 2, 1}, ids)
+		}
+	}
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(251))); len(ids) != 0 {
+		t.Errorf("expected nothing for a non-follower, got: %v", ids)
+	}
+	if items, _ := storage.MultiGet(ctx, 1, 2, 3); len(items) != 3 {
+		t.Errorf("expected the items to be stored, got: %v", items)
+	}
+}
+
+func TestPusherRetries(t *testing.T) {
+	storage := &flakyStorage{MemoryStorage: NewMemoryStorage(), failures: 2, attempts: make(map[string]int)}
+	graph := NewMemoryGraph()
+	graph.Follow(2, 1)
+
+	var mu sync.Mutex
+	var failed []string
+	options := PushOptions{
+		RetryBackoff: time.Millisecond,
+		OnError: func(feedID string, entry Entry, err error) {
+			mu.Lock()
+			failed = append(failed, fmt.Sprint(feedID, " ", entry.ItemID))
+			mu.Unlock()
+		},
+	}
+
+	options.MaxAttempts = 3
+	p := NewPusher(storage, graph, options)
+	if err := p.Publish(context.Background(), &Item{ID: 9, AuthorID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(2))); !reflect.DeepEqual(ids, []uint64{9}) || len(failed) != 0 {
+		t.Errorf("expected delivery on the third attempt, got: %v, failed: %v", ids, failed)
+	}
+
+	options.MaxAttempts = 2
+	p = NewPusher(storage, graph, options)
+	p.Publish(context.Background(), &Item{ID: 10, AuthorID: 3})
+	p.Close()
+	if expected := []string{"home:3 10"}; !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected: %v, got: %v", expected, failed)
+	}
+	if err := p.Publish(context.Background(), &Item{ID: 11, AuthorID: 3}); err != ErrClosed {
+		t.Errorf("expected: %v, got: %v", ErrClosed, err)
+	}
+}
+
+// archive is an Archiver keeping entries in memory.
+type archive struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+}
+
+func (a *archive) Archive(ctx context.Context, feedID string, entries []Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries[feedID] = append(a.entries[feedID], entries...)
+	return nil
+}
+
+func TestPusherCap(t *testing.T) {
+	ctx := context.Background()
+	graph := NewMemoryGraph()
+	graph.Follow(2, 1)
+	cold := &archive{entries: make(map[string][]Entry)}
+	tests := []struct {
+		name     string
+		cap      Cap
+		expected []uint64
+	}{
+		{"drop oldest", Cap{MaxLen: 2}, []uint64{4, 3}},
+		{"drop lowest score", Cap{MaxLen: 2, Truncation: DropLowestScore, Score: func(item *Item) float64 {
+			return float64(item.Likes)
+		}}, []uint64{3, 1}},
+		{"archive oldest", Cap{MaxLen: 2, Truncation: ArchiveOldest, Archiver: cold}, []uint64{4, 3}},
+	}
+	for _, test := range tests {
+		storage := NewMemoryStorage()
+		p := NewPusher(storage, graph, PushOptions{Cap: test.cap})
+		for id := uint64(1); id <= 4; id++ {
+			p.Publish(ctx, &Item{ID: id, AuthorID: 1, Timestamp: int64(id), Likes: int64(id % 2)})
+		}
+		p.Close()
+		if ids := itemIDs(mustRange(t, storage, HomeFeed(2))); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, ids)
+		}
+		// User timelines are not capped.
+		if ids := itemIDs(mustRange(t, storage, UserFeed(1))); len(ids) != 4 {
+			t.Errorf("%s, expected: %v, got: %v", test.name, 4, len(ids))
+		}
+	}
+	// Posts are delivered concurrently, so the archive order varies.
+	ids := itemIDs(cold.entries[HomeFeed(2)])
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if !reflect.DeepEqual(ids, []uint64{1, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{1, 2}, ids)
+	}
+}
+
+// listingGraph counts the follower lists read.
+type listingGraph struct {
+	*MemoryGraph
+	mu    sync.Mutex
+	lists int
+}
+
+func (lg *listingGraph) Followers(ctx context.Context, userID uint64) ([]uint64, error) {
+	lg.mu.Lock()
+	lg.lists++
+	lg.mu.Unlock()
+	return lg.MemoryGraph.Followers(ctx, userID)
+}
+
+func TestPusherSkipsCelebrities(t *testing.T) {
+	storage := NewMemoryStorage()
+	graph := &listingGraph{MemoryGraph: NewMemoryGraph()}
+	for follower := uint64(2); follower <= 4; follower++ {
+		graph.Follow(follower, 1)
+	}
+	graph.Follow(2, 5)
+	p := NewPusher(storage, graph, PushOptions{MaxFollowers: 2})
+	p.Publish(context.Background(), &Item{ID: 1, AuthorID: 1})
+	p.Publish(context.Background(), &Item{ID: 2, AuthorID: 5})
+	p.Close()
+	// The followers of the celebrity are not even listed.
+	if graph.lists != 1 {
+		t.Errorf("expected: %v, got: %v", 1, graph.lists)
+	}
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(2))); !reflect.DeepEqual(ids, []uint64{2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2}, ids)
+	}
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(1))); !reflect.DeepEqual(ids, []uint64{1}) {
+		t.Errorf("expected the author's own timeline, got: %v", ids)
+	}
+}
+
+func TestPusherOptions(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for archiving without an archiver")
+		}
+	}()
+	NewPusher(NewMemoryStorage(), NewMemoryGraph(), PushOptions{Cap: Cap{MaxLen: 2, Truncation: ArchiveOldest}})
+}
+
+// Run with -race: Wait may run while posts are published.
+func TestPusherConcurrentWait(t *testing.T) {
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(2, 1)
+	p := NewPusher(storage, graph, PushOptions{})
+	defer p.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for id := uint64(1); id <= 100; id++ {
+			p.Publish(context.Background(), &Item{ID: id, AuthorID: 1, Timestamp: int64(id)})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			p.Wait()
+		}
+	}()
+	wg.Wait()
+	p.Wait()
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(2))); len(ids) != 100 {
+		t.Errorf("expected: %v, got: %v", 100, len(ids))
+	}
+}
@@ -0,0 +1,62 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+)
+
+// Ranker scores items for a viewer on the read path; pages are ordered by
+// descending score, ties keeping timeline order. Ranking reorders the items
+// within a page only, so pagination stays positional and pages never
+// overlap.
+// Implementations must be safe for concurrent use.
+type Ranker interface {
+	// Score returns the score of item for the viewer.
+	Score(ctx context.Context, viewerID uint64, item *Item) float64
+	// ScoreBatch returns the scores of items for the viewer, in the same
+	// order. Rankers backed by a remote model score a page in one call.
+	ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64
+}
+
+// Chronological ranks items newest first, i.e. keeps timeline order. It is
+// the default Ranker.
+type Chronological struct{}
+
+func (Chronological) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	return float64(item.Timestamp)
+}
+
+func (c Chronological) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	return ScoreEach(ctx, c, viewerID, items)
+}
+
+// Returns the scores of items by calling ranker.Score on each, for rankers
+// without a cheaper batch path to implement ScoreBatch with.
+func ScoreEach(ctx context.Context, ranker Ranker, viewerID uint64, items []*Item) []float64 {
+	scores := make([]float64, len(items))
+	for i, item := range items {
+		scores[i] = ranker.Score(ctx, viewerID, item)
+	}
+	return scores
+}
+
+// rank orders items by descending score in place.
+func rank(ctx context.Context, ranker Ranker, viewerID uint64, items []*Item) {
+	if len(items) < 2 {
+		return
+	}
+	scores := ranker.ScoreBatch(ctx, viewerID, items)
+	sort.Stable(byScore{items: items, scores: scores})
+}
+
+type byScore struct {
+	items  []*Item
+	scores []float64
+}
+
+func (b byScore) Len() int           { return len(b.items) }
+func (b byScore) Less(i, j int) bool { return b.scores[i] > b.scores[j] }
+func (b byScore) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
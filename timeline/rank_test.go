@@ -0,0 +1,47 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// affinity ranks the items of favourite authors first.
+type affinity map[uint64]float64
+
+func (a affinity) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	return a[item.AuthorID]
+}
+
+func (a affinity) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	return ScoreEach(ctx, a, viewerID, items)
+}
+
+func TestRank(t *testing.T) {
+	ctx := context.Background()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	graph.Follow(1, 11)
+	s := NewService(NewMemoryStorage(), graph, Options{Delivery: PullDelivery})
+	defer s.Close()
+	for id := uint64(1); id <= 6; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10 + id%2, Timestamp: int64(id)})
+	}
+
+	page, _ := s.Read(ctx, 1, ReadOptions{Limit: 4})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{6, 5, 4, 3}) {
+		t.Errorf("expected: %v, got: %v", []uint64{6, 5, 4, 3}, readIDs(page.Items))
+	}
+
+	// Items are ranked within a page; ties keep timeline order, and the
+	// next page continues below the page's oldest item.
+	ranker := affinity{11: 1}
+	page, _ = s.Read(ctx, 1, ReadOptions{Limit: 4, Ranker: ranker})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{5, 3, 6, 4}) {
+		t.Errorf("expected: %v, got: %v", []uint64{5, 3, 6, 4}, readIDs(page.Items))
+	}
+	page, _ = s.Read(ctx, 1, ReadOptions{Limit: 4, Ranker: ranker, Cursor: page.Next})
+	if !reflect.DeepEqual(readIDs(page.Items), []uint64{1, 2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{1, 2}, readIDs(page.Items))
+	}
+}
@@ -0,0 +1,341 @@
+package timeline
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"feed/filter"
+)
+
+// ReadOptions select a page of a home timeline.
+type ReadOptions struct {
+	// Cursor continues from a previous page; empty reads the newest page.
+	Cursor string
+	// Direction reads the items older than Cursor (the default) or newer
+	// than it, e.g. to fill the gap above what a client has after it
+	// reconnects. It is ignored without a Cursor.
+	Direction Direction
+	// At, if set and there is no Cursor, starts the page at the newest item
+	// posted at or before it instead of at the newest item, e.g. to show the
+	// feed from last Tuesday. The page's cursors page on from there both
+	// ways.
+	At time.Time
+	// Limit is the page size. Defaults to 20.
+	Limit int
+	// Ranker, if set, ranks the page instead of the ranker of the kind of
+	// timeline or Options.Ranker, e.g. for a product surface with its own
+	// ranking.
+	Ranker Ranker
+	// Grouping, if its Key is set, collapses similar items of the page.
+	Grouping Grouping
+	// Feeds are further timelines merged into the home timeline, e.g. a
+	// topic timeline or recommendations. Set Options.DedupCapacity to drop
+	// the items found in several of them on different pages.
+	Feeds []string
+	// Types, if set, keeps only the items of these types, and ExcludeTypes
+	// leaves out the items of those, before ranking, so that pages stay
+	// full.
+	Types        []ItemType
+	ExcludeTypes []ItemType
+	// Languages, if set, keeps only the items in these languages, and
+	// ExcludeLanguages leaves out the items in those, before ranking. A
+	// language matches its regional variants, e.g. "en" matches "en-GB".
+	// Items without a language are left out when Languages is set.
+	Languages        []string
+	ExcludeLanguages []string
+	// Seen demotes or leaves out the items the viewer saw already, as
+	// recorded with MarkSeen. Defaults to ShowSeen.
+	Seen SeenPolicy
+	// Injections insert external items, e.g. ads, into the ranked page.
+	// They do not take the place of organic items, so pages can hold more
+	// than Limit items.
+	Injections []Injection
+	// explain, if set, collects the candidates of the read (see Explain).
+	explain *Explanation
+}
+
+// Page is a page of a timeline, newest first.
+type Page struct {
+	// Pinned are the items pinned to the timeline, most recently pinned
+	// first, to show above Items. They are only set on the newest page, and
+	// left out of Items on every page.
+	Pinned []*Item
+	Items  []*Item
+	// Next is the cursor of the following, older page, or empty if there
+	// are no older items. When items are left out, the following page can
+	// turn out empty.
+	Next string
+	// Prev is the cursor for reading the items newer than this page with
+	// Direction Newer, or empty if the page is empty.
+	Prev string
+	// HasNewer is true if the page was read with Direction Newer and newer
+	// items remain beyond it, i.e. the gap is not filled yet.
+	HasNewer bool
+	// Variants are the experiment variants the viewer is assigned to by
+	// experiment name, configured or not (e.g. control), for logging, or nil
+	// if none (see Options.Experiments).
+	Variants map[string]string
+}
+
+// Filter leaves the items Keep returns false for out of pages. Name tells
+// explanations which filter left an item out.
+type Filter struct {
+	Name string
+	Keep func(item *Item) bool
+}
+
+// maxScans bounds the batches a read scans to fill a page whose items are
+// being left out, so that a page stays cheap even if nearly all of them are;
+// the page then comes back short, with a Next cursor to read on from.
+const maxScans = 8
+
+// Returns a page of the viewer's home timeline. Items whose payload is
+// missing from storage, deleted and expired items, and those the viewer's
+// block list hides are left out, and the page is refilled from further
+// entries. The page is read with the experiment variants of the viewer.
+func (s *Service) Read(ctx context.Context, viewerID uint64, options ReadOptions) (*Page, error) {
+	feedIDs, err := s.homeFeeds(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	options, filters, variants := s.experiment(ctx, viewerID, options)
+	options.Ranker = s.ranker(options, Home)
+	page, err := s.read(ctx, viewerID, append(feedIDs, options.Feeds...), options, filters)
+	if err != nil {
+		return nil, err
+	}
+	page.Variants = variants
+	return page, nil
+}
+
+// read returns a page of the merged timelines for the viewer, ranked by
+// options.Ranker, leaving out the items a filter returns false for.
+func (s *Service) read(ctx context.Context, viewerID uint64, feedIDs []string, options ReadOptions, filters []Filter) (*Page, error) {
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	var cursor *Cursor
+	if options.Cursor != "" {
+		decoded, err := s.cursors.Decode(options.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &decoded
+	}
+
+	var from *Position
+	direction := Older
+	if cursor != nil {
+		from, direction = &cursor.Position, options.Direction
+	} else if !options.At.IsZero() {
+		// Anchor just above every item of the timestamp, so that reading
+		// older items starts at its floor.
+		anchor := Cursor{Position: Position{Timestamp: timestampOf(options.At), ItemID: math.MaxUint64}}
+		anchor.Top = anchor.Position
+		cursor = &anchor
+		from = &cursor.Position
+	}
+	seen, err := s.seenSet(cursor)
+	if err != nil {
+		return nil, err
+	}
+	now := timestampOf(s.options.Now())
+	filters = append([]Filter{{Name: "hidden", Keep: func(item *Item) bool {
+		return item.Visible(now)
+	}}}, append(contentFilters(options), filters...)...)
+	// The items the viewer saw (see ReadOptions.Seen), unlike seen, the
+	// identities the pagination returned; looked up per batch scanned.
+	seenItems := make(map[uint64]bool)
+	if options.Seen == ExcludeSeen {
+		filters = append(filters, Filter{Name: "seen", Keep: func(item *Item) bool {
+			return !seenItems[item.ID]
+		}})
+	}
+	blocked, err := s.blockFilter(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked != nil {
+		filters = append(filters, Filter{Name: "blocked", Keep: blocked})
+	}
+	if seen != nil {
+		// Deduplicate last, so that only items actually returned are seen.
+		filters = append(filters, Filter{Name: "duplicate", Keep: func(item *Item) bool {
+			return s.firstSeen(seen, item)
+		}})
+	}
+
+	// Scan batches of one entry more than needed, to tell whether the page
+	// is the last one in its direction, until the page is full. The cursor
+	// continues after the last entry consumed, kept or not.
+	items := []*Item{}
+	positions := []Position{}
+	groups := newGrouper(options.Grouping)
+	var first, last *Position
+	last = from
+	hasMore, full := true, false
+	for scans := 0; scans < maxScans && hasMore && !full; scans++ {
+		entries, err := s.readFeeds(ctx, feedIDs, last, direction, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := s.storage.MultiGet(ctx, entryIDs(entries)...)
+		if err != nil {
+			return nil, err
+		}
+		if options.Seen != ShowSeen {
+			found, err := s.options.Seen.Seen(ctx, viewerID, entryIDs(entries))
+			if err != nil {
+				return nil, err
+			}
+			for itemID := range found {
+				seenItems[itemID] = true
+			}
+		}
+		hasMore = len(entries) > limit
+		for _, entry := range entries {
+			item, found := stored[entry.ItemID]
+			// A full page still takes the items joining its groups.
+			if len(items) == limit && !(found && groups.fits(items, item)) {
+				hasMore, full = true, true
+				break
+			}
+			position := entry.Position()
+			if first == nil {
+				first = &position
+			}
+			last = &position
+			dropped := "missing"
+			if found {
+				dropped = drop(filters, item)
+			}
+			grouped := dropped == "" && groups.join(items, item)
+			if options.explain != nil {
+				options.explain.Candidates = append(options.explain.Candidates, ExplainedItem{Entry: entry, Item: item, Filter: dropped, Grouped: grouped})
+			}
+			if dropped != "" || grouped {
+				continue
+			}
+			items = append(items, item)
+			positions = append(positions, position)
+		}
+	}
+	groups.collapse(items, direction)
+	if direction == Newer {
+		// Newer reads come closest to the cursor, i.e. oldest, first.
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			positions[i], positions[j] = positions[j], positions[i]
+		}
+	}
+
+	// The cursors below follow the positions, i.e. timeline order.
+	if options.explain != nil {
+		options.explain.ranker = options.Ranker
+	}
+	rank(ctx, options.Ranker, viewerID, items)
+	if options.Seen == DemoteSeen {
+		demote(items, seenItems)
+	}
+	page := &Page{Items: items}
+	if len(options.Injections) > 0 {
+		page.Items = s.inject(ctx, viewerID, items, options.Injections, options.explain == nil)
+	}
+	var seenData []byte
+	if seen != nil {
+		seenData, _ = seen.MarshalBinary()
+	}
+	if first == nil {
+		if cursor != nil && (direction == Newer || options.Cursor == "") {
+			// Nothing new yet, or nothing before At: read on from here.
+			page.Prev = s.cursors.Encode(Cursor{Position: cursor.Position, Top: cursor.Top, Seen: seenData})
+			page.Next = page.Prev
+		}
+		return page, nil
+	}
+	top := *first
+	if cursor != nil {
+		top = cursor.Top
+	}
+	// Next continues below the oldest item of the page and Prev above its
+	// newest one; past the last entry consumed on the direction side, so
+	// that left out entries are not scanned again.
+	newest, oldest := *last, *last
+	if direction == Older {
+		newest = *first
+		if from != nil {
+			newest = *from
+		}
+		if len(positions) > 0 {
+			newest = positions[0]
+		}
+	} else {
+		oldest = *from
+		if len(positions) > 0 {
+			oldest = positions[len(positions)-1]
+		}
+	}
+	if hasMore || direction == Newer {
+		page.Next = s.cursors.Encode(Cursor{Position: oldest, Top: top, Seen: seenData})
+	}
+	page.Prev = s.cursors.Encode(Cursor{Position: newest, Top: top, Seen: seenData})
+	page.HasNewer = direction == Newer && hasMore
+	return page, nil
+}
+
+// Returns the timestamp of t in Unix milliseconds, as Item.Timestamp holds.
+func timestampOf(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// drop returns the name of the first filter leaving item out, or "" if
+// every filter keeps it.
+func drop(filters []Filter, item *Item) string {
+	for _, f := range filters {
+		if !f.Keep(item) {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// entryIDs returns the item IDs of entries in the same order.
+func entryIDs(entries []Entry) []uint64 {
+	ids := make([]uint64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ItemID
+	}
+	return ids
+}
+
+// seenSet returns the identities a deduplicating pagination has returned so
+// far, or nil if deduplication is off.
+func (s *Service) seenSet(cursor *Cursor) (*filter.Bloom, error) {
+	if s.options.DedupCapacity <= 0 {
+		return nil, nil
+	}
+	if cursor == nil || len(cursor.Seen) == 0 {
+		return filter.NewBloom(s.options.DedupCapacity, 0.01), nil
+	}
+	seen := &filter.Bloom{}
+	if err := seen.UnmarshalBinary(cursor.Seen); err != nil {
+		return nil, ErrBadCursor
+	}
+	return seen, nil
+}
+
+// firstSeen adds the identity of item to seen, and returns whether it was
+// not there yet.
+func (s *Service) firstSeen(seen *filter.Bloom, item *Item) bool {
+	id := item.ID
+	if s.options.Identity != nil {
+		id = s.options.Identity(item)
+	}
+	if seen.TestID(int64(id)) {
+		return false
+	}
+	seen.AddID(int64(id))
+	return true
+}
@@ -0,0 +1,153 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrBadScores = errors.New("timeline: model returned a wrong number of scores")
+
+// Features are named model inputs, e.g. "viewer.follows_author" or
+// "item.age_hours".
+type Features map[string]float64
+
+// ModelRequest is a batch of candidate items to score for a viewer.
+type ModelRequest struct {
+	ViewerID   uint64
+	Viewer     Features
+	Candidates []Candidate
+}
+
+// Candidate is an item to score and its features.
+type Candidate struct {
+	ItemID   uint64
+	Features Features
+}
+
+// ModelClient calls an external model server. It is transport agnostic:
+// grpcmodel.Client calls servers over gRPC, and other transports plug in
+// with an adapter translating ModelRequest into the server's messages.
+// Implementations must be safe for concurrent use.
+type ModelClient interface {
+	// Score returns the scores of the request's candidates, in the same
+	// order.
+	Score(ctx context.Context, request *ModelRequest) ([]float64, error)
+}
+
+// RemoteOptions configure a RemoteRanker. Zero values select the defaults.
+type RemoteOptions struct {
+	// Budget bounds the time spent scoring a page, after which it falls
+	// back. Defaults to 50ms.
+	Budget time.Duration
+	// BatchSize is the number of candidates sent per call; the calls of a
+	// page run at once. Defaults to 100.
+	BatchSize int
+	// ViewerFeatures, if set, assembles the viewer's features, once per
+	// page.
+	ViewerFeatures func(ctx context.Context, viewerID uint64) Features
+	// ItemFeatures, if set, assembles the features of each candidate.
+	ItemFeatures func(ctx context.Context, viewerID uint64, item *Item) Features
+	// Fallback ranks pages the model could not score in time. Defaults to
+	// Chronological.
+	Fallback Ranker
+	// OnFallback, if set, is called with the reason of every fallback.
+	OnFallback func(err error)
+}
+
+func (o *RemoteOptions) setDefaults() {
+	if o.Budget <= 0 {
+		o.Budget = 50 * time.Millisecond
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Fallback == nil {
+		o.Fallback = Chronological{}
+	}
+}
+
+// RemoteRanker ranks with an external model server. A page is scored by the
+// model or, if any of its calls fails or overruns the budget, entirely by
+// the fallback, as model and fallback scores cannot be compared.
+// Structure is thread safe.
+type RemoteRanker struct {
+	client  ModelClient
+	options RemoteOptions
+}
+
+// Instantiates a new ranker calling client.
+func NewRemoteRanker(client ModelClient, options RemoteOptions) *RemoteRanker {
+	options.setDefaults()
+	return &RemoteRanker{client: client, options: options}
+}
+
+func (r *RemoteRanker) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	return r.ScoreBatch(ctx, viewerID, []*Item{item})[0]
+}
+
+func (r *RemoteRanker) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	scores, err := r.scoreRemote(ctx, viewerID, items)
+	if err != nil {
+		if r.options.OnFallback != nil {
+			r.options.OnFallback(err)
+		}
+		return r.options.Fallback.ScoreBatch(ctx, viewerID, items)
+	}
+	return scores
+}
+
+type remoteResult struct {
+	offset int
+	scores []float64
+	err    error
+}
+
+// scoreRemote scores items in batches called at once, giving up when the
+// budget runs out even if the client ignores ctx.
+func (r *RemoteRanker) scoreRemote(ctx context.Context, viewerID uint64, items []*Item) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.options.Budget)
+	defer cancel()
+	var viewer Features
+	if r.options.ViewerFeatures != nil {
+		viewer = r.options.ViewerFeatures(ctx, viewerID)
+	}
+	calls := 0
+	// Buffered, so that calls returning after the budget do not block.
+	results := make(chan remoteResult, (len(items)+r.options.BatchSize-1)/r.options.BatchSize)
+	for offset := 0; offset < len(items); offset += r.options.BatchSize {
+		end := offset + r.options.BatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		request := &ModelRequest{ViewerID: viewerID, Viewer: viewer, Candidates: make([]Candidate, end-offset)}
+		for i, item := range items[offset:end] {
+			request.Candidates[i].ItemID = item.ID
+			if r.options.ItemFeatures != nil {
+				request.Candidates[i].Features = r.options.ItemFeatures(ctx, viewerID, item)
+			}
+		}
+		calls++
+		go func(offset int, request *ModelRequest) {
+			scores, err := r.client.Score(ctx, request)
+			if err == nil && len(scores) != len(request.Candidates) {
+				err = ErrBadScores
+			}
+			results <- remoteResult{offset: offset, scores: scores, err: err}
+		}(offset, request)
+	}
+
+	scores := make([]float64, len(items))
+	for ; calls > 0; calls-- {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				return nil, result.err
+			}
+			copy(scores[result.offset:], result.scores)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return scores, nil
+}
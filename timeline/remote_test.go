@@ -0,0 +1,74 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeModel scores candidates by their "likes" feature times the viewer's
+// "weight" feature.
+type fakeModel struct {
+	calls int32
+	delay time.Duration
+	err   error
+}
+
+func (m *fakeModel) Score(ctx context.Context, request *ModelRequest) ([]float64, error) {
+	atomic.AddInt32(&m.calls, 1)
+	time.Sleep(m.delay)
+	scores := []float64{}
+	for _, candidate := range request.Candidates {
+		scores = append(scores, candidate.Features["likes"]*request.Viewer["weight"])
+	}
+	return scores, m.err
+}
+
+func TestRemoteRanker(t *testing.T) {
+	ctx := context.Background()
+	items := []*Item{}
+	for id := uint64(1); id <= 5; id++ {
+		items = append(items, &Item{ID: id, Timestamp: int64(id), Likes: int64(id % 3)})
+	}
+	ranked := func(ranker Ranker) []uint64 {
+		copied := append([]*Item{}, items...)
+		rank(ctx, ranker, 1, copied)
+		return readIDs(copied)
+	}
+
+	model := &fakeModel{}
+	var fallbacks []error
+	options := RemoteOptions{
+		BatchSize: 2,
+		ViewerFeatures: func(ctx context.Context, viewerID uint64) Features {
+			return Features{"weight": -1}
+		},
+		ItemFeatures: func(ctx context.Context, viewerID uint64, item *Item) Features {
+			return Features{"likes": float64(item.Likes)}
+		},
+		OnFallback: func(err error) { fallbacks = append(fallbacks, err) },
+	}
+	if actual := ranked(NewRemoteRanker(model, options)); !reflect.DeepEqual(actual, []uint64{3, 1, 4, 2, 5}) {
+		t.Errorf("expected: %v, got: %v", []uint64{3, 1, 4, 2, 5}, actual)
+	}
+	if model.calls != 3 || len(fallbacks) != 0 {
+		t.Errorf("expected %v calls and no fallback, got: %v, %v", 3, model.calls, fallbacks)
+	}
+
+	// Failing or slow models fall back to chronological order.
+	chronological := []uint64{5, 4, 3, 2, 1}
+	failing := errors.New("unavailable")
+	if actual := ranked(NewRemoteRanker(&fakeModel{err: failing}, options)); !reflect.DeepEqual(actual, chronological) {
+		t.Errorf("expected: %v, got: %v", chronological, actual)
+	}
+	options.Budget = 10 * time.Millisecond
+	if actual := ranked(NewRemoteRanker(&fakeModel{delay: time.Second}, options)); !reflect.DeepEqual(actual, chronological) {
+		t.Errorf("expected: %v, got: %v", chronological, actual)
+	}
+	if len(fallbacks) != 2 || fallbacks[0] != failing || fallbacks[1] != context.DeadlineExceeded {
+		t.Errorf("expected: %v, got: %v", []error{failing, context.DeadlineExceeded}, fallbacks)
+	}
+}
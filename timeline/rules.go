@@ -0,0 +1,217 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrBadRules = errors.New("timeline: bad rules")
+
+// Relationship is how the viewer relates to the author of an item.
+type Relationship string
+
+const (
+	// Self is the viewer's own item.
+	Self Relationship = "self"
+	// Followed is an item of an author the viewer follows.
+	Followed Relationship = "followed"
+	// NotFollowed is an item of an author the viewer does not follow, e.g.
+	// a recommendation.
+	NotFollowed Relationship = "not_followed"
+)
+
+// Duration is a time.Duration written as in "6h" or "90m" in rules.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Rule multiplies the scores of the items matching every condition it sets
+// by Factor: above 1 to boost them, below to penalize them. Unset
+// conditions match every item.
+type Rule struct {
+	// Name tells the rule apart in configuration.
+	Name   string  `json:"name"`
+	Factor float64 `json:"factor"`
+	// MinAge and MaxAge bound the age of the items, e.g. a MaxAge of "1h"
+	// to boost fresh items.
+	MinAge Duration `json:"min_age,omitempty"`
+	MaxAge Duration `json:"max_age,omitempty"`
+	// Types are the item types matched, e.g. "video".
+	Types []ItemType `json:"types,omitempty"`
+	// Languages are the languages matched, with their regional variants.
+	Languages []string `json:"languages,omitempty"`
+	// Tags match the items with one of them.
+	Tags []string `json:"tags,omitempty"`
+	// Relationships are the relationships of the viewer to the author
+	// matched.
+	Relationships []Relationship `json:"relationships,omitempty"`
+}
+
+// Returns the rules of the JSON array data, e.g. read from a configuration
+// file, or ErrBadRules if they do not parse, or a rule has a negative
+// factor, a max age below its min age or an unknown relationship.
+func ParseRules(data []byte) ([]Rule, error) {
+	rules := []Rule{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRules, err)
+	}
+	for _, rule := range rules {
+		if rule.Factor < 0 {
+			return nil, fmt.Errorf("%w: rule %q has a negative factor", ErrBadRules, rule.Name)
+		}
+		if rule.MaxAge != 0 && rule.MaxAge < rule.MinAge {
+			return nil, fmt.Errorf("%w: rule %q has a max age below its min age", ErrBadRules, rule.Name)
+		}
+		for _, relationship := range rule.Relationships {
+			if relationship != Self && relationship != Followed && relationship != NotFollowed {
+				return nil, fmt.Errorf("%w: rule %q has an unknown relationship %q", ErrBadRules, rule.Name, relationship)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// matches returns true if item matches every condition of the rule, aged age
+// and related to the viewer by relationship.
+func (r *Rule) matches(item *Item, age time.Duration, relationship Relationship) bool {
+	if age < time.Duration(r.MinAge) || r.MaxAge != 0 && age > time.Duration(r.MaxAge) {
+		return false
+	}
+	if len(r.Types) > 0 && !hasType(r.Types, item.Type) {
+		return false
+	}
+	if len(r.Languages) > 0 && (item.Language == "" || !hasLanguage(r.Languages, item.Language)) {
+		return false
+	}
+	if len(r.Tags) > 0 && !hasTag(r.Tags, item.Tags) {
+		return false
+	}
+	if len(r.Relationships) > 0 {
+		for _, other := range r.Relationships {
+			if other == relationship {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func hasTag(tags, itemTags []string) bool {
+	for _, tag := range tags {
+		for _, itemTag := range itemTags {
+			if tag == itemTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RuleRanker applies rules to the scores of another ranker, so that boosts
+// and penalties can be tuned from configuration. Every rule an item matches
+// applies, so factors multiply. Rules can be replaced while ranking, e.g.
+// when the configuration is reloaded.
+// Structure is thread safe.
+type RuleRanker struct {
+	ranker Ranker
+	graph  Graph
+	mu     sync.RWMutex
+	rules  []Rule
+	now    func() time.Time
+}
+
+// Instantiates a new ranker applying rules to the scores of ranker. graph
+// tells the relationship of viewers to authors; it is only read, once per
+// batch, if a rule matches relationships, and may be nil otherwise.
+func NewRuleRanker(ranker Ranker, graph Graph, rules []Rule) *RuleRanker {
+	return &RuleRanker{ranker: ranker, graph: graph, rules: rules, now: time.Now}
+}
+
+// Replaces the rules.
+func (rr *RuleRanker) SetRules(rules []Rule) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.rules = rules
+}
+
+// Returns the rules.
+func (rr *RuleRanker) Rules() []Rule {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+	return rr.rules
+}
+
+func (rr *RuleRanker) Score(ctx context.Context, viewerID uint64, item *Item) float64 {
+	return rr.ScoreBatch(ctx, viewerID, []*Item{item})[0]
+}
+
+func (rr *RuleRanker) ScoreBatch(ctx context.Context, viewerID uint64, items []*Item) []float64 {
+	scores := rr.ranker.ScoreBatch(ctx, viewerID, items)
+	rules := rr.Rules()
+	if len(rules) == 0 {
+		return scores
+	}
+	followed, err := rr.followees(ctx, viewerID, rules)
+	if err != nil {
+		// Without the graph, leave the scores alone rather than misapply
+		// relationship rules.
+		return scores
+	}
+	now := timestampOf(rr.now())
+	for i, item := range items {
+		age := time.Duration(now-item.Timestamp) * time.Millisecond
+		relationship := NotFollowed
+		if item.AuthorID == viewerID {
+			relationship = Self
+		} else if followed[item.AuthorID] {
+			relationship = Followed
+		}
+		for r := range rules {
+			if rules[r].matches(item, age, relationship) {
+				scores[i] *= rules[r].Factor
+			}
+		}
+	}
+	return scores
+}
+
+// followees returns the followees of the viewer if a rule matches
+// relationships, and nil otherwise.
+func (rr *RuleRanker) followees(ctx context.Context, viewerID uint64, rules []Rule) (map[uint64]bool, error) {
+	needed := false
+	for _, rule := range rules {
+		needed = needed || len(rule.Relationships) > 0
+	}
+	if !needed || rr.graph == nil {
+		return nil, nil
+	}
+	followees, err := rr.graph.Followees(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	followed := make(map[uint64]bool, len(followees))
+	for _, followee := range followees {
+		followed[followee] = true
+	}
+	return followed, nil
+}
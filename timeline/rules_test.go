@@ -0,0 +1,62 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`[
+		{"name": "fresh", "factor": 2, "max_age": "1h"},
+		{"name": "strangers", "factor": 0.5, "relationships": ["not_followed"], "types": ["video"]}
+	]`))
+	expected := []Rule{
+		{Name: "fresh", Factor: 2, MaxAge: Duration(time.Hour)},
+		{Name: "strangers", Factor: 0.5, Relationships: []Relationship{NotFollowed}, Types: []ItemType{Video}},
+	}
+	if err != nil || !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected: %v, got: %v (%v)", expected, rules, err)
+	}
+	for _, data := range []string{
+		`{}`,
+		`[{"factor": -1}]`,
+		`[{"factor": 1, "max_age": "soon"}]`,
+		`[{"factor": 1, "min_age": "2h", "max_age": "1h"}]`,
+		`[{"factor": 1, "relationships": ["friend"]}]`,
+	} {
+		if _, err := ParseRules([]byte(data)); !errors.Is(err, ErrBadRules) {
+			t.Errorf("%s, expected: %v, got: %v", data, ErrBadRules, err)
+		}
+	}
+}
+
+func TestRuleRanker(t *testing.T) {
+	ctx := context.Background()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	hour := int64(time.Hour / time.Millisecond)
+	items := []*Item{
+		{ID: 1, AuthorID: 10, Timestamp: 10 * hour},
+		{ID: 2, AuthorID: 10, Timestamp: 2 * hour, Type: Video},
+		{ID: 3, AuthorID: 20, Timestamp: 10 * hour, Type: Video, Tags: []string{"go"}},
+		{ID: 4, AuthorID: 1, Timestamp: 2 * hour, Language: "en-US"},
+	}
+	rr := NewRuleRanker(affinity{1: 1, 10: 1, 20: 1}, graph, []Rule{
+		{Name: "fresh", Factor: 2, MaxAge: Duration(time.Hour)},
+		{Name: "videos", Factor: 3, Types: []ItemType{Video}},
+		{Name: "strangers", Factor: 0.5, Relationships: []Relationship{NotFollowed}},
+		{Name: "go", Factor: 10, Tags: []string{"go"}, MinAge: Duration(time.Minute)},
+		{Name: "mine", Factor: 0, Relationships: []Relationship{Self}, Languages: []string{"en"}},
+	})
+	rr.now = func() time.Time { return time.Unix(0, 0).Add(10*time.Hour + time.Minute) }
+	if scores := rr.ScoreBatch(ctx, 1, items); !reflect.DeepEqual(scores, []float64{2, 3, 30, 0}) {
+		t.Errorf("expected: %v, got: %v", []float64{2, 3, 30, 0}, scores)
+	}
+	rr.SetRules(nil)
+	if score := rr.Score(ctx, 1, items[2]); score != 1 {
+		t.Errorf("expected: %v, got: %v", 1, score)
+	}
+}
@@ -0,0 +1,153 @@
+package timeline
+
+import (
+	"context"
+	"sync"
+
+	"feed/filter"
+	"feed/roaring"
+)
+
+// SeenPolicy selects what reads do with the items the viewer saw already.
+type SeenPolicy int
+
+const (
+	// ShowSeen ranks seen items like the others.
+	ShowSeen SeenPolicy = iota
+	// DemoteSeen moves the seen items of a page below the unseen ones,
+	// keeping the ranking order within each.
+	DemoteSeen
+	// ExcludeSeen leaves seen items out of pages.
+	ExcludeSeen
+)
+
+// SeenStore keeps the items viewers saw, as clients report impressions.
+// Implementations must be safe for concurrent use.
+type SeenStore interface {
+	// MarkSeen records that the viewer saw the items.
+	MarkSeen(ctx context.Context, viewerID uint64, itemIDs ...uint64) error
+	// Seen returns the items of itemIDs the viewer saw. Items not seen are
+	// left out.
+	Seen(ctx context.Context, viewerID uint64, itemIDs []uint64) (map[uint64]bool, error)
+}
+
+// MemorySeen is an exact SeenStore kept in process memory, as a roaring
+// bitmap per viewer: dense item IDs cost about a bit each, sparse ones two
+// bytes. Nothing is forgotten, see BloomSeen for a bounded store.
+// Structure is thread safe.
+type MemorySeen struct {
+	mu   sync.RWMutex
+	seen map[uint64]*roaring.Bitmap
+}
+
+// Instantiates a new empty memory seen store.
+func NewMemorySeen() *MemorySeen {
+	return &MemorySeen{seen: make(map[uint64]*roaring.Bitmap)}
+}
+
+func (ms *MemorySeen) MarkSeen(ctx context.Context, viewerID uint64, itemIDs ...uint64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	seen := ms.seen[viewerID]
+	if seen == nil {
+		seen = roaring.New()
+		ms.seen[viewerID] = seen
+	}
+	for _, itemID := range itemIDs {
+		seen.AddID(int64(itemID))
+	}
+	return nil
+}
+
+func (ms *MemorySeen) Seen(ctx context.Context, viewerID uint64, itemIDs []uint64) (map[uint64]bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	found := make(map[uint64]bool)
+	if seen := ms.seen[viewerID]; seen != nil {
+		for _, itemID := range itemIDs {
+			if seen.ContainsID(int64(itemID)) {
+				found[itemID] = true
+			}
+		}
+	}
+	return found, nil
+}
+
+// BloomSeen is an approximate SeenStore kept in process memory, bounded per
+// viewer: it keeps the last capacity to 2*capacity items seen in two Bloom
+// filters at a 1% false positive rate, i.e. about 2.4 bytes per item of
+// capacity, and forgets older ones. An item is taken for seen by mistake
+// about 2% of the time.
+// Structure is thread safe.
+type BloomSeen struct {
+	mu       sync.Mutex
+	capacity int
+	seen     map[uint64]*[2]*filter.Bloom // the current filter, then the previous one
+}
+
+// Instantiates a new empty Bloom seen store remembering at least capacity
+// items per viewer.
+func NewBloomSeen(capacity int) *BloomSeen {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &BloomSeen{capacity: capacity, seen: make(map[uint64]*[2]*filter.Bloom)}
+}
+
+func (bs *BloomSeen) MarkSeen(ctx context.Context, viewerID uint64, itemIDs ...uint64) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	seen := bs.seen[viewerID]
+	if seen == nil {
+		seen = &[2]*filter.Bloom{filter.NewBloom(bs.capacity, 0.01)}
+		bs.seen[viewerID] = seen
+	}
+	for _, itemID := range itemIDs {
+		// Skip repeats, so that they do not count towards the capacity.
+		if seen[0].TestID(int64(itemID)) {
+			continue
+		}
+		if seen[0].Len() >= bs.capacity {
+			// Rotate, forgetting the items of the previous filter.
+			seen[0], seen[1] = filter.NewBloom(bs.capacity, 0.01), seen[0]
+		}
+		seen[0].AddID(int64(itemID))
+	}
+	return nil
+}
+
+func (bs *BloomSeen) Seen(ctx context.Context, viewerID uint64, itemIDs []uint64) (map[uint64]bool, error) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	found := make(map[uint64]bool)
+	if seen := bs.seen[viewerID]; seen != nil {
+		for _, itemID := range itemIDs {
+			if seen[0].TestID(int64(itemID)) || seen[1] != nil && seen[1].TestID(int64(itemID)) {
+				found[itemID] = true
+			}
+		}
+	}
+	return found, nil
+}
+
+// Records that the viewer saw the items, as their client reports, for
+// ReadOptions.Seen.
+func (s *Service) MarkSeen(ctx context.Context, viewerID uint64, itemIDs ...uint64) error {
+	return s.options.Seen.MarkSeen(ctx, viewerID, itemIDs...)
+}
+
+// demote moves the seen items below the others, keeping their order.
+func demote(items []*Item, seen map[uint64]bool) {
+	sorted := make([]*Item, 0, len(items))
+	for _, item := range items {
+		if !seen[item.ID] {
+			sorted = append(sorted, item)
+		}
+	}
+	for _, item := range items {
+		if seen[item.ID] {
+			sorted = append(sorted, item)
+		}
+	}
+	copy(items, sorted)
+}
@@ -0,0 +1,62 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSeenStores(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]SeenStore{"memory": NewMemorySeen(), "bloom": NewBloomSeen(100)}
+	for name, store := range stores {
+		store.MarkSeen(ctx, 1, 1, 2, 1<<40)
+		store.MarkSeen(ctx, 2, 3)
+		seen, err := store.Seen(ctx, 1, []uint64{1, 3, 1 << 40})
+		if expected := map[uint64]bool{1: true, 1 << 40: true}; err != nil || !reflect.DeepEqual(seen, expected) {
+			t.Errorf("%s, expected: %v, got: %v (%v)", name, expected, seen, err)
+		}
+	}
+
+	// A bounded store forgets the oldest items past twice its capacity.
+	store := NewBloomSeen(2)
+	for id := uint64(1); id <= 5; id++ {
+		store.MarkSeen(ctx, 1, id, id)
+	}
+	seen, _ := store.Seen(ctx, 1, []uint64{1, 2, 3, 4, 5})
+	if expected := map[uint64]bool{3: true, 4: true, 5: true}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("expected: %v, got: %v", expected, seen)
+	}
+}
+
+func TestReadSeen(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(PushDelivery)
+	defer s.Close()
+	for id := uint64(1); id <= 5; id++ {
+		s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: int64(id)})
+	}
+	s.Wait()
+	s.MarkSeen(ctx, 1, 5, 3)
+	s.MarkSeen(ctx, 2, 4)
+
+	tests := []struct {
+		options  ReadOptions
+		expected []uint64
+	}{
+		{ReadOptions{}, []uint64{5, 4, 3, 2, 1}},
+		{ReadOptions{Seen: DemoteSeen}, []uint64{4, 2, 1, 5, 3}},
+		{ReadOptions{Seen: ExcludeSeen}, []uint64{4, 2, 1}},
+		// Excluded items are refilled from further entries.
+		{ReadOptions{Seen: ExcludeSeen, Limit: 2}, []uint64{4, 2}},
+	}
+	for _, test := range tests {
+		page, err := s.Read(ctx, 1, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := readIDs(page.Items); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%+v, expected: %v, got: %v", test.options, test.expected, ids)
+		}
+	}
+}
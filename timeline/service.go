@@ -0,0 +1,342 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var ErrStale = errors.New("timeline: item version is not newer than the stored one")
+
+// Delivery selects how posts reach home timelines.
+type Delivery int
+
+const (
+	// PushDelivery fans every post out on write, so reads are a single
+	// timeline lookup.
+	PushDelivery Delivery = iota
+	// PullDelivery merges the followees' user timelines on every read, so
+	// posting costs nothing per follower.
+	PullDelivery
+	// HybridDelivery pushes posts of authors with at most
+	// Options.CelebrityThreshold followers, and pulls the posts of authors
+	// above it at read time, merging both. It avoids fan-out storms for
+	// authors with huge audiences while keeping most reads cheap.
+	HybridDelivery
+)
+
+// Options configure a Service.
+type Options struct {
+	Delivery Delivery
+	// CelebrityThreshold is the follower count above which HybridDelivery
+	// pulls an author's posts instead of pushing them. Defaults to 10000.
+	// An author crossing it upwards keeps their earlier, pushed posts in home
+	// timelines; crossing it downwards, their earlier posts are no longer
+	// pulled, so only posts made afterwards show up.
+	CelebrityThreshold int
+	// PullConcurrency bounds the timelines read at once when pulling, and
+	// the follower counts looked up at once by hybrid reads.
+	PullConcurrency int
+	Push            PushOptions
+	// CursorKey signs the pagination cursors handed to clients. Servers
+	// sharing readers must share the key.
+	CursorKey []byte
+	// DedupCapacity, if positive, drops items from a page whose identity
+	// was already returned on a page of the same pagination, e.g. an item
+	// found both in the home timeline and in a topic timeline merged into it
+	// with ReadOptions.Feeds. The identities seen are carried in the cursor
+	// as a Bloom filter sized for DedupCapacity items at a 1% false positive
+	// rate, which grows cursors by about 1.6 characters per item of
+	// capacity; past it, items are increasingly dropped by mistake.
+	DedupCapacity int
+	// Identity returns the identity deduplication compares, e.g. the ID of
+	// the original post for reposts. Defaults to the item ID.
+	Identity func(item *Item) uint64
+	// MaxPinned caps the items pinned to a timeline. Defaults to 3.
+	MaxPinned int
+	// BlockLists, if set, hides the items of muted or blocked authors and
+	// with muted keywords from readers. Reads fail if it does, rather than
+	// show blocked content. Wrap it with NewCachedBlockLists.
+	BlockLists BlockListProvider
+	// Kinds configure the timelines by kind: routing of posts, retention
+	// and ranking. Pass Kinds.TTL and Kinds.MaxLen to the Compactor.
+	Kinds Kinds
+	// Markers keep the read markers of users. Defaults to memory ones.
+	Markers Markers
+	// Impressions keep the impressions of injected items, for frequency
+	// caps. Defaults to memory ones.
+	Impressions Impressions
+	// Seen keeps the items users saw, for ReadOptions.Seen. Defaults to a
+	// MemorySeen.
+	Seen SeenStore
+	// Ranker orders the items of pages. Defaults to Chronological.
+	Ranker Ranker
+	// Experiments, if their Assignments are set, configure reads by the
+	// experiment variants of the viewer.
+	Experiments Experiments
+	// Invalidate, if set, is called with the ID of every item updated or
+	// retracted once the change is stored, to drop it from caches in front
+	// of the service.
+	Invalidate func(itemID uint64)
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Service is the entry point of the feed core: it publishes posts and reads
+// home timelines according to the configured delivery.
+// Structure is thread safe.
+type Service struct {
+	storage Storage
+	graph   Graph
+	options Options
+	pusher  *Pusher
+	puller  *Puller
+	cursors *CursorCodec
+	pinMu   sync.Mutex
+}
+
+// Instantiates a new service and starts its background workers.
+func NewService(storage Storage, graph Graph, options Options) *Service {
+	if options.CelebrityThreshold <= 0 {
+		options.CelebrityThreshold = 10000
+	}
+	if options.MaxPinned <= 0 {
+		options.MaxPinned = 3
+	}
+	if options.Ranker == nil {
+		options.Ranker = Chronological{}
+	}
+	if options.Markers == nil {
+		options.Markers = NewMemoryMarkers()
+	}
+	if options.Impressions == nil {
+		options.Impressions = NewMemoryImpressions()
+	}
+	if options.Seen == nil {
+		options.Seen = NewMemorySeen()
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.Push.Now == nil {
+		options.Push.Now = options.Now
+	}
+	if options.Delivery == HybridDelivery {
+		options.Push.MaxFollowers = options.CelebrityThreshold
+	}
+	s := &Service{storage: storage, graph: graph, options: options, cursors: NewCursorCodec(options.CursorKey)}
+	s.puller = NewPuller(storage, graph, options.PullConcurrency)
+	if options.Delivery != PullDelivery {
+		s.pusher = NewPusher(storage, graph, options.Push)
+	}
+	return s
+}
+
+// Stores item and adds it to its author's user timeline and to the
+// timelines the kinds route it to, then delivers it to home timelines as
+// the delivery mode requires, asynchronously.
+func (s *Service) Publish(ctx context.Context, item *Item) error {
+	if s.pusher != nil {
+		if err := s.pusher.Publish(ctx, item); err != nil {
+			return err
+		}
+	} else {
+		if err := s.storage.PutItems(ctx, item); err != nil {
+			return err
+		}
+		if err := s.storage.AppendItems(ctx, UserFeed(item.AuthorID), EntryOf(item)); err != nil {
+			return err
+		}
+	}
+	return s.route(ctx, item)
+}
+
+// Returns up to limit entries of the viewer's home timeline on the direction
+// side of from, closest to it first, as Storage.RangeByCursor does.
+func (s *Service) ReadHome(ctx context.Context, viewerID uint64, from *Position, direction Direction, limit int) ([]Entry, error) {
+	feedIDs, err := s.homeFeeds(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readFeeds(ctx, feedIDs, from, direction, limit)
+}
+
+// readFeeds reads a single timeline directly, and merges several.
+func (s *Service) readFeeds(ctx context.Context, feedIDs []string, from *Position, direction Direction, limit int) ([]Entry, error) {
+	if len(feedIDs) == 1 {
+		return s.storage.RangeByCursor(ctx, feedIDs[0], from, direction, limit)
+	}
+	return s.puller.readMerged(ctx, feedIDs, from, direction, limit)
+}
+
+// homeFeeds returns the timelines making up the viewer's home timeline:
+// the materialized one when pushing, the user timelines of the viewer and
+// their followees when pulling, and for hybrid delivery the materialized
+// one plus the user timelines of the followees whose posts are not pushed.
+func (s *Service) homeFeeds(ctx context.Context, viewerID uint64) ([]string, error) {
+	if s.options.Delivery == PushDelivery {
+		return []string{HomeFeed(viewerID)}, nil
+	}
+	followees, err := s.graph.Followees(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if s.options.Delivery == PullDelivery {
+		feedIDs := []string{UserFeed(viewerID)}
+		for _, followee := range followees {
+			feedIDs = append(feedIDs, UserFeed(followee))
+		}
+		return feedIDs, nil
+	}
+	celebrities, err := s.puller.celebrities(ctx, followees, s.options.CelebrityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	feedIDs := []string{HomeFeed(viewerID)}
+	for _, celebrity := range celebrities {
+		feedIDs = append(feedIDs, UserFeed(celebrity))
+	}
+	return feedIDs, nil
+}
+
+// Deletes an item on behalf of its author: stores its tombstone, which drops
+// the payload, so that readers stop showing it right away, removes it from
+// its routed timelines, then from the timelines it was delivered to,
+// asynchronously.
+// Fails with ErrNotFound if the item is not stored.
+func (s *Service) Retract(ctx context.Context, itemID uint64) error {
+	stored, err := s.storage.MultiGet(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	item, found := stored[itemID]
+	if !found {
+		return ErrNotFound
+	}
+	// Route before the payload goes, as routes may depend on it.
+	routed := s.options.Kinds.routes(item)
+	if item.DeletedAt == 0 {
+		item.DeletedAt = timestampOf(s.options.Now())
+	}
+	item.Payload = nil
+	if err := s.storage.PutItems(ctx, item); err != nil {
+		return err
+	}
+	s.invalidate(item.ID)
+	for _, feedID := range routed {
+		if err := s.storage.Delete(ctx, feedID, item.ID); err != nil {
+			return err
+		}
+	}
+	if s.pusher != nil {
+		return s.pusher.Retract(ctx, item)
+	}
+	return s.storage.Delete(ctx, UserFeed(item.AuthorID), item.ID)
+}
+
+// Stores an edit of an item, e.g. of its caption: the last write wins, so the
+// edit is applied only if its Version is greater than the stored one's, and
+// fails with ErrStale otherwise. The ID, author and timestamp of the item do
+// not change. Timelines reference items, so every timeline shows the edit
+// on its next read. If the expiry changed, the entries of the item are
+// rewritten too, as the compactor expires entries by theirs: in the
+// author's user timeline and routed timelines right away, and in the home
+// timelines it was delivered to asynchronously.
+// Fails with ErrNotFound if the item is not stored or was deleted.
+func (s *Service) Update(ctx context.Context, item *Item) error {
+	stored, err := s.storage.MultiGet(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+	old, found := stored[item.ID]
+	if !found || old.DeletedAt != 0 {
+		return ErrNotFound
+	}
+	update := *item
+	update.AuthorID, update.Timestamp, update.Children = old.AuthorID, old.Timestamp, nil
+	applied, err := s.storage.UpdateItem(ctx, &update)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrStale
+	}
+	s.invalidate(item.ID)
+	if update.ExpireAt == old.ExpireAt {
+		return nil
+	}
+	// The routes of the stored item, as those are the timelines holding it.
+	entry := EntryOf(&update)
+	for _, feedID := range append(s.options.Kinds.routes(old), UserFeed(update.AuthorID)) {
+		if err := reexpire(ctx, s.storage, feedID, entry); err != nil {
+			return err
+		}
+	}
+	if s.pusher != nil {
+		return s.pusher.Update(ctx, &update)
+	}
+	return nil
+}
+
+// reexpire sets the expiry of the entry of an item in a timeline to that of
+// entry, if the timeline holds it, keeping its other fields, e.g. its Score.
+// Timestamps do not change, so the entry is looked up at its position. An
+// entry deleted in between is added back, pointing to a tombstone, which
+// readers leave out.
+func reexpire(ctx context.Context, storage Storage, feedID string, entry Entry) error {
+	from := Position{Timestamp: entry.Timestamp, ItemID: entry.ItemID + 1}
+	if entry.ItemID == math.MaxUint64 {
+		from = Position{Timestamp: entry.Timestamp + 1}
+	}
+	found, err := storage.RangeByCursor(ctx, feedID, &from, Older, 1)
+	if err != nil || len(found) == 0 || found[0].ItemID != entry.ItemID {
+		return err
+	}
+	held := found[0]
+	held.ExpireAt = entry.ExpireAt
+	return storage.AppendItems(ctx, feedID, held)
+}
+
+func (s *Service) invalidate(itemID uint64) {
+	if s.options.Invalidate != nil {
+		s.options.Invalidate(itemID)
+	}
+}
+
+// Makes the recent posts of followeeID show in the home timeline of
+// followerID, asynchronously. Call it once followerID follows followeeID
+// in the graph. Only pushed posts are backfilled, as pulled ones show up by
+// themselves.
+func (s *Service) Backfill(ctx context.Context, followerID, followeeID uint64) error {
+	if s.pusher == nil {
+		return nil
+	}
+	return s.pusher.Backfill(ctx, followerID, followeeID)
+}
+
+// Removes the posts of authorID from the home timeline of userID,
+// asynchronously. Call it once userID unfollowed or blocked authorID, so
+// that their posts do not linger until they age out.
+func (s *Service) Purge(ctx context.Context, userID, authorID uint64) error {
+	if s.pusher == nil {
+		return nil
+	}
+	return s.pusher.Purge(ctx, userID, authorID)
+}
+
+// Blocks until every task queued so far is done: posts published delivered,
+// posts retracted removed, updates, backfills and purges done.
+func (s *Service) Wait() {
+	if s.pusher != nil {
+		s.pusher.Wait()
+	}
+}
+
+// Delivers the queued posts and stops the background workers.
+func (s *Service) Close() {
+	if s.pusher != nil {
+		s.pusher.Close()
+	}
+}
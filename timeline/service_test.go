@@ -0,0 +1,236 @@
+package timeline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestService returns a service over memory storage where users 1 and 2
+// follow the regular author 10, and users 1 to 5 follow the celebrity 20.
+func newTestService(delivery Delivery) (*Service, *MemoryStorage) {
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	graph.Follow(2, 10)
+	for user := uint64(1); user <= 5; user++ {
+		graph.Follow(user, 20)
+	}
+	return NewService(storage, graph, Options{Delivery: delivery, CelebrityThreshold: 3}), storage
+}
+
+func TestServiceDeliveryModes(t *testing.T) {
+	ctx := context.Background()
+	for _, delivery := range []Delivery{PushDelivery, PullDelivery, HybridDelivery} {
+		s, storage := newTestService(delivery)
+		s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1})
+		s.Publish(ctx, &Item{ID: 2, AuthorID: 20, Timestamp: 2})
+		s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3})
+		s.Wait()
+
+		entries, err := s.ReadHome(ctx, 1, nil, Older, 10)
+		if err != nil || !reflect.DeepEqual(itemIDs(entries), []uint64{3, 2, 1}) {
+			t.Errorf("delivery %d, expected: %v, got: %v (%v)", delivery, []uint64{3, 2, 1}, itemIDs(entries), err)
+		}
+		from := entries[0].Position()
+		if entries, _ := s.ReadHome(ctx, 5, &from, Older, 10); !reflect.DeepEqual(itemIDs(entries), []uint64{2}) {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, []uint64{2}, itemIDs(entries))
+		}
+
+		// Only pushed posts are materialized in home timelines.
+		materialized := itemIDs(mustRange(t, storage, HomeFeed(1)))
+		expected := map[Delivery][]uint64{PushDelivery: {3, 2, 1}, PullDelivery: {}, HybridDelivery: {3, 1}}[delivery]
+		if !reflect.DeepEqual(materialized, expected) {
+			t.Errorf("delivery %d, expected materialized: %v, got: %v", delivery, expected, materialized)
+		}
+		s.Close()
+	}
+}
+
+func mustRange(t *testing.T, s Storage, feedID string) []Entry {
+	entries, err := s.RangeByCursor(context.Background(), feedID, nil, Older, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestRetract(t *testing.T) {
+	ctx := context.Background()
+	for _, delivery := range []Delivery{PushDelivery, PullDelivery, HybridDelivery} {
+		s, storage := newTestService(delivery)
+		s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1, Payload: []byte("oops")})
+		s.Publish(ctx, &Item{ID: 2, AuthorID: 10, Timestamp: 2})
+		s.Wait()
+
+		if err := s.Retract(ctx, 1); err != nil {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, nil, err)
+		}
+		if err := s.Retract(ctx, 99); err != ErrNotFound {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, ErrNotFound, err)
+		}
+		page, _ := s.Read(ctx, 2, ReadOptions{})
+		if !reflect.DeepEqual(readIDs(page.Items), []uint64{2}) {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, []uint64{2}, readIDs(page.Items))
+		}
+		stored, _ := storage.MultiGet(ctx, 1)
+		if tombstone := stored[1]; tombstone == nil || tombstone.DeletedAt == 0 || tombstone.Payload != nil {
+			t.Errorf("delivery %d, expected a tombstone, got: %v", delivery, tombstone)
+		}
+
+		s.Wait()
+		for _, feedID := range []string{UserFeed(10), HomeFeed(1), HomeFeed(2)} {
+			if ids := itemIDs(mustRange(t, storage, feedID)); len(ids) > 1 {
+				t.Errorf("delivery %d, %s, expected: %v, got: %v", delivery, feedID, []uint64{2}, ids)
+			}
+		}
+		s.Close()
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1473000000, 0)
+	day := int64(24 * time.Hour / time.Millisecond)
+	for _, delivery := range []Delivery{PushDelivery, PullDelivery, HybridDelivery} {
+		storage := NewMemoryStorage()
+		graph := NewMemoryGraph()
+		for user := uint64(1); user <= 3; user++ {
+			graph.Follow(user, 20)
+		}
+		options := Options{Delivery: delivery, CelebrityThreshold: 2, Now: func() time.Time { return now }}
+		options.Push.BackfillItems = 3
+		s := NewService(storage, graph, options)
+		// Item 1 is too old, and of items 2..5 only the newest 3 are copied.
+		s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: timestampOf(now) - 8*day})
+		for id := uint64(2); id <= 5; id++ {
+			s.Publish(ctx, &Item{ID: id, AuthorID: 10, Timestamp: timestampOf(now) - 6*day + int64(id)})
+		}
+		s.Publish(ctx, &Item{ID: 6, AuthorID: 20, Timestamp: timestampOf(now)})
+		s.Wait()
+
+		graph.Follow(1, 10)
+		graph.Follow(4, 20)
+		s.Backfill(ctx, 1, 10)
+		s.Backfill(ctx, 4, 20)
+		s.Wait()
+		expected := []uint64{6, 5, 4, 3}
+		if delivery == PullDelivery {
+			// Pulling reads every post of the followees anyway.
+			expected = []uint64{6, 5, 4, 3, 2, 1}
+		}
+		page, _ := s.Read(ctx, 1, ReadOptions{})
+		if !reflect.DeepEqual(readIDs(page.Items), expected) {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, expected, readIDs(page.Items))
+		}
+		// Celebrity posts are pulled rather than backfilled.
+		if ids := itemIDs(mustRange(t, storage, HomeFeed(4))); delivery == HybridDelivery && len(ids) != 0 {
+			t.Errorf("delivery %d, expected no backfill, got: %v", delivery, ids)
+		}
+		if page, _ := s.Read(ctx, 4, ReadOptions{}); !reflect.DeepEqual(readIDs(page.Items), []uint64{6}) {
+			t.Errorf("delivery %d, expected: %v, got: %v", delivery, []uint64{6}, readIDs(page.Items))
+		}
+		s.Close()
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestService(PushDelivery)
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1})
+	s.Publish(ctx, &Item{ID: 2, AuthorID: 20, Timestamp: 2})
+	s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3})
+	s.Wait()
+
+	if err := s.Purge(ctx, 1, 10); err != nil {
+		t.Errorf("expected: %v, got: %v", nil, err)
+	}
+	s.Wait()
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(1))); !reflect.DeepEqual(ids, []uint64{2}) {
+		t.Errorf("expected: %v, got: %v", []uint64{2}, ids)
+	}
+	if ids := itemIDs(mustRange(t, storage, HomeFeed(2))); !reflect.DeepEqual(ids, []uint64{3, 2, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{3, 2, 1}, ids)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	var invalidated []uint64
+	s := NewService(storage, graph, Options{Invalidate: func(itemID uint64) {
+		invalidated = append(invalidated, itemID)
+	}})
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1, Version: 1, Payload: []byte("old caption")})
+	s.Wait()
+
+	// The author and timestamp stay.
+	if err := s.Update(ctx, &Item{ID: 1, AuthorID: 99, Version: 3, ExpireAt: 1 << 50, Payload: []byte("new caption")}); err != nil {
+		t.Errorf("expected: %v, got: %v", nil, err)
+	}
+	for _, version := range []uint64{2, 3} {
+		if err := s.Update(ctx, &Item{ID: 1, Version: version, Payload: []byte("stale")}); err != ErrStale {
+			t.Errorf("version %d, expected: %v, got: %v", version, ErrStale, err)
+		}
+	}
+	page, _ := s.Read(ctx, 1, ReadOptions{})
+	if item := page.Items[0]; string(item.Payload) != "new caption" || item.AuthorID != 10 || item.Timestamp != 1 {
+		t.Errorf("expected the new caption, got: %+v", item)
+	}
+	if entries := mustRange(t, storage, UserFeed(10)); entries[0].ExpireAt != 1<<50 {
+		t.Errorf("expected: %v, got: %v", int64(1<<50), entries[0].ExpireAt)
+	}
+
+	s.Wait()
+	if entries := mustRange(t, storage, HomeFeed(1)); entries[0].ExpireAt != 1<<50 {
+		t.Errorf("expected: %v, got: %v", int64(1<<50), entries[0].ExpireAt)
+	}
+
+	s.Retract(ctx, 1)
+	for _, itemID := range []uint64{1, 2} {
+		if err := s.Update(ctx, &Item{ID: itemID, Version: 9}); err != ErrNotFound {
+			t.Errorf("item %d, expected: %v, got: %v", itemID, ErrNotFound, err)
+		}
+	}
+	if !reflect.DeepEqual(invalidated, []uint64{1, 1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{1, 1}, invalidated)
+	}
+}
+
+func TestUpdateExpiry(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	graph := NewMemoryGraph()
+	graph.Follow(1, 10)
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+	s := NewService(storage, graph, Options{Now: clock, Kinds: Kinds{Hashtag: {Route: func(item *Item) []string {
+		return item.Tags
+	}}}})
+	defer s.Close()
+	s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1, ExpireAt: 100, Tags: []string{"go"}})
+	s.Publish(ctx, &Item{ID: 2, AuthorID: 10, Timestamp: 2, ExpireAt: 1000})
+	s.Wait()
+	// Extend the story, and cut the other one short.
+	s.Update(ctx, &Item{ID: 1, Version: 1, ExpireAt: 1000, Tags: []string{"go"}})
+	s.Update(ctx, &Item{ID: 2, Version: 1, ExpireAt: 100})
+	s.Wait()
+
+	now = time.Unix(0, 500*int64(time.Millisecond))
+	compactor := NewCompactor(storage, CompactorOptions{Interval: time.Hour, Now: clock})
+	compactor.Compact(ctx)
+	compactor.Close()
+	for _, feedID := range []string{HomeFeed(1), HomeFeed(10), UserFeed(10)} {
+		if ids := itemIDs(mustRange(t, storage, feedID)); !reflect.DeepEqual(ids, []uint64{1}) {
+			t.Errorf("%s, expected: %v, got: %v", feedID, []uint64{1}, ids)
+		}
+	}
+	if ids := itemIDs(mustRange(t, storage, FeedOf(Hashtag, "go"))); !reflect.DeepEqual(ids, []uint64{1}) {
+		t.Errorf("expected: %v, got: %v", []uint64{1}, ids)
+	}
+}
@@ -0,0 +1,54 @@
+package timeline
+
+import "context"
+
+// Returns true if a timeline of the viewer has entries newer than the page
+// cursor was returned with as Prev, e.g. to show a "new posts" banner. See
+// CountNew.
+func (s *Service) HasNew(ctx context.Context, viewerID uint64, feedID string, cursor string) (bool, error) {
+	count, err := s.CountNew(ctx, viewerID, feedID, cursor, 1)
+	return count.Count > 0, err
+}
+
+// Returns the number of entries of a timeline of the viewer newer than the
+// page cursor was returned with as Prev, up to max (defaults to 99), e.g. for
+// a "12 new posts" banner. HomeFeed(viewerID) is the viewer's home timeline
+// whatever the delivery. Only the timeline entries are read, not the items,
+// so expired entries are left out but deleted items not yet removed from the
+// timeline and the items reads leave out otherwise are counted: use Unread
+// for an exact count.
+func (s *Service) CountNew(ctx context.Context, viewerID uint64, feedID string, cursor string, max int) (Unread, error) {
+	if max <= 0 {
+		max = 99
+	}
+	decoded, err := s.cursors.Decode(cursor)
+	if err != nil {
+		return Unread{}, err
+	}
+	feedIDs, err := s.timelineFeeds(ctx, viewerID, feedID)
+	if err != nil {
+		return Unread{}, err
+	}
+	now := timestampOf(s.options.Now())
+	count, last := 0, &decoded.Position
+	for scans := 0; scans < maxScans && count <= max; scans++ {
+		entries, err := s.readFeeds(ctx, feedIDs, last, Newer, max+1-count)
+		if err != nil {
+			return Unread{}, err
+		}
+		for _, entry := range entries {
+			if !entry.Expired(now) {
+				count++
+			}
+		}
+		if len(entries) == 0 {
+			break
+		}
+		position := entries[len(entries)-1].Position()
+		last = &position
+	}
+	if count > max {
+		return Unread{Count: max, More: true}, nil
+	}
+	return Unread{Count: count}, nil
+}
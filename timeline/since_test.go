@@ -0,0 +1,44 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountNew(t *testing.T) {
+	ctx := context.Background()
+	for _, delivery := range []Delivery{PushDelivery, PullDelivery} {
+		s, _ := newTestService(delivery)
+		s.Publish(ctx, &Item{ID: 1, AuthorID: 10, Timestamp: 1})
+		s.Wait()
+		page, _ := s.Read(ctx, 1, ReadOptions{})
+		if has, err := s.HasNew(ctx, 1, HomeFeed(1), page.Prev); has || err != nil {
+			t.Errorf("%v, expected nothing new, got: %v (%v)", delivery, has, err)
+		}
+
+		s.Publish(ctx, &Item{ID: 2, AuthorID: 10, Timestamp: 2})
+		s.Publish(ctx, &Item{ID: 3, AuthorID: 10, Timestamp: 3, ExpireAt: 1})
+		s.Publish(ctx, &Item{ID: 4, AuthorID: 10, Timestamp: 4})
+		s.Wait()
+		if has, _ := s.HasNew(ctx, 1, HomeFeed(1), page.Prev); !has {
+			t.Errorf("%v, expected new items", delivery)
+		}
+		tests := []struct {
+			max      int
+			expected Unread
+		}{
+			{0, Unread{Count: 2}},
+			{2, Unread{Count: 2}},
+			{1, Unread{Count: 1, More: true}},
+		}
+		for _, test := range tests {
+			if count, err := s.CountNew(ctx, 1, HomeFeed(1), page.Prev, test.max); count != test.expected || err != nil {
+				t.Errorf("%v, max %d, expected: %v, got: %v (%v)", delivery, test.max, test.expected, count, err)
+			}
+		}
+		if _, err := s.CountNew(ctx, 1, UserFeed(10), "forged", 0); err != ErrBadCursor {
+			t.Errorf("expected: %v, got: %v", ErrBadCursor, err)
+		}
+		s.Close()
+	}
+}
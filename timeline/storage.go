@@ -0,0 +1,176 @@
+// Package timeline is the feed core: it maintains timelines of item
+// references, newest first, on top of a pluggable Storage, and assembles
+// them into pages for readers.
+package timeline
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotFound = errors.New("timeline: item not found")
+
+// Item is a piece of feed content.
+type Item struct {
+	ID       uint64
+	AuthorID uint64
+	// Timestamp orders the item in timelines, in Unix milliseconds.
+	Timestamp int64
+	// Version orders the updates of the item: the last write wins, i.e.
+	// the highest version (see Service.Update).
+	Version uint64
+	// ExpireAt, if not 0, is when the item disappears from timelines, in
+	// Unix milliseconds, e.g. 24h after posting for stories.
+	ExpireAt int64
+	// Likes, Comments and Shares count the engagement with the item, as
+	// time-decay rankers weigh it.
+	Likes    int64
+	Comments int64
+	Shares   int64
+	// Tags are the hashtags of the item, e.g. for routing it to hashtag
+	// timelines (see KindPolicy.Route).
+	Tags []string
+	// Type is the kind of content of the item, e.g. Photo, for filtering
+	// reads (see ReadOptions.Types).
+	Type ItemType
+	// Language is the BCP 47 tag of the language of the item, e.g. "en" or
+	// "pt-BR", for filtering reads (see ReadOptions.Languages).
+	Language string
+	// Verb and ObjectID describe activities, e.g. the author liked (Verb
+	// "like") the photo ObjectID, for grouping.
+	Verb     string
+	ObjectID uint64
+	Payload  []byte
+	// DeletedAt, if not 0, marks the item as a tombstone: its author
+	// deleted it at that time, in Unix milliseconds. Readers leave
+	// tombstones out, including from timelines not cleaned up yet.
+	DeletedAt int64
+	// Injection is the name of the injection that inserted the item into a
+	// page, or "" for organic items (see ReadOptions.Injections). It is
+	// never stored.
+	Injection string
+	// Children are the items a read grouped into this one, newest first,
+	// the item being a copy of the newest (see Grouping). They are never
+	// stored.
+	Children []*Item
+}
+
+// Entry is the reference to an item kept in a timeline. Timelines are
+// ordered by Position, newest first.
+type Entry struct {
+	ItemID    uint64
+	AuthorID  uint64
+	Timestamp int64
+	// ExpireAt is the item's, so that expired entries can be compacted
+	// without reading the items.
+	ExpireAt int64
+	// Score is the item's score at write time, for evicting the entries
+	// with the lowest score from capped timelines (see PushOptions.Cap).
+	Score float64
+}
+
+// Returns the entry referencing item.
+func EntryOf(item *Item) Entry {
+	return Entry{ItemID: item.ID, AuthorID: item.AuthorID, Timestamp: item.Timestamp, ExpireAt: item.ExpireAt}
+}
+
+// Returns true if the item is neither expired at now, in Unix
+// milliseconds, nor deleted, i.e. readers may show it.
+func (item *Item) Visible(now int64) bool {
+	return item.DeletedAt == 0 && !EntryOf(item).Expired(now)
+}
+
+// Returns true if the entry has an expiry time and it is not after now, in
+// Unix milliseconds.
+func (e Entry) Expired(now int64) bool {
+	return e.ExpireAt != 0 && e.ExpireAt <= now
+}
+
+// Position is a point in a timeline. Entries with equal timestamps are
+// ordered by item ID, so that every entry has a distinct position.
+type Position struct {
+	Timestamp int64
+	ItemID    uint64
+}
+
+// Returns the position of the entry.
+func (e Entry) Position() Position {
+	return Position{Timestamp: e.Timestamp, ItemID: e.ItemID}
+}
+
+// Returns true if p comes before other in a timeline, i.e. is newer.
+func (p Position) Before(other Position) bool {
+	if p.Timestamp != other.Timestamp {
+		return p.Timestamp > other.Timestamp
+	}
+	return p.ItemID > other.ItemID
+}
+
+// Eviction selects the entries AppendCapped evicts.
+type Eviction int
+
+const (
+	// EvictOldest evicts the oldest entries.
+	EvictOldest Eviction = iota
+	// EvictLowestScore evicts the entries with the lowest Score, the
+	// oldest first among equal scores.
+	EvictLowestScore
+)
+
+// Direction selects which side of a position RangeByCursor reads.
+type Direction int
+
+const (
+	// Older reads the entries after the position, newest first.
+	Older Direction = iota
+	// Newer reads the entries before the position, closest to it first,
+	// i.e. oldest first.
+	Newer
+)
+
+// Storage keeps timelines and the items they reference. The feed core only
+// talks to storage through this interface, so backends can be swapped and
+// the core tested against MemoryStorage.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// AppendItems adds entries to a timeline, replacing entries of the same
+	// item. Entries can be appended in any order.
+	AppendItems(ctx context.Context, feedID string, entries ...Entry) error
+
+	// AppendCapped adds entries to a timeline like AppendItems, then
+	// evicts entries while it holds more than maxLen, and returns them. The
+	// append and the evictions are atomic, so that the timeline never
+	// exceeds maxLen. An appended entry can be evicted right away.
+	AppendCapped(ctx context.Context, feedID string, maxLen int, eviction Eviction, entries ...Entry) ([]Entry, error)
+
+	// RangeByCursor returns up to limit entries strictly on the direction
+	// side of from, closest to it first. A nil from starts at the newest
+	// entry for Older and at the oldest for Newer.
+	RangeByCursor(ctx context.Context, feedID string, from *Position, direction Direction, limit int) ([]Entry, error)
+
+	// Trim removes the oldest entries beyond maxLen and returns them.
+	Trim(ctx context.Context, feedID string, maxLen int) ([]Entry, error)
+
+	// Delete removes the entries of the items from a timeline. Missing
+	// items are ignored.
+	Delete(ctx context.Context, feedID string, itemIDs ...uint64) error
+
+	// DeleteByAuthor removes the entries of an author's items from a
+	// timeline, using an index rather than scanning it.
+	DeleteByAuthor(ctx context.Context, feedID string, authorID uint64) error
+
+	// PutItems stores items, replacing those with the same ID.
+	PutItems(ctx context.Context, items ...*Item) error
+
+	// UpdateItem replaces the stored item with the same ID if item has a
+	// greater Version, and returns whether it did. Missing items and
+	// tombstones are not replaced.
+	UpdateItem(ctx context.Context, item *Item) (bool, error)
+
+	// MultiGet returns the stored items by ID. Missing items are left out.
+	MultiGet(ctx context.Context, itemIDs ...uint64) (map[uint64]*Item, error)
+
+	// ListFeeds returns up to limit IDs of timelines, in ascending order,
+	// starting after the ID after ("" starts at the first one).
+	ListFeeds(ctx context.Context, after string, limit int) ([]string, error)
+}
@@ -0,0 +1,304 @@
+// Package treemap is an ordered map from keys to values, the companion of
+// treeset with the same set-algebra API. Operations that combine two maps
+// take a MergeFunc deciding the value of keys present in both.
+// Structure is not thread safe.
+package treemap
+
+import (
+	"github.com/emirpasic/gods/utils"
+
+	"feed/treeset"
+)
+
+// entry is stored in the underlying set, ordered by key only. Entries are
+// never shared between maps, so values can be updated in place.
+type entry struct {
+	key   interface{}
+	value interface{}
+}
+
+// MergeFunc returns the value to keep for key, present in both maps with
+// value in the receiver and otherValue in the other map.
+type MergeFunc func(key, value, otherValue interface{}) interface{}
+
+// KeepOwn is the MergeFunc that keeps the receiver's value.
+func KeepOwn(key, value, otherValue interface{}) interface{} {
+	return value
+}
+
+// KeepOther is the MergeFunc that keeps the other map's value.
+func KeepOther(key, value, otherValue interface{}) interface{} {
+	return otherValue
+}
+
+type Map struct {
+	entries    *treeset.Set // of *entry
+	comparator utils.Comparator
+}
+
+// Instantiates a new empty map with the custom key comparator.
+func NewWith(comparator utils.Comparator) *Map {
+	return &Map{entries: treeset.NewWith(byKey(comparator)), comparator: comparator}
+}
+
+// Instantiates a new empty map with the IntComparator, i.e. keys are of type int.
+func NewWithIntComparator() *Map {
+	return NewWith(utils.IntComparator)
+}
+
+// Instantiates a new empty map with the StringComparator, i.e. keys are of type string.
+func NewWithStringComparator() *Map {
+	return NewWith(utils.StringComparator)
+}
+
+func byKey(comparator utils.Comparator) utils.Comparator {
+	return func(a, b interface{}) int {
+		return comparator(a.(*entry).key, b.(*entry).key)
+	}
+}
+
+// fromSorted returns a map with the same comparator as m holding entries,
+// which must be ascending and not belong to any other map.
+func (m *Map) fromSorted(entries []interface{}) *Map {
+	return &Map{entries: treeset.NewFromSorted(byKey(m.comparator), entries...), comparator: m.comparator}
+}
+
+func (m *Map) lookup(key interface{}) *entry {
+	found, ok := m.entries.Ceiling(&entry{key: key})
+	if !ok || m.comparator(found.(*entry).key, key) != 0 {
+		return nil
+	}
+	return found.(*entry)
+}
+
+// Sets the value of key, adding key if absent.
+func (m *Map) Put(key interface{}, value interface{}) {
+	if e := m.lookup(key); e != nil {
+		e.value = value
+		return
+	}
+	m.entries.Add(&entry{key: key, value: value})
+}
+
+// Returns the value of key and whether key is present.
+func (m *Map) Get(key interface{}) (value interface{}, found bool) {
+	if e := m.lookup(key); e != nil {
+		return e.value, true
+	}
+	return nil, false
+}
+
+// Removes the keys (one or more) from the map.
+func (m *Map) Remove(keys ...interface{}) {
+	for _, key := range keys {
+		m.entries.Remove(&entry{key: key})
+	}
+}
+
+// Check wether keys (one or more) are present in the map.
+// Returns true if no arguments are passed at all.
+func (m *Map) Contains(keys ...interface{}) bool {
+	for _, key := range keys {
+		if m.lookup(key) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Calls f for every key in ascending order with its value.
+func (m *Map) Each(f func(key, value interface{})) {
+	it := m.entries.Iterator()
+	for it.Next() {
+		e := it.Value().(*entry)
+		f(e.key, e.value)
+	}
+}
+
+// Returns all keys in ascending order.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, 0, m.Size())
+	m.Each(func(key, value interface{}) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Returns all values in ascending order of their keys.
+func (m *Map) Values() []interface{} {
+	values := make([]interface{}, 0, m.Size())
+	m.Each(func(key, value interface{}) {
+		values = append(values, value)
+	})
+	return values
+}
+
+// Returns true if map does not contain any elements.
+func (m *Map) Empty() bool {
+	return m.entries.Empty()
+}
+
+// Returns number of elements within the map.
+func (m *Map) Size() int {
+	return m.entries.Size()
+}
+
+// Clears all entries in the map.
+func (m *Map) Clear() {
+	m.entries.Clear()
+}
+
+// Returns the entry with the largest key less than or equal to key.
+func (m *Map) Floor(key interface{}) (foundKey interface{}, value interface{}, ok bool) {
+	return unpack(m.entries.Floor(&entry{key: key}))
+}
+
+// Returns the entry with the smallest key greater than or equal to key.
+func (m *Map) Ceiling(key interface{}) (foundKey interface{}, value interface{}, ok bool) {
+	return unpack(m.entries.Ceiling(&entry{key: key}))
+}
+
+func unpack(found interface{}, ok bool) (interface{}, interface{}, bool) {
+	if !ok {
+		return nil, nil, false
+	}
+	e := found.(*entry)
+	return e.key, e.value, true
+}
+
+// Returns an independent copy of the map in O(n).
+func (m *Map) Clone() *Map {
+	entries := make([]interface{}, 0, m.Size())
+	m.Each(func(key, value interface{}) {
+		entries = append(entries, &entry{key: key, value: value})
+	})
+	return m.fromSorted(entries)
+}
+
+// Range copies follow treeset: the lower bound is inclusive and the upper
+// bound exclusive.
+
+// Returns a new map with the entries whose keys are strictly less than to.
+func (m *Map) HeadMap(to interface{}) *Map {
+	return m.copyRange(nil, to, false, true)
+}
+
+// Returns a new map with the entries whose keys are greater than or equal to from.
+func (m *Map) TailMap(from interface{}) *Map {
+	return m.copyRange(from, nil, true, false)
+}
+
+// Returns a new map with the entries whose keys are greater than or equal
+// to from and strictly less than to.
+func (m *Map) SubMap(from, to interface{}) *Map {
+	return m.copyRange(from, to, true, true)
+}
+
+func (m *Map) copyRange(from, to interface{}, hasFrom, hasTo bool) *Map {
+	it := m.entries.Iterator()
+	var ok bool
+	if hasFrom {
+		ok = it.Seek(&entry{key: from})
+	} else {
+		ok = it.Next()
+	}
+	var entries []interface{}
+	for ; ok; ok = it.Next() {
+		e := it.Value().(*entry)
+		if hasTo && m.comparator(e.key, to) >= 0 {
+			break
+		}
+		entries = append(entries, &entry{key: e.key, value: e.value})
+	}
+	return m.fromSorted(entries)
+}
+
+// merge walks both maps in key order and returns the entries for which keep
+// approves, merging the values of keys present in both.
+func (m *Map) merge(other *Map, merge MergeFunc, keep func(inMap, inOther bool) bool) []interface{} {
+	var entries []interface{}
+	add := func(key, value interface{}, inMap, inOther bool) {
+		if keep(inMap, inOther) {
+			entries = append(entries, &entry{key: key, value: value})
+		}
+	}
+	i, j := m.entries.Iterator(), other.entries.Iterator()
+	hasI, hasJ := i.Next(), j.Next()
+	for hasI || hasJ {
+		switch {
+		case !hasJ:
+			e := i.Value().(*entry)
+			add(e.key, e.value, true, false)
+			hasI = i.Next()
+		case !hasI:
+			e := j.Value().(*entry)
+			add(e.key, e.value, false, true)
+			hasJ = j.Next()
+		default:
+			a, b := i.Value().(*entry), j.Value().(*entry)
+			compare := m.comparator(a.key, b.key)
+			switch {
+			case compare == 0:
+				if keep(true, true) {
+					add(a.key, merge(a.key, a.value, b.value), true, true)
+				}
+				hasI, hasJ = i.Next(), j.Next()
+			case compare < 0:
+				add(a.key, a.value, true, false)
+				hasI = i.Next()
+			case compare > 0:
+				add(b.key, b.value, false, true)
+				hasJ = j.Next()
+			}
+		}
+	}
+	return entries
+}
+
+// Returns a new map with the keys of both maps, merging the values of keys
+// present in both with merge.
+func (m *Map) Union(other *Map, merge MergeFunc) *Map {
+	return m.fromSorted(m.merge(other, merge, func(inMap, inOther bool) bool { return true }))
+}
+
+// Returns a new map with the keys present in both maps, their values merged
+// with merge.
+func (m *Map) Inter(other *Map, merge MergeFunc) *Map {
+	return m.fromSorted(m.merge(other, merge, func(inMap, inOther bool) bool { return inMap && inOther }))
+}
+
+// Returns a new map with the entries whose keys are not present in other.
+func (m *Map) Diff(other *Map) *Map {
+	return m.fromSorted(m.merge(other, KeepOwn, func(inMap, inOther bool) bool { return inMap && !inOther }))
+}
+
+// Adds the entries of other, merging the values of keys present in both
+// with merge.
+func (m *Map) InPlaceUnion(other *Map, merge MergeFunc) {
+	if other == m {
+		return
+	}
+	other.Each(func(key, value interface{}) {
+		if e := m.lookup(key); e != nil {
+			e.value = merge(key, e.value, value)
+		} else {
+			m.entries.Add(&entry{key: key, value: value})
+		}
+	})
+}
+
+// Keeps only the keys present in other, merging their values with merge.
+func (m *Map) InPlaceInter(other *Map, merge MergeFunc) {
+	m.entries = m.Inter(other, merge).entries
+}
+
+// Removes the keys present in other.
+func (m *Map) InPlaceDiff(other *Map) {
+	if other == m {
+		m.Clear()
+		return
+	}
+	other.Each(func(key, value interface{}) {
+		m.Remove(key)
+	})
+}
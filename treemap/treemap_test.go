@@ -0,0 +1,91 @@
+package treemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newIntMap(pairs ...int) *Map {
+	m := NewWithIntComparator()
+	for i := 0; i < len(pairs); i += 2 {
+		m.Put(pairs[i], pairs[i+1])
+	}
+	return m
+}
+
+func sum(key, value, otherValue interface{}) interface{} {
+	return value.(int) + otherValue.(int)
+}
+
+func checkEntries(t *testing.T, name string, m *Map, pairs ...int) {
+	var keys, values []interface{}
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i])
+		values = append(values, pairs[i+1])
+	}
+	if len(keys) == 0 {
+		keys, values = []interface{}{}, []interface{}{}
+	}
+	if !reflect.DeepEqual(m.Keys(), keys) || !reflect.DeepEqual(m.Values(), values) {
+		t.Errorf("%s, expected: %v %v, got: %v %v", name, keys, values, m.Keys(), m.Values())
+	}
+}
+
+func TestMapBasics(t *testing.T) {
+	m := newIntMap(3, 30, 1, 10, 2, 20)
+	m.Put(2, 21)
+	if value, found := m.Get(2); !found || value != 21 {
+		t.Errorf("expected: %v, got: %v", 21, value)
+	}
+	if _, found := m.Get(4); found {
+		t.Errorf("expected missing key to be absent")
+	}
+	m.Remove(1, 9)
+	checkEntries(t, "Remove", m, 2, 21, 3, 30)
+	if !m.Contains(2, 3) || m.Contains(1) {
+		t.Errorf("unexpected Contains result for: %v", m.Keys())
+	}
+	if key, value, ok := m.Floor(2); !ok || key != 2 || value != 21 {
+		t.Errorf("Floor, expected: %v %v, got: %v %v", 2, 21, key, value)
+	}
+	if key, _, ok := m.Ceiling(4); ok {
+		t.Errorf("Ceiling, expected none, got: %v", key)
+	}
+
+	clone := m.Clone()
+	clone.Put(2, 0)
+	if value, _ := m.Get(2); value != 21 {
+		t.Errorf("expected clone to be independent, got: %v", value)
+	}
+}
+
+func TestMapAlgebra(t *testing.T) {
+	a, b := newIntMap(1, 1, 2, 2, 3, 3), newIntMap(2, 20, 3, 30, 4, 40)
+
+	checkEntries(t, "Union", a.Union(b, sum), 1, 1, 2, 22, 3, 33, 4, 40)
+	checkEntries(t, "Inter", a.Inter(b, KeepOther), 2, 20, 3, 30)
+	checkEntries(t, "Diff", a.Diff(b), 1, 1)
+
+	c := a.Clone()
+	c.InPlaceUnion(b, KeepOwn)
+	checkEntries(t, "InPlaceUnion", c, 1, 1, 2, 2, 3, 3, 4, 40)
+	c.InPlaceInter(a, sum)
+	checkEntries(t, "InPlaceInter", c, 1, 2, 2, 4, 3, 6)
+	c.InPlaceDiff(b)
+	checkEntries(t, "InPlaceDiff", c, 1, 2)
+	checkEntries(t, "unchanged", a, 1, 1, 2, 2, 3, 3)
+}
+
+func TestMapRanges(t *testing.T) {
+	m := newIntMap(1, 10, 2, 20, 3, 30, 4, 40)
+	checkEntries(t, "HeadMap", m.HeadMap(3), 1, 10, 2, 20)
+	checkEntries(t, "TailMap", m.TailMap(3), 3, 30, 4, 40)
+	checkEntries(t, "SubMap", m.SubMap(2, 4), 2, 20, 3, 30)
+	checkEntries(t, "SubMap empty", m.SubMap(4, 2))
+
+	sub := m.SubMap(1, 3)
+	sub.Put(1, 0)
+	if value, _ := m.Get(1); value != 10 {
+		t.Errorf("expected range copy to be independent, got: %v", value)
+	}
+}
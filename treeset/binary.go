@@ -0,0 +1,226 @@
+package treeset
+
+// Compact binary encoding: a version byte, the item count as a uvarint, then
+// every item in ascending order as a uvarint length followed by that many
+// bytes produced by an ItemCodec. A set of int64 IDs costs about two bytes
+// per small ID, against the ten or so of a JSON array.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const binaryVersion = 1
+
+// MaxItemLength bounds the encoded length of a single item, so a corrupt
+// length cannot make the decoder allocate gigabytes.
+const MaxItemLength = 1 << 20
+
+var (
+	ErrBadVersion  = errors.New("unsupported binary set version")
+	ErrUnsorted    = errors.New("binary set items are not in ascending order")
+	ErrItemTooLong = fmt.Errorf("binary set item longer than %d bytes", MaxItemLength)
+	ErrBadCount    = errors.New("binary set item count out of range")
+)
+
+// ItemCodec converts single items to and from bytes.
+type ItemCodec interface {
+	// Appends the encoding of item to buf.
+	AppendItem(buf []byte, item interface{}) ([]byte, error)
+	// Decodes an item from exactly the bytes AppendItem produced.
+	DecodeItem(data []byte) (interface{}, error)
+}
+
+type int64Codec struct{}
+type intCodec struct{}
+type stringCodec struct{}
+
+var (
+	// Int64Codec encodes int64 items as zig-zag varints.
+	Int64Codec ItemCodec = int64Codec{}
+	// IntCodec encodes int items as zig-zag varints.
+	IntCodec ItemCodec = intCodec{}
+	// StringCodec encodes string items as their raw bytes.
+	StringCodec ItemCodec = stringCodec{}
+)
+
+func appendVarint(buf []byte, v int64) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	return append(buf, scratch[:binary.PutVarint(scratch[:], v)]...)
+}
+
+func decodeVarint(data []byte) (int64, error) {
+	v, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return 0, fmt.Errorf("invalid varint item %x", data)
+	}
+	return v, nil
+}
+
+func (int64Codec) AppendItem(buf []byte, item interface{}) ([]byte, error) {
+	v, ok := item.(int64)
+	if !ok {
+		return buf, fmt.Errorf("expected int64 item, got %T", item)
+	}
+	return appendVarint(buf, v), nil
+}
+
+func (int64Codec) DecodeItem(data []byte) (interface{}, error) {
+	return decodeVarint(data)
+}
+
+func (intCodec) AppendItem(buf []byte, item interface{}) ([]byte, error) {
+	v, ok := item.(int)
+	if !ok {
+		return buf, fmt.Errorf("expected int item, got %T", item)
+	}
+	return appendVarint(buf, int64(v)), nil
+}
+
+func (intCodec) DecodeItem(data []byte) (interface{}, error) {
+	v, err := decodeVarint(data)
+	return int(v), err
+}
+
+func (stringCodec) AppendItem(buf []byte, item interface{}) ([]byte, error) {
+	v, ok := item.(string)
+	if !ok {
+		return buf, fmt.Errorf("expected string item, got %T", item)
+	}
+	return append(buf, v...), nil
+}
+
+func (stringCodec) DecodeItem(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+// Writes the set to w in the binary format, encoding items with codec.
+func (set *Set) Encode(w io.Writer, codec ItemCodec) error {
+	bw := bufio.NewWriter(w)
+	header := []byte{binaryVersion}
+	header = appendUvarint(header, uint64(set.Size()))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	var item, length []byte
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		var err error
+		if item, err = codec.AppendItem(item[:0], node.Key); err != nil {
+			return err
+		}
+		length = appendUvarint(length[:0], uint64(len(item)))
+		if _, err := bw.Write(length); err != nil {
+			return err
+		}
+		if _, err := bw.Write(item); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	return append(buf, scratch[:binary.PutUvarint(scratch[:], v)]...)
+}
+
+// Decoder reads the items of a binary-encoded set one at a time, so huge sets
+// can be streamed without buffering them.
+type Decoder struct {
+	r         *bufio.Reader
+	codec     ItemCodec
+	remaining int
+	buf       []byte
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// Reads the header of a binary-encoded set from r. Counts beyond what an int
+// holds fail with ErrBadCount.
+func NewDecoder(r io.Reader, codec ItemCodec) (*Decoder, error) {
+	br := bufio.NewReader(r)
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != binaryVersion {
+		return nil, ErrBadVersion
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(maxInt) {
+		return nil, ErrBadCount
+	}
+	return &Decoder{r: br, codec: codec, remaining: int(count)}, nil
+}
+
+// Returns the number of items not read yet.
+func (d *Decoder) Remaining() int {
+	return d.remaining
+}
+
+// Returns the next item in ascending order, or io.EOF after the last one.
+// Items longer than MaxItemLength fail with ErrItemTooLong, and items cut
+// short by the end of the input with io.ErrUnexpectedEOF.
+func (d *Decoder) Next() (interface{}, error) {
+	if d.remaining == 0 {
+		return nil, io.EOF
+	}
+	length, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if length > MaxItemLength {
+		return nil, ErrItemTooLong
+	}
+	if uint64(cap(d.buf)) < length {
+		d.buf = make([]byte, length)
+	}
+	d.buf = d.buf[:length]
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		return nil, noEOF(err)
+	}
+	d.remaining--
+	return d.codec.DecodeItem(d.buf)
+}
+
+// noEOF reports a stream that ends before its announced item count as truncated.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Replaces the contents of set with a binary-encoded set read from r,
+// decoding items with codec. Items are streamed straight into an O(n) bulk
+// load. On error the set is left empty.
+func (set *Set) Decode(r io.Reader, codec ItemCodec) error {
+	d, err := NewDecoder(r, codec)
+	if err != nil {
+		return err
+	}
+	set.Clear()
+	var last interface{}
+	set.tree.load(d.Remaining(), func() (interface{}, bool) {
+		if err != nil {
+			return nil, false
+		}
+		var item interface{}
+		if item, err = d.Next(); err == nil && last != nil && set.comparator(last, item) >= 0 {
+			err = ErrUnsorted
+		}
+		last = item
+		return item, err == nil
+	}, itemExists)
+	if err != nil {
+		set.tree.Root = nil
+		return err
+	}
+	return nil
+}
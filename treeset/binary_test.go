@@ -0,0 +1,116 @@
+package treeset
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	set := NewWith(Int64Comparator)
+	for _, id := range []int64{-5, 0, 1, 300, 1 << 40} {
+		set.Add(id)
+	}
+	var buf bytes.Buffer
+	if err := set.Encode(&buf, Int64Codec); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewWith(Int64Comparator)
+	restored.Add(int64(99))
+	if err := restored.Decode(bytes.NewReader(buf.Bytes()), Int64Codec); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Equals(set) || restored.Size() != 5 {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Values())
+	}
+	checkRBTree(t, restored.tree.Root)
+
+	strings := NewWithStringComparator()
+	strings.Add("", "b", "a")
+	buf.Reset()
+	if err := strings.Encode(&buf, StringCodec); err != nil {
+		t.Fatal(err)
+	}
+	restoredStrings := NewWithStringComparator()
+	if err := restoredStrings.Decode(&buf, StringCodec); err != nil || !restoredStrings.Equals(strings) {
+		t.Errorf("expected: %v, got: %v (%v)", strings.Values(), restoredStrings.Values(), err)
+	}
+}
+
+func TestBinaryDecoderStreams(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newIntSet(3, 1, 2).Encode(&buf, IntCodec); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDecoder(&buf, IntCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	for {
+		item, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if expected := []interface{}{1, 2, 3}; !equalValues(items, expected) {
+		t.Errorf("expected: %v, got: %v", expected, items)
+	}
+}
+
+func TestBinaryDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newIntSet(1, 2, 3).Encode(&buf, IntCodec); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	set := newIntSet(7)
+	if err := set.Decode(bytes.NewReader(data[:len(data)-1]), IntCodec); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected: %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+	if !set.Empty() {
+		t.Errorf("expected an empty set after a failed decode, got: %v", set.Values())
+	}
+
+	// Announce a huge count; decoding must fail fast instead of allocating.
+	huge := append([]byte{binaryVersion, 0xff, 0xff, 0xff, 0xff, 0x0f}, data[2:]...)
+	if err := set.Decode(bytes.NewReader(huge), IntCodec); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected: %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+
+	// A count beyond an int must not turn into a negative size.
+	overflow := []byte{binaryVersion, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	if err := set.Decode(bytes.NewReader(overflow), IntCodec); err != ErrBadCount {
+		t.Errorf("expected: %v, got: %v", ErrBadCount, err)
+	}
+	if _, err := NewDecoder(bytes.NewReader(overflow), IntCodec); err != ErrBadCount {
+		t.Errorf("expected: %v, got: %v", ErrBadCount, err)
+	}
+	// Announce a huge item; it must be rejected before its buffer is allocated.
+	long := []byte{binaryVersion, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	if err := set.Decode(bytes.NewReader(long), IntCodec); err != ErrItemTooLong {
+		t.Errorf("expected: %v, got: %v", ErrItemTooLong, err)
+	}
+	// A length within the bound but past the end of the input is truncated.
+	short := []byte{binaryVersion, 1, 0x80, 0x80, 0x10, 1}
+	if err := set.Decode(bytes.NewReader(short), IntCodec); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected: %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+
+	unsorted := NewWith(Reverse(IntComparator))
+	if err := unsorted.Decode(bytes.NewReader(data), IntCodec); err != ErrUnsorted {
+		t.Errorf("expected: %v, got: %v", ErrUnsorted, err)
+	}
+	if err := set.Decode(bytes.NewReader([]byte{9}), IntCodec); err != ErrBadVersion {
+		t.Errorf("expected: %v, got: %v", ErrBadVersion, err)
+	}
+	if err := newIntSet(1).Encode(&buf, StringCodec); err == nil {
+		t.Errorf("expected an error encoding ints with StringCodec")
+	}
+}
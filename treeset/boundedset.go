@@ -0,0 +1,91 @@
+package treeset
+
+import "github.com/emirpasic/gods/utils"
+
+// EvictionPolicy decides which end of a BoundedSet is trimmed on overflow.
+type EvictionPolicy int
+
+const (
+	// EvictSmallest drops the smallest items, keeping the largest N,
+	// e.g. the latest N posts of a timeline ordered by time.
+	EvictSmallest EvictionPolicy = iota
+	// EvictLargest drops the largest items, keeping the smallest N.
+	EvictLargest
+)
+
+// BoundedSet is an ordered set holding at most maxSize items, trimmed from
+// one end according to its EvictionPolicy whenever an Add overflows it.
+// Structure is not thread safe.
+type BoundedSet struct {
+	set     *Set
+	maxSize int
+	policy  EvictionPolicy
+}
+
+// Instantiates a new empty bounded set with the custom comparator, holding at
+// most maxSize items. A maxSize <= 0 means unbounded.
+func NewBounded(comparator utils.Comparator, maxSize int, policy EvictionPolicy) *BoundedSet {
+	return &BoundedSet{set: NewWith(comparator), maxSize: maxSize, policy: policy}
+}
+
+// Adds the items (one or more) to the set, then evicts items while the set is
+// over its maximum size and returns them in eviction order. An added item
+// can be evicted right away, e.g. a post older than the whole timeline.
+func (bs *BoundedSet) Add(items ...interface{}) (evicted []interface{}) {
+	bs.set.Add(items...)
+	for bs.maxSize > 0 && bs.set.Size() > bs.maxSize {
+		var item interface{}
+		if bs.policy == EvictLargest {
+			item, _ = bs.set.PopMax()
+		} else {
+			item, _ = bs.set.PopMin()
+		}
+		evicted = append(evicted, item)
+	}
+	return evicted
+}
+
+// Removes the items (one or more) from the set.
+func (bs *BoundedSet) Remove(items ...interface{}) {
+	bs.set.Remove(items...)
+}
+
+// Check wether items (one or more) are present in the set.
+func (bs *BoundedSet) Contains(items ...interface{}) bool {
+	return bs.set.Contains(items...)
+}
+
+// Returns the smallest item, and whether the set is non-empty.
+func (bs *BoundedSet) Min() (interface{}, bool) {
+	return bs.set.Min()
+}
+
+// Returns the largest item, and whether the set is non-empty.
+func (bs *BoundedSet) Max() (interface{}, bool) {
+	return bs.set.Max()
+}
+
+// Returns the maximum number of items kept in the set.
+func (bs *BoundedSet) MaxSize() int {
+	return bs.maxSize
+}
+
+// Returns true if set does not contain any elements.
+func (bs *BoundedSet) Empty() bool {
+	return bs.set.Empty()
+}
+
+// Returns number of elements within the set.
+func (bs *BoundedSet) Size() int {
+	return bs.set.Size()
+}
+
+// Clears all values in the set.
+func (bs *BoundedSet) Clear() {
+	bs.set.Clear()
+}
+
+// Returns all items in the set in comparator order.
+func (bs *BoundedSet) Values() []interface{} {
+	return bs.set.Values()
+}
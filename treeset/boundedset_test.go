@@ -0,0 +1,33 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestBoundedSetEvictSmallest(t *testing.T) {
+	set := NewBounded(utils.IntComparator, 3, EvictSmallest)
+	if evicted := set.Add(5, 1, 4); len(evicted) != 0 {
+		t.Errorf("expected no eviction, got: %v", evicted)
+	}
+	if evicted := set.Add(2, 6); !equalValues(evicted, []interface{}{1, 2}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2}, evicted)
+	}
+	if expected := []interface{}{4, 5, 6}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+}
+
+func TestBoundedSetEvictLargest(t *testing.T) {
+	set := NewBounded(utils.IntComparator, 2, EvictLargest)
+	set.Add(3, 1, 2)
+	if expected := []interface{}{1, 2}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	unbounded := NewBounded(utils.IntComparator, 0, EvictLargest)
+	if evicted := unbounded.Add(1, 2, 3); len(evicted) != 0 || unbounded.Size() != 3 {
+		t.Errorf("expected an unbounded set, got: %v", unbounded.Values())
+	}
+}
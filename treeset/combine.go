@@ -0,0 +1,96 @@
+package treeset
+
+// CombineResult holds the outcome of a single merge walk over two sets, from
+// which the union, intersection and one-sided differences are all derived
+// without re-scanning either set.
+type CombineResult struct {
+	set       *Set
+	leftOnly  []interface{}
+	both      []interface{}
+	rightOnly []interface{}
+}
+
+// Walks set and otherSet once and returns a CombineResult exposing their
+// union, intersection and differences. Result sets carry the receiver's
+// comparator; for items present in both, the receiver's one is kept.
+func (set *Set) Combine(otherSet *Set) *CombineResult {
+	result := &CombineResult{set: set}
+	i, j := leftmost(set.tree), leftmost(otherSet.tree)
+	for i != nil || j != nil {
+		switch {
+		case j == nil:
+			result.leftOnly = append(result.leftOnly, i.Key)
+			i = successor(i)
+		case i == nil:
+			result.rightOnly = append(result.rightOnly, j.Key)
+			j = successor(j)
+		default:
+			compare := set.comparator(i.Key, j.Key)
+			switch {
+			case compare == 0:
+				result.both = append(result.both, i.Key)
+				i = successor(i)
+				j = successor(j)
+			case compare < 0:
+				result.leftOnly = append(result.leftOnly, i.Key)
+				i = successor(i)
+			case compare > 0:
+				result.rightOnly = append(result.rightOnly, j.Key)
+				j = successor(j)
+			}
+		}
+	}
+	return result
+}
+
+// newSet builds a set from disjoint parts, each sorted by the comparator, in
+// O(n) bulk loads instead of item by item.
+func (cr *CombineResult) newSet(parts ...[]interface{}) *Set {
+	newSet := cr.set.newEmpty()
+	if len(parts) == 1 {
+		newSet.tree.loadSorted(parts[0], itemExists)
+	} else {
+		newSet.tree.loadSorted(mergeSorted(cr.set.comparator, parts), itemExists)
+	}
+	return newSet
+}
+
+// Returns the items present in either set.
+func (cr *CombineResult) Union() *Set {
+	return cr.newSet(cr.leftOnly, cr.both, cr.rightOnly)
+}
+
+// Returns the items present in both sets.
+func (cr *CombineResult) Intersection() *Set {
+	return cr.newSet(cr.both)
+}
+
+// Returns the items present only in the receiver of Combine.
+func (cr *CombineResult) LeftOnly() *Set {
+	return cr.newSet(cr.leftOnly)
+}
+
+// Returns the items present only in the argument of Combine.
+func (cr *CombineResult) RightOnly() *Set {
+	return cr.newSet(cr.rightOnly)
+}
+
+// Returns the number of items present in either set.
+func (cr *CombineResult) UnionSize() int {
+	return len(cr.leftOnly) + len(cr.both) + len(cr.rightOnly)
+}
+
+// Returns the number of items present in both sets.
+func (cr *CombineResult) IntersectionSize() int {
+	return len(cr.both)
+}
+
+// Returns the number of items present only in the receiver of Combine.
+func (cr *CombineResult) LeftOnlySize() int {
+	return len(cr.leftOnly)
+}
+
+// Returns the number of items present only in the argument of Combine.
+func (cr *CombineResult) RightOnlySize() int {
+	return len(cr.rightOnly)
+}
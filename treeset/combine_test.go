@@ -0,0 +1,35 @@
+package treeset
+
+import "testing"
+
+func TestCombine(t *testing.T) {
+	pairs := [][2]*Set{
+		{newIntSet(1, 2, 3, 5, 8), newIntSet(2, 4, 5, 9)},
+		{newIntSet(1, 2), newIntSet()},
+		{newIntSet(), newIntSet(1, 2)},
+	}
+	for _, pair := range pairs {
+		a, b := pair[0], pair[1]
+		result := a.Combine(b)
+		checks := []struct {
+			name     string
+			got      *Set
+			gotSize  int
+			expected *Set
+		}{
+			{"Union", result.Union(), result.UnionSize(), a.Union(b)},
+			{"Intersection", result.Intersection(), result.IntersectionSize(), a.Inter(b)},
+			{"LeftOnly", result.LeftOnly(), result.LeftOnlySize(), a.Diff(b)},
+			{"RightOnly", result.RightOnly(), result.RightOnlySize(), b.Diff(a)},
+		}
+		for _, c := range checks {
+			if !equalValues(c.got.Values(), c.expected.Values()) {
+				t.Errorf("%s, expected: %v, got: %v", c.name, c.expected.Values(), c.got.Values())
+			}
+			if c.gotSize != c.expected.Size() {
+				t.Errorf("%sSize, expected: %d, got: %d", c.name, c.expected.Size(), c.gotSize)
+			}
+			checkRBTree(t, c.got.tree.Root)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package treeset
+
+import (
+	"reflect"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func Int64Comparator(a, b interface{}) int {
+	aInt := a.(int64)
+	bInt := b.(int64)
+	switch {
+	case aInt > bInt:
+		return 1
+	case aInt < bInt:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// comparatorKind marks trees ordered by a built-in comparator, whose inserts
+// and lookups can compare keys directly.
+type comparatorKind int
+
+const (
+	customComparator comparatorKind = iota
+	intComparator
+	stringComparator
+)
+
+var (
+	intComparatorPointer    = reflect.ValueOf(utils.IntComparator).Pointer()
+	stringComparatorPointer = reflect.ValueOf(utils.StringComparator).Pointer()
+)
+
+// kindOf recognizes the built-in comparators, whichever constructor they are
+// passed to.
+func kindOf(comparator utils.Comparator) comparatorKind {
+	if comparator == nil {
+		return customComparator
+	}
+	switch reflect.ValueOf(comparator).Pointer() {
+	case intComparatorPointer:
+		return intComparator
+	case stringComparatorPointer:
+		return stringComparator
+	}
+	return customComparator
+}
+
+var IntComparator = utils.IntComparator
+var StringComparator = utils.StringComparator
+
+func withTieBreak(primary, tieBreak utils.Comparator) utils.Comparator {
+	return Chain(primary, tieBreak)
+}
+
+// Comparator combinators. Feed orderings are typically built as
+//
+//	Chain(Reverse(ByInt64(score)), ByInt64(id))
+//
+// i.e. by score descending, tie-broken by ID ascending.
+
+// Returns a comparator ordering items the opposite way to comparator.
+func Reverse(comparator utils.Comparator) utils.Comparator {
+	return func(a, b interface{}) int {
+		return comparator(b, a)
+	}
+}
+
+// Returns a comparator that orders by the first of comparators, consulting
+// each next one only when all previous ones report two items as equal.
+func Chain(comparators ...utils.Comparator) utils.Comparator {
+	return func(a, b interface{}) int {
+		for _, comparator := range comparators {
+			if compare := comparator(a, b); compare != 0 {
+				return compare
+			}
+		}
+		return 0
+	}
+}
+
+// Returns a comparator ordering items by the field extracted by field,
+// compared with comparator.
+func ByField(field func(item interface{}) interface{}, comparator utils.Comparator) utils.Comparator {
+	return func(a, b interface{}) int {
+		return comparator(field(a), field(b))
+	}
+}
+
+// Returns a comparator ordering items by an int64 field, e.g. a timestamp or
+// an ID, without boxing the field values.
+func ByInt64(field func(item interface{}) int64) utils.Comparator {
+	return func(a, b interface{}) int {
+		x, y := field(a), field(b)
+		switch {
+		case x > y:
+			return 1
+		case x < y:
+			return -1
+		default:
+			return 0
+		}
+	}
+}
+
+// Returns a comparator ordering items by a float64 field, e.g. a score.
+func ByFloat64(field func(item interface{}) float64) utils.Comparator {
+	return func(a, b interface{}) int {
+		x, y := field(a), field(b)
+		switch {
+		case x > y:
+			return 1
+		case x < y:
+			return -1
+		default:
+			return 0
+		}
+	}
+}
+
+// Returns a comparator ordering items by a string field.
+func ByString(field func(item interface{}) string) utils.Comparator {
+	return func(a, b interface{}) int {
+		x, y := field(a), field(b)
+		switch {
+		case x > y:
+			return 1
+		case x < y:
+			return -1
+		default:
+			return 0
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestComparatorCombinators(t *testing.T) {
+	type scored struct {
+		id    int64
+		score float64
+		name  string
+	}
+	items := []interface{}{
+		scored{id: 3, score: 1.5, name: "c"},
+		scored{id: 1, score: 2.5, name: "a"},
+		scored{id: 2, score: 1.5, name: "b"},
+	}
+	id := func(item interface{}) int64 { return item.(scored).id }
+	score := func(item interface{}) float64 { return item.(scored).score }
+	name := func(item interface{}) string { return item.(scored).name }
+
+	tests := []struct {
+		name       string
+		comparator utils.Comparator
+		expected   []int64
+	}{
+		{"ByInt64", ByInt64(id), []int64{1, 2, 3}},
+		{"Reverse", Reverse(ByInt64(id)), []int64{3, 2, 1}},
+		{"Chain", Chain(Reverse(ByFloat64(score)), ByInt64(id)), []int64{1, 2, 3}},
+		{"Chain reversed tie-break", Chain(ByFloat64(score), Reverse(ByInt64(id))), []int64{3, 2, 1}},
+		{"ByString", ByString(name), []int64{1, 2, 3}},
+		{"ByField", Reverse(ByField(func(item interface{}) interface{} { return item.(scored).name }, utils.StringComparator)), []int64{3, 2, 1}},
+	}
+	for _, test := range tests {
+		set := NewWith(test.comparator)
+		set.Add(items...)
+		var ids []int64
+		for _, item := range set.Values() {
+			ids = append(ids, item.(scored).id)
+		}
+		if len(ids) != len(test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, ids)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package treeset
+
+type EditOp int
+
+const (
+	EditAdd EditOp = iota
+	EditRemove
+)
+
+func (op EditOp) String() string {
+	switch op {
+	case EditAdd:
+		return "Add"
+	case EditRemove:
+		return "Remove"
+	}
+	return "Unknown"
+}
+
+// Edit is a single step of an edit script.
+type Edit struct {
+	Op    EditOp
+	Value interface{}
+}
+
+// Returns the minimal list of edits that turns the set into target: removals
+// of items missing from target and additions of items only in target,
+// ordered by value. Computed in a single merge walk, it can be applied
+// as-is to a downstream store.
+func (set *Set) EditScript(target *Set) []Edit {
+	script := make([]Edit, 0)
+	i, j := leftmost(set.tree), leftmost(target.tree)
+	for i != nil || j != nil {
+		switch {
+		case j == nil:
+			script = append(script, Edit{Op: EditRemove, Value: i.Key})
+			i = successor(i)
+		case i == nil:
+			script = append(script, Edit{Op: EditAdd, Value: j.Key})
+			j = successor(j)
+		default:
+			compare := set.comparator(i.Key, j.Key)
+			switch {
+			case compare == 0:
+				i = successor(i)
+				j = successor(j)
+			case compare < 0:
+				script = append(script, Edit{Op: EditRemove, Value: i.Key})
+				i = successor(i)
+			case compare > 0:
+				script = append(script, Edit{Op: EditAdd, Value: j.Key})
+				j = successor(j)
+			}
+		}
+	}
+	return script
+}
+
+// Returns the items of newSet missing from oldSet (added) and the items of
+// oldSet missing from newSet (removed), both ascending, computed in a single
+// merge walk. Handy to push incremental updates between two consecutive
+// snapshots of a timeline.
+func Delta(oldSet, newSet *Set) (added, removed []interface{}) {
+	added, removed = []interface{}{}, []interface{}{}
+	for cursor := oldSet.mergeWith(newSet); ; {
+		key, inOld, inNew, ok := cursor.next()
+		if !ok {
+			break
+		}
+		switch {
+		case inNew && !inOld:
+			added = append(added, key)
+		case inOld && !inNew:
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,57 @@
+package treeset
+
+import "testing"
+
+func TestEditScript(t *testing.T) {
+	pairs := [][2]*Set{
+		{newIntSet(1, 2, 3, 5), newIntSet(2, 4, 5, 6)},
+		{newIntSet(1, 2), newIntSet()},
+		{newIntSet(), newIntSet(1, 2)},
+		{newIntSet(1, 2), newIntSet(1, 2)},
+	}
+	for _, pair := range pairs {
+		source, target := pair[0], pair[1]
+		script := source.EditScript(target)
+
+		applied := source.Clone()
+		for _, edit := range script {
+			switch edit.Op {
+			case EditAdd:
+				applied.Add(edit.Value)
+			case EditRemove:
+				applied.Remove(edit.Value)
+			}
+		}
+		if !equalValues(applied.Values(), target.Values()) {
+			t.Errorf("expected: %v, got: %v", target.Values(), applied.Values())
+		}
+		if len(script) != source.Diff(target).Size()+target.Diff(source).Size() {
+			t.Errorf("expected a minimal script, got: %v", script)
+		}
+	}
+
+	script := newIntSet(1, 3).EditScript(newIntSet(2, 3))
+	expected := []Edit{{Op: EditRemove, Value: 1}, {Op: EditAdd, Value: 2}}
+	for i := range expected {
+		if script[i] != expected[i] {
+			t.Errorf("expected: %v, got: %v", expected, script)
+			break
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	oldSet, newSet := newIntSet(1, 2, 4, 6), newIntSet(2, 3, 4, 7)
+	added, removed := Delta(oldSet, newSet)
+	if expected := []interface{}{3, 7}; !equalValues(added, expected) {
+		t.Errorf("added, expected: %v, got: %v", expected, added)
+	}
+	if expected := []interface{}{1, 6}; !equalValues(removed, expected) {
+		t.Errorf("removed, expected: %v, got: %v", expected, removed)
+	}
+
+	added, removed = Delta(newSet, newSet.Snapshot())
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("expected an empty delta, got: %v %v", added, removed)
+	}
+}
@@ -0,0 +1,76 @@
+package treeset
+
+// Enumerable helpers in the style of gods' EnumerableWithIndex. Callbacks see
+// items in ascending order along with their 0-based index, and run straight
+// off the tree without copying the items into a slice first.
+// Callbacks must not modify the set.
+
+// Calls the given function once for each element, passing that element's index and value.
+func (set *Set) Each(f func(index int, value interface{})) {
+	index := 0
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		f(index, node.Key)
+		index++
+	}
+}
+
+// Same as Each, but in descending order; index 0 is the largest element.
+func (set *Set) EachReverse(f func(index int, value interface{})) {
+	index := 0
+	for node := rightmost(set.tree); node != nil; node = predecessor(node) {
+		f(index, node.Key)
+		index++
+	}
+}
+
+// Invokes the given function once for each element and returns a set
+// containing the values returned by the given function, ordered by the
+// receiver's comparator.
+func (set *Set) Map(f func(index int, value interface{}) interface{}) *Set {
+	newSet := set.newEmpty()
+	set.Each(func(index int, value interface{}) {
+		newSet.tree.Put(f(index, value), itemExists)
+	})
+	return newSet
+}
+
+// Returns a new set containing all elements for which the given function returns a true value.
+func (set *Set) Filter(f func(index int, value interface{}) bool) *Set {
+	newSet := set.newEmpty()
+	set.Each(func(index int, value interface{}) {
+		if f(index, value) {
+			newSet.tree.Put(value, itemExists)
+		}
+	})
+	return newSet
+}
+
+// Passes each element of the set to the given function and
+// returns true if the function ever returns true for any element.
+func (set *Set) Any(f func(index int, value interface{}) bool) bool {
+	index, _ := set.Find(f)
+	return index != -1
+}
+
+// Passes each element of the set to the given function and
+// returns true if the function returns true for all elements.
+func (set *Set) All(f func(index int, value interface{}) bool) bool {
+	index, _ := set.Find(func(index int, value interface{}) bool {
+		return !f(index, value)
+	})
+	return index == -1
+}
+
+// Passes each element of the set to the given function and returns
+// the first (index,value) for which the function is true or -1,nil otherwise
+// if no element matches the criteria.
+func (set *Set) Find(f func(index int, value interface{}) bool) (int, interface{}) {
+	index := 0
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		if f(index, node.Key) {
+			return index, node.Key
+		}
+		index++
+	}
+	return -1, nil
+}
@@ -0,0 +1,48 @@
+package treeset
+
+import "testing"
+
+func TestEnumerable(t *testing.T) {
+	set := newIntSet(3, 1, 2, 4)
+
+	var indexes, values []interface{}
+	set.Each(func(index int, value interface{}) {
+		indexes = append(indexes, index)
+		values = append(values, value)
+	})
+	if !equalValues(indexes, []interface{}{0, 1, 2, 3}) || !equalValues(values, []interface{}{1, 2, 3, 4}) {
+		t.Errorf("Each, expected: %v, got: %v %v", set.Values(), indexes, values)
+	}
+
+	mapped := set.Map(func(index int, value interface{}) interface{} {
+		return -value.(int)
+	})
+	if expected := []interface{}{-4, -3, -2, -1}; !equalValues(mapped.Values(), expected) {
+		t.Errorf("Map, expected: %v, got: %v", expected, mapped.Values())
+	}
+
+	even := set.Filter(func(index int, value interface{}) bool {
+		return value.(int)%2 == 0
+	})
+	if expected := []interface{}{2, 4}; !equalValues(even.Values(), expected) {
+		t.Errorf("Filter, expected: %v, got: %v", expected, even.Values())
+	}
+
+	greaterThan := func(n int) func(index int, value interface{}) bool {
+		return func(index int, value interface{}) bool {
+			return value.(int) > n
+		}
+	}
+	if !set.Any(greaterThan(3)) || set.Any(greaterThan(4)) {
+		t.Errorf("Any, unexpected result for: %v", set.Values())
+	}
+	if !set.All(greaterThan(0)) || set.All(greaterThan(1)) {
+		t.Errorf("All, unexpected result for: %v", set.Values())
+	}
+	if index, value := set.Find(greaterThan(2)); index != 2 || value != 3 {
+		t.Errorf("Find, expected: %v %v, got: %v %v", 2, 3, index, value)
+	}
+	if index, value := set.Find(greaterThan(4)); index != -1 || value != nil {
+		t.Errorf("Find, expected: %v %v, got: %v %v", -1, nil, index, value)
+	}
+}
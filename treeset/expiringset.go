@@ -0,0 +1,87 @@
+package treeset
+
+import (
+	"time"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// ExpiringSet is an ordered set where every item carries a deadline after
+// which it is gone. Expired items are purged lazily by every method, so
+// "recently seen" sets need no background sweeper; ExpireNow purges
+// explicitly, e.g. to release memory of an idle set.
+// Purging costs O(log n) per expired item thanks to a deadline-ordered index.
+// Structure is not thread safe.
+type ExpiringSet struct {
+	items *TTLSet // stamped with deadlines instead of insertion times
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// Instantiates a new empty expiring set with the custom comparator, where
+// Add gives items a deadline ttl from now.
+func NewExpiringSetWith(comparator utils.Comparator, ttl time.Duration) *ExpiringSet {
+	return &ExpiringSet{items: NewTTLSetWith(comparator), ttl: ttl, now: time.Now}
+}
+
+// Adds the items (one or more) to the set, expiring ttl from now.
+// Re-adding an item that is already present extends its deadline.
+func (es *ExpiringSet) Add(items ...interface{}) {
+	es.AddUntil(es.now().Add(es.ttl), items...)
+}
+
+// Adds the items (one or more) to the set, expiring at deadline.
+// Re-adding an item that is already present replaces its deadline.
+func (es *ExpiringSet) AddUntil(deadline time.Time, items ...interface{}) {
+	es.purge()
+	es.items.AddAt(deadline, items...)
+}
+
+// Removes the items (one or more) from the set.
+func (es *ExpiringSet) Remove(items ...interface{}) {
+	es.items.Remove(items...)
+}
+
+// Removes and returns the items whose deadline has passed, soonest first.
+func (es *ExpiringSet) ExpireNow() []interface{} {
+	return es.items.Expire(es.now())
+}
+
+func (es *ExpiringSet) purge() {
+	es.items.Expire(es.now())
+}
+
+// Returns the deadline of item and whether it is present in the set.
+func (es *ExpiringSet) Deadline(item interface{}) (time.Time, bool) {
+	es.purge()
+	return es.items.InsertedAt(item)
+}
+
+// Check wether items (one or more) are present and not expired.
+func (es *ExpiringSet) Contains(items ...interface{}) bool {
+	es.purge()
+	return es.items.Contains(items...)
+}
+
+// Returns true if set does not contain any unexpired elements.
+func (es *ExpiringSet) Empty() bool {
+	es.purge()
+	return es.items.Empty()
+}
+
+// Returns number of unexpired elements within the set.
+func (es *ExpiringSet) Size() int {
+	es.purge()
+	return es.items.Size()
+}
+
+// Clears all values in the set.
+func (es *ExpiringSet) Clear() {
+	es.items.Clear()
+}
+
+// Returns all unexpired items in the set in comparator order.
+func (es *ExpiringSet) Values() []interface{} {
+	es.purge()
+	return es.items.Values()
+}
@@ -0,0 +1,54 @@
+package treeset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestExpiringSetLazyPurge(t *testing.T) {
+	now := time.Unix(1473000000, 0)
+	set := NewExpiringSetWith(utils.IntComparator, time.Minute)
+	set.now = func() time.Time { return now }
+
+	set.Add(1, 2)
+	now = now.Add(30 * time.Second)
+	set.Add(3)
+	set.AddUntil(now.Add(time.Hour), 4)
+
+	now = now.Add(45 * time.Second)
+	if set.Contains(1) || set.Contains(2) || !set.Contains(3, 4) {
+		t.Errorf("expected only 3 and 4 left, got: %v", set.Values())
+	}
+	if set.Size() != 2 {
+		t.Errorf("expected: %v, got: %v", 2, set.Size())
+	}
+
+	// Re-adding extends the deadline.
+	set.Add(3)
+	now = now.Add(45 * time.Second)
+	if !set.Contains(3) {
+		t.Errorf("expected refreshed item to be kept, got: %v", set.Values())
+	}
+	if deadline, _ := set.Deadline(4); !deadline.Equal(time.Unix(1473000030, 0).Add(time.Hour)) {
+		t.Errorf("unexpected deadline: %v", deadline)
+	}
+}
+
+func TestExpiringSetExpireNow(t *testing.T) {
+	now := time.Unix(1473000000, 0)
+	set := NewExpiringSetWith(utils.IntComparator, time.Minute)
+	set.now = func() time.Time { return now }
+	set.AddUntil(now.Add(2*time.Second), 5)
+	set.AddUntil(now.Add(time.Second), 7)
+	set.AddUntil(now.Add(time.Hour), 6)
+
+	now = now.Add(3 * time.Second)
+	if expired := set.ExpireNow(); !equalValues(expired, []interface{}{7, 5}) {
+		t.Errorf("expected: %v, got: %v", []int{7, 5}, expired)
+	}
+	if expired := set.ExpireNow(); len(expired) != 0 {
+		t.Errorf("expected nothing left to expire, got: %v", expired)
+	}
+}
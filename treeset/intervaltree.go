@@ -0,0 +1,259 @@
+package treeset
+
+import (
+	"math"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// IntervalTree indexes items by a half-open interval [start, end) of int64
+// bounds, e.g. the publish_at .. expire_at visibility window of a post as
+// Unix timestamps, and finds the items whose interval contains a point or
+// overlaps a range in O(log n + k) for k results.
+// Each item has one interval; adding an item again moves it.
+// It is an AVL tree ordered by start, with every node caching the largest
+// end in its subtree so that subtrees ending too early can be skipped.
+// Structure is not thread safe.
+type IntervalTree struct {
+	root      *inode
+	items     *Set // item -> Interval
+	itemOrder utils.Comparator
+}
+
+// Interval is the half-open range [Start, End) of an item. An End of
+// math.MaxInt64 stands for no end, e.g. a post that never expires, so At
+// finds such intervals at math.MaxInt64 too.
+type Interval struct {
+	Start, End int64
+}
+
+type inode struct {
+	item        interface{}
+	interval    Interval
+	maxEnd      int64
+	left, right *inode
+	height      int
+}
+
+// Instantiates a new empty interval tree whose items are told apart by the
+// custom comparator.
+func NewIntervalTreeWith(comparator utils.Comparator) *IntervalTree {
+	return &IntervalTree{items: NewWith(comparator), itemOrder: comparator}
+}
+
+// Adds item with the interval [start, end), replacing its previous interval
+// if it was present. Empty intervals (end <= start) are never matched.
+func (it *IntervalTree) Add(item interface{}, start, end int64) {
+	it.Remove(item)
+	interval := Interval{Start: start, End: end}
+	it.items.tree.Put(item, interval)
+	it.root = it.insert(it.root, item, interval)
+}
+
+// Removes the items (one or more) from the tree.
+func (it *IntervalTree) Remove(items ...interface{}) {
+	for _, item := range items {
+		interval, found := it.items.tree.Get(item)
+		if !found {
+			continue
+		}
+		it.items.tree.Remove(item)
+		it.root = it.remove(it.root, item, interval.(Interval))
+	}
+}
+
+// Returns the interval of item, and whether it is present.
+func (it *IntervalTree) Get(item interface{}) (Interval, bool) {
+	interval, found := it.items.tree.Get(item)
+	if !found {
+		return Interval{}, false
+	}
+	return interval.(Interval), true
+}
+
+// Returns the items whose interval contains point, e.g. the items live at
+// a time, ordered by interval start.
+func (it *IntervalTree) At(point int64) []interface{} {
+	if point < math.MaxInt64 {
+		return it.Overlapping(point, point+1)
+	}
+	// point+1 would overflow: match the unbounded intervals instead.
+	items := []interface{}{}
+	var walk func(node *inode)
+	walk = func(node *inode) {
+		if node == nil || node.maxEnd < math.MaxInt64 {
+			return
+		}
+		walk(node.left)
+		if node.interval.End == math.MaxInt64 && node.interval.Start < node.interval.End {
+			items = append(items, node.item)
+		}
+		walk(node.right)
+	}
+	walk(it.root)
+	return items
+}
+
+// Returns the items whose interval overlaps [start, end), ordered by
+// interval start.
+func (it *IntervalTree) Overlapping(start, end int64) []interface{} {
+	items := []interface{}{}
+	var walk func(node *inode)
+	walk = func(node *inode) {
+		if node == nil || node.maxEnd <= start {
+			return
+		}
+		walk(node.left)
+		if node.interval.Start >= end {
+			return
+		}
+		if node.interval.End > start && node.interval.Start < node.interval.End {
+			items = append(items, node.item)
+		}
+		walk(node.right)
+	}
+	if start < end {
+		walk(it.root)
+	}
+	return items
+}
+
+// Check wether items (one or more) are present in the tree.
+// Returns true if no arguments are passed at all.
+func (it *IntervalTree) Contains(items ...interface{}) bool {
+	return it.items.Contains(items...)
+}
+
+// Returns true if tree does not contain any elements.
+func (it *IntervalTree) Empty() bool {
+	return it.root == nil
+}
+
+// Returns number of elements within the tree.
+func (it *IntervalTree) Size() int {
+	return it.items.Size()
+}
+
+// Clears all values in the tree.
+func (it *IntervalTree) Clear() {
+	it.root = nil
+	it.items.Clear()
+}
+
+// Returns all items ordered by interval start.
+func (it *IntervalTree) Values() []interface{} {
+	values := make([]interface{}, 0, it.Size())
+	var walk func(node *inode)
+	walk = func(node *inode) {
+		if node != nil {
+			walk(node.left)
+			values = append(values, node.item)
+			walk(node.right)
+		}
+	}
+	walk(it.root)
+	return values
+}
+
+// compare orders nodes by interval start, then by item.
+func (it *IntervalTree) compare(item interface{}, interval Interval, node *inode) int {
+	switch {
+	case interval.Start < node.interval.Start:
+		return -1
+	case interval.Start > node.interval.Start:
+		return 1
+	default:
+		return it.itemOrder(item, node.item)
+	}
+}
+
+func (it *IntervalTree) insert(node *inode, item interface{}, interval Interval) *inode {
+	if node == nil {
+		return &inode{item: item, interval: interval, maxEnd: interval.End, height: 1}
+	}
+	if it.compare(item, interval, node) < 0 {
+		node.left = it.insert(node.left, item, interval)
+	} else {
+		node.right = it.insert(node.right, item, interval)
+	}
+	return ibalance(node)
+}
+
+func (it *IntervalTree) remove(node *inode, item interface{}, interval Interval) *inode {
+	if node == nil {
+		return nil
+	}
+	switch compare := it.compare(item, interval, node); {
+	case compare < 0:
+		node.left = it.remove(node.left, item, interval)
+	case compare > 0:
+		node.right = it.remove(node.right, item, interval)
+	default:
+		if node.left == nil {
+			return node.right
+		}
+		if node.right == nil {
+			return node.left
+		}
+		min := node.right
+		for min.left != nil {
+			min = min.left
+		}
+		node.item, node.interval = min.item, min.interval
+		node.right = it.remove(node.right, min.item, min.interval)
+	}
+	return ibalance(node)
+}
+
+func iheight(node *inode) int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
+
+func (node *inode) update() {
+	node.height = 1 + iheight(node.left)
+	if h := iheight(node.right); h >= node.height {
+		node.height = h + 1
+	}
+	node.maxEnd = node.interval.End
+	for _, child := range []*inode{node.left, node.right} {
+		if child != nil && child.maxEnd > node.maxEnd {
+			node.maxEnd = child.maxEnd
+		}
+	}
+}
+
+func irotateLeft(node *inode) *inode {
+	right := node.right
+	node.right, right.left = right.left, node
+	node.update()
+	right.update()
+	return right
+}
+
+func irotateRight(node *inode) *inode {
+	left := node.left
+	node.left, left.right = left.right, node
+	node.update()
+	left.update()
+	return left
+}
+
+func ibalance(node *inode) *inode {
+	node.update()
+	switch balance := iheight(node.left) - iheight(node.right); {
+	case balance > 1:
+		if iheight(node.left.left) < iheight(node.left.right) {
+			node.left = irotateLeft(node.left)
+		}
+		return irotateRight(node)
+	case balance < -1:
+		if iheight(node.right.right) < iheight(node.right.left) {
+			node.right = irotateRight(node.right)
+		}
+		return irotateLeft(node)
+	}
+	return node
+}
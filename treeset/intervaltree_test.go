@@ -0,0 +1,119 @@
+package treeset
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestIntervalTree(t *testing.T) {
+	tree := NewIntervalTreeWith(utils.StringComparator)
+	tree.Add("scheduled", 100, 200)
+	tree.Add("expiring", 0, 150)
+	tree.Add("forever", 50, 1<<62)
+	tree.Add("empty", 120, 120)
+
+	tests := []struct {
+		point    int64
+		expected []interface{}
+	}{
+		{-1, []interface{}{}},
+		{0, []interface{}{"expiring"}},
+		{100, []interface{}{"expiring", "forever", "scheduled"}},
+		{150, []interface{}{"forever", "scheduled"}},
+		{200, []interface{}{"forever"}},
+	}
+	for _, test := range tests {
+		if actual := tree.At(test.point); !equalValues(actual, test.expected) {
+			t.Errorf("At(%v), expected: %v, got: %v", test.point, test.expected, actual)
+		}
+	}
+	if actual := tree.Overlapping(150, 160); !equalValues(actual, []interface{}{"forever", "scheduled"}) {
+		t.Errorf("expected: %v, got: %v", []interface{}{"forever", "scheduled"}, actual)
+	}
+
+	tree.Add("scheduled", 300, 400)
+	if interval, _ := tree.Get("scheduled"); interval != (Interval{300, 400}) {
+		t.Errorf("expected: %v, got: %v", Interval{300, 400}, interval)
+	}
+	if actual := tree.At(150); !equalValues(actual, []interface{}{"forever"}) {
+		t.Errorf("expected: %v, got: %v", []interface{}{"forever"}, actual)
+	}
+	tree.Remove("forever", "missing")
+	if tree.Size() != 3 || tree.Contains("forever") {
+		t.Errorf("expected: %v, got: %v", 3, tree.Size())
+	}
+	tree.Clear()
+	if !tree.Empty() || len(tree.At(350)) != 0 {
+		t.Errorf("expected empty tree")
+	}
+}
+
+func TestIntervalTreeAtMaxInt64(t *testing.T) {
+	tree := NewIntervalTreeWith(utils.StringComparator)
+	tree.Add("unbounded", 60, math.MaxInt64)
+	tree.Add("bounded", 50, math.MaxInt64-1)
+	tree.Add("empty", math.MaxInt64, math.MaxInt64)
+	for point, expected := range map[int64][]interface{}{
+		math.MaxInt64 - 2: {"bounded", "unbounded"},
+		math.MaxInt64 - 1: {"unbounded"},
+		math.MaxInt64:     {"unbounded"},
+	} {
+		if actual := tree.At(point); !equalValues(actual, expected) {
+			t.Errorf("At(%v), expected: %v, got: %v", point, expected, actual)
+		}
+	}
+}
+
+func TestIntervalTreeMatchesScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := NewIntervalTreeWith(utils.IntComparator)
+	intervals := make(map[int]Interval)
+	for i := 0; i < 5000; i++ {
+		item := rnd.Intn(500)
+		if rnd.Intn(4) == 0 {
+			tree.Remove(item)
+			delete(intervals, item)
+			continue
+		}
+		start := rnd.Int63n(1000)
+		end := start + rnd.Int63n(100)
+		tree.Add(item, start, end)
+		intervals[item] = Interval{start, end}
+	}
+	checkIntervalTree(t, tree.root)
+	for point := int64(-10); point < 1110; point += 7 {
+		expected := 0
+		for _, interval := range intervals {
+			if interval.Start <= point && point < interval.End {
+				expected++
+			}
+		}
+		if actual := tree.At(point); len(actual) != expected {
+			t.Fatalf("At(%v), expected %d items, got: %d", point, expected, len(actual))
+		}
+	}
+}
+
+// checkIntervalTree verifies the AVL balance and cached maxEnd of every node.
+func checkIntervalTree(t *testing.T, node *inode) {
+	if node == nil {
+		return
+	}
+	checkIntervalTree(t, node.left)
+	checkIntervalTree(t, node.right)
+	if balance := iheight(node.left) - iheight(node.right); balance < -1 || balance > 1 {
+		t.Fatalf("unbalanced node %v", node.item)
+	}
+	maxEnd := node.interval.End
+	for _, child := range []*inode{node.left, node.right} {
+		if child != nil && child.maxEnd > maxEnd {
+			maxEnd = child.maxEnd
+		}
+	}
+	if node.maxEnd != maxEnd {
+		t.Fatalf("node %v, expected maxEnd: %v, got: %v", node.item, maxEnd, node.maxEnd)
+	}
+}
@@ -0,0 +1,108 @@
+package treeset
+
+type position byte
+
+const (
+	begin, between, end position = 0, 1, 2
+)
+
+// Iterator is a stateful cursor over the items of a set in ascending order.
+// It walks the tree in place, without materializing Values().
+// Modifying the set invalidates the iterator.
+type Iterator struct {
+	set      *Set
+	node     *rbNode
+	position position
+}
+
+// Returns a stateful iterator positioned before the first item.
+func (set *Set) Iterator() Iterator {
+	return Iterator{set: set, position: begin}
+}
+
+// Returns a stateful iterator positioned past the last item, so that
+// calling Prev walks the set in descending order.
+func (set *Set) ReverseIterator() Iterator {
+	return Iterator{set: set, position: end}
+}
+
+// Moves the iterator to the next item and returns true if there was one.
+// From the initial position it moves to the first item.
+func (it *Iterator) Next() bool {
+	switch it.position {
+	case begin:
+		it.node = leftmost(it.set.tree)
+	case between:
+		it.node = successor(it.node)
+	case end:
+		return false
+	}
+	if it.node == nil {
+		it.End()
+		return false
+	}
+	it.position = between
+	return true
+}
+
+// Moves the iterator to the previous item and returns true if there was one.
+// From the end position it moves to the last item.
+func (it *Iterator) Prev() bool {
+	switch it.position {
+	case begin:
+		return false
+	case between:
+		it.node = predecessor(it.node)
+	case end:
+		it.node = rightmost(it.set.tree)
+	}
+	if it.node == nil {
+		it.Begin()
+		return false
+	}
+	it.position = between
+	return true
+}
+
+// Returns the current item. Only valid after Next, Prev, First, Last or Seek
+// returned true.
+func (it *Iterator) Value() interface{} {
+	return it.node.Key
+}
+
+// Resets the iterator to its initial position, before the first item.
+func (it *Iterator) Begin() {
+	it.node = nil
+	it.position = begin
+}
+
+// Moves the iterator past the last item.
+func (it *Iterator) End() {
+	it.node = nil
+	it.position = end
+}
+
+// Moves the iterator to the first item and returns true if there was one.
+func (it *Iterator) First() bool {
+	it.Begin()
+	return it.Next()
+}
+
+// Moves the iterator to the last item and returns true if there was one.
+func (it *Iterator) Last() bool {
+	it.End()
+	return it.Prev()
+}
+
+// Moves the iterator to the first item greater than or equal to value and
+// returns true if there was one, so that a walk can resume from a cursor.
+// Otherwise the iterator ends up past the last item.
+func (it *Iterator) Seek(value interface{}) bool {
+	node := it.set.ceiling(value)
+	if node == nil {
+		it.End()
+		return false
+	}
+	it.node, it.position = node, between
+	return true
+}
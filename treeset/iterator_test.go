@@ -0,0 +1,63 @@
+package treeset
+
+import "testing"
+
+func TestIterator(t *testing.T) {
+	set := newIntSet(5, 1, 3)
+	it := set.Iterator()
+
+	var forward []interface{}
+	for it.Next() {
+		forward = append(forward, it.Value())
+	}
+	if !equalValues(forward, []interface{}{1, 3, 5}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 3, 5}, forward)
+	}
+	if it.Next() {
+		t.Errorf("expected iterator to stay at the end")
+	}
+
+	var backward []interface{}
+	for it.Prev() {
+		backward = append(backward, it.Value())
+	}
+	if !equalValues(backward, []interface{}{5, 3, 1}) {
+		t.Errorf("expected: %v, got: %v", []int{5, 3, 1}, backward)
+	}
+
+	if !it.Last() || it.Value() != 5 {
+		t.Errorf("expected Last to move to 5")
+	}
+	if !it.First() || it.Value() != 1 {
+		t.Errorf("expected First to move to 1")
+	}
+
+	empty := NewWithIntComparator().Iterator()
+	if empty.Next() || empty.Prev() || empty.First() || empty.Last() {
+		t.Errorf("expected nothing to iterate on an empty set")
+	}
+}
+
+func TestIteratorSeek(t *testing.T) {
+	set := newIntSet(10, 20, 30)
+	it := set.Iterator()
+
+	if !it.Seek(20) || it.Value() != 20 {
+		t.Errorf("expected Seek(20) to land on 20")
+	}
+	if !it.Seek(15) || it.Value() != 20 {
+		t.Errorf("expected Seek(15) to land on 20")
+	}
+	if !it.Next() || it.Value() != 30 {
+		t.Errorf("expected to resume after the seek position")
+	}
+	if !it.Seek(5) || it.Value() != 10 || it.Prev() {
+		t.Errorf("expected Seek(5) to land on the first item")
+	}
+	if it.Seek(35) {
+		t.Errorf("expected Seek past the last item to fail")
+	}
+	if !it.Prev() || it.Value() != 30 {
+		t.Errorf("expected Prev after a failed Seek to land on the last item")
+	}
+}
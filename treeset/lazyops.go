@@ -0,0 +1,59 @@
+package treeset
+
+// OpIterator produces the result of a set operation on demand, in ascending
+// order, by walking both operand trees in step. Reading the first page of a
+// union or intersection costs only as many steps as the page needs, and no
+// result set is allocated. Modifying either operand invalidates the iterator.
+type OpIterator struct {
+	cursor *mergeCursor
+	keep   func(inSet, inOther bool) bool
+	value  interface{}
+}
+
+// Returns an iterator over the items of set or otherSet.
+func (set *Set) UnionIter(otherSet *Set) *OpIterator {
+	return set.opIterator(otherSet, func(inSet, inOther bool) bool { return true })
+}
+
+// Returns an iterator over the items present in both set and otherSet.
+func (set *Set) InterIter(otherSet *Set) *OpIterator {
+	return set.opIterator(otherSet, func(inSet, inOther bool) bool { return inSet && inOther })
+}
+
+// Returns an iterator over the items of set not present in otherSet.
+func (set *Set) DiffIter(otherSet *Set) *OpIterator {
+	return set.opIterator(otherSet, func(inSet, inOther bool) bool { return inSet && !inOther })
+}
+
+func (set *Set) opIterator(otherSet *Set, keep func(inSet, inOther bool) bool) *OpIterator {
+	return &OpIterator{cursor: set.mergeWith(otherSet), keep: keep}
+}
+
+// Moves the iterator to the next result and returns true if there was one.
+func (it *OpIterator) Next() bool {
+	for {
+		key, inSet, inOther, ok := it.cursor.next()
+		if !ok {
+			it.value = nil
+			return false
+		}
+		if it.keep(inSet, inOther) {
+			it.value = key
+			return true
+		}
+	}
+}
+
+// Returns the current result. Only valid after Next returned true.
+func (it *OpIterator) Value() interface{} {
+	return it.value
+}
+
+// Returns up to n further results, e.g. the first page of an intersection.
+func (it *OpIterator) Take(n int) []interface{} {
+	values := []interface{}{}
+	for len(values) < n && it.Next() {
+		values = append(values, it.value)
+	}
+	return values
+}
@@ -0,0 +1,40 @@
+package treeset
+
+import "testing"
+
+func TestOpIterators(t *testing.T) {
+	a, b := newIntSet(1, 2, 3, 5, 8), newIntSet(2, 3, 4, 8, 9)
+	tests := []struct {
+		name     string
+		it       *OpIterator
+		expected []interface{}
+	}{
+		{"UnionIter", a.UnionIter(b), []interface{}{1, 2, 3, 4, 5, 8, 9}},
+		{"InterIter", a.InterIter(b), []interface{}{2, 3, 8}},
+		{"DiffIter", a.DiffIter(b), []interface{}{1, 5}},
+		{"InterIter empty", a.InterIter(newIntSet()), []interface{}{}},
+	}
+	for _, test := range tests {
+		values := []interface{}{}
+		for test.it.Next() {
+			values = append(values, test.it.Value())
+		}
+		if !equalValues(values, test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, values)
+		}
+		if test.it.Next() {
+			t.Errorf("%s, expected an exhausted iterator to stay exhausted", test.name)
+		}
+	}
+}
+
+func TestOpIteratorTake(t *testing.T) {
+	a, b := benchmarkSet(1000), benchmarkSet(500)
+	it := a.InterIter(b)
+	if page, expected := it.Take(3), []interface{}{0, 1, 2}; !equalValues(page, expected) {
+		t.Errorf("expected: %v, got: %v", expected, page)
+	}
+	if page, expected := it.Take(2), []interface{}{3, 4}; !equalValues(page, expected) {
+		t.Errorf("expected: %v, got: %v", expected, page)
+	}
+}
@@ -0,0 +1,94 @@
+package treeset
+
+import (
+	"container/list"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// LRUSet is an ordered set bounded to a maximum size that evicts the least
+// recently used item when an Add overflows it, which suits membership caches.
+// Besides the tree it keeps a recency list with one entry per item, so each
+// item costs an extra list element on top of its tree node.
+// Structure is not thread safe.
+type LRUSet struct {
+	set     *Set       // item -> *list.Element in recency
+	recency *list.List // most recently used first
+	maxSize int
+}
+
+// Instantiates a new empty LRU set with the custom comparator, holding at
+// most maxSize items. A maxSize <= 0 means unbounded.
+func NewLRUSetWith(comparator utils.Comparator, maxSize int) *LRUSet {
+	return &LRUSet{set: NewWith(comparator), recency: list.New(), maxSize: maxSize}
+}
+
+// Adds the items (one or more) to the set, marking them as most recently used.
+// Evicts least recently used items while the set is over its maximum size.
+func (ls *LRUSet) Add(items ...interface{}) {
+	for _, item := range items {
+		if node := ls.set.lookup(item); node != nil {
+			ls.recency.MoveToFront(node.Value.(*list.Element))
+			continue
+		}
+		ls.set.tree.Put(item, ls.recency.PushFront(item))
+	}
+	for ls.maxSize > 0 && ls.set.Size() > ls.maxSize {
+		oldest := ls.recency.Back()
+		ls.recency.Remove(oldest)
+		ls.set.tree.Remove(oldest.Value)
+	}
+}
+
+// Check wether item is present in the set and, if so, marks it as most
+// recently used so that it is spared from the next eviction.
+func (ls *LRUSet) Touch(item interface{}) bool {
+	node := ls.set.lookup(item)
+	if node == nil {
+		return false
+	}
+	ls.recency.MoveToFront(node.Value.(*list.Element))
+	return true
+}
+
+// Removes the items (one or more) from the set.
+func (ls *LRUSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if node := ls.set.lookup(item); node != nil {
+			ls.recency.Remove(node.Value.(*list.Element))
+			ls.set.tree.Remove(node.Key)
+		}
+	}
+}
+
+// Check wether items (one or more) are present in the set.
+// Unlike Touch it does not update recency.
+func (ls *LRUSet) Contains(items ...interface{}) bool {
+	return ls.set.Contains(items...)
+}
+
+// Returns the maximum number of items kept in the set.
+func (ls *LRUSet) MaxSize() int {
+	return ls.maxSize
+}
+
+// Returns true if set does not contain any elements.
+func (ls *LRUSet) Empty() bool {
+	return ls.set.Empty()
+}
+
+// Returns number of elements within the set.
+func (ls *LRUSet) Size() int {
+	return ls.set.Size()
+}
+
+// Clears all values in the set.
+func (ls *LRUSet) Clear() {
+	ls.set.Clear()
+	ls.recency.Init()
+}
+
+// Returns all items in the set in comparator order.
+func (ls *LRUSet) Values() []interface{} {
+	return ls.set.Values()
+}
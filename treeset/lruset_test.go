@@ -0,0 +1,39 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestLRUSetTouchSparesFromEviction(t *testing.T) {
+	set := NewLRUSetWith(utils.IntComparator, 3)
+	set.Add(1, 2, 3)
+	if !set.Touch(1) {
+		t.Errorf("expected touched item to be present")
+	}
+	set.Add(4)
+
+	if !equalValues(set.Values(), []interface{}{1, 3, 4}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 3, 4}, set.Values())
+	}
+	if set.Touch(2) {
+		t.Errorf("expected evicted item to be absent")
+	}
+}
+
+func TestLRUSetContainsDoesNotTouch(t *testing.T) {
+	set := NewLRUSetWith(utils.IntComparator, 2)
+	set.Add(1, 2)
+	set.Contains(1)
+	set.Add(3)
+	if !equalValues(set.Values(), []interface{}{2, 3}) {
+		t.Errorf("expected: %v, got: %v", []int{2, 3}, set.Values())
+	}
+
+	set.Remove(2)
+	set.Add(4, 5)
+	if !equalValues(set.Values(), []interface{}{4, 5}) {
+		t.Errorf("expected: %v, got: %v", []int{4, 5}, set.Values())
+	}
+}
@@ -0,0 +1,291 @@
+package treeset
+
+import (
+	"container/heap"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// sliceCursor is the read position within one of the sorted input slices.
+type sliceCursor struct {
+	values []interface{}
+	pos    int
+}
+
+type sliceCursorHeap struct {
+	cursors    []*sliceCursor
+	comparator utils.Comparator
+}
+
+func (h *sliceCursorHeap) Len() int { return len(h.cursors) }
+func (h *sliceCursorHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	return h.comparator(a.values[a.pos], b.values[b.pos]) < 0
+}
+func (h *sliceCursorHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+func (h *sliceCursorHeap) Push(x interface{}) {
+	h.cursors = append(h.cursors, x.(*sliceCursor))
+}
+func (h *sliceCursorHeap) Pop() interface{} {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
+
+// Instantiates a new set from several slices that are each already sorted
+// by comparator, e.g. sorted shards or files. The slices are k-way merged in
+// O(total log k) comparisons and items repeated across them are kept once.
+// Results are undefined if a slice is not sorted.
+func NewFromSortedSlices(comparator utils.Comparator, slices ...[]interface{}) *Set {
+	set := NewWith(comparator)
+	set.tree.loadSorted(mergeSorted(comparator, slices), itemExists)
+	return set
+}
+
+// mergeSorted k-way merges slices sorted by comparator into one sorted slice,
+// keeping items repeated across them once.
+func mergeSorted(comparator utils.Comparator, slices [][]interface{}) []interface{} {
+	h := &sliceCursorHeap{comparator: comparator}
+	for _, values := range slices {
+		if len(values) > 0 {
+			h.cursors = append(h.cursors, &sliceCursor{values: values})
+		}
+	}
+	heap.Init(h)
+
+	keys := []interface{}{}
+	for h.Len() > 0 {
+		cursor := h.cursors[0]
+		value := cursor.values[cursor.pos]
+		if len(keys) == 0 || comparator(keys[len(keys)-1], value) != 0 {
+			keys = append(keys, value)
+		}
+		cursor.pos++
+		if cursor.pos < len(cursor.values) {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return keys
+}
+
+// mergeCursor walks two trees ordered by the same comparator in step,
+// yielding every distinct key once along with which trees hold it.
+type mergeCursor struct {
+	comparator utils.Comparator
+	i, j       *rbNode
+}
+
+func (set *Set) mergeWith(otherSet *Set) *mergeCursor {
+	return &mergeCursor{comparator: set.comparator, i: leftmost(set.tree), j: leftmost(otherSet.tree)}
+}
+
+func (cursor *mergeCursor) next() (key interface{}, inLeft, inRight, ok bool) {
+	switch {
+	case cursor.i == nil && cursor.j == nil:
+		return nil, false, false, false
+	case cursor.j == nil:
+		key, inLeft = cursor.i.Key, true
+	case cursor.i == nil:
+		key, inRight = cursor.j.Key, true
+	default:
+		compare := cursor.comparator(cursor.i.Key, cursor.j.Key)
+		switch {
+		case compare == 0:
+			key, inLeft, inRight = cursor.i.Key, true, true
+		case compare < 0:
+			key, inLeft = cursor.i.Key, true
+		case compare > 0:
+			key, inRight = cursor.j.Key, true
+		}
+	}
+	if inLeft {
+		cursor.i = successor(cursor.i)
+	}
+	if inRight {
+		cursor.j = successor(cursor.j)
+	}
+	return key, inLeft, inRight, true
+}
+
+// merged returns a new set holding the keys of the merge of set and otherSet
+// that keep accepts. A first pass counts them so the second can build the
+// result tree directly in O(n + m).
+func (set *Set) merged(otherSet *Set, keep func(inSet, inOther bool) bool) *Set {
+	return set.mergedVisiting(otherSet, keep, nil)
+}
+
+// mergedVisiting is merged, calling visit, if not nil, with the keys of the
+// merge in ascending order during the pass that builds the result. That pass
+// stops at the last kept key, so later keys are not visited.
+func (set *Set) mergedVisiting(otherSet *Set, keep func(inSet, inOther bool) bool, visit func(key interface{}, inSet, inOther bool)) *Set {
+	n := 0
+	for cursor := set.mergeWith(otherSet); ; {
+		_, inSet, inOther, ok := cursor.next()
+		if !ok {
+			break
+		}
+		if keep(inSet, inOther) {
+			n++
+		}
+	}
+
+	result := set.newEmpty()
+	cursor := set.mergeWith(otherSet)
+	result.tree.load(n, func() (interface{}, bool) {
+		for {
+			key, inSet, inOther, _ := cursor.next()
+			if visit != nil {
+				visit(key, inSet, inOther)
+			}
+			if keep(inSet, inOther) {
+				return key, true
+			}
+		}
+	}, itemExists)
+	return result
+}
+
+// channelHead is the next unconsumed value of one of the sorted channels.
+type channelHead struct {
+	value  interface{}
+	source <-chan interface{}
+}
+
+type channelHeadHeap struct {
+	heads      []channelHead
+	comparator utils.Comparator
+}
+
+func (h *channelHeadHeap) Len() int { return len(h.heads) }
+func (h *channelHeadHeap) Less(i, j int) bool {
+	return h.comparator(h.heads[i].value, h.heads[j].value) < 0
+}
+func (h *channelHeadHeap) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+func (h *channelHeadHeap) Push(x interface{}) {
+	h.heads = append(h.heads, x.(channelHead))
+}
+func (h *channelHeadHeap) Pop() interface{} {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
+
+// K-way merges several channels, each delivering values sorted by
+// comparator, and returns the first n distinct values in sorted order.
+// Every source is read one value ahead of the merge; once n values are
+// collected no further values are read, and the sources are left as they are.
+// Returns fewer than n values if the sources close first.
+func MergeTopN(comparator utils.Comparator, n int, sources ...<-chan interface{}) []interface{} {
+	result := make([]interface{}, 0)
+	if n <= 0 {
+		return result
+	}
+	h := &channelHeadHeap{comparator: comparator}
+	for _, source := range sources {
+		if value, ok := <-source; ok {
+			h.heads = append(h.heads, channelHead{value: value, source: source})
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 && len(result) < n {
+		head := h.heads[0]
+		if len(result) == 0 || comparator(result[len(result)-1], head.value) != 0 {
+			result = append(result, head.value)
+			if len(result) == n {
+				break
+			}
+		}
+		if value, ok := <-head.source; ok {
+			h.heads[0].value = value
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return result
+}
+
+type nodeHeap struct {
+	nodes      []*rbNode
+	comparator utils.Comparator
+}
+
+func (h *nodeHeap) Len() int { return len(h.nodes) }
+func (h *nodeHeap) Less(i, j int) bool {
+	return h.comparator(h.nodes[i].Key, h.nodes[j].Key) < 0
+}
+func (h *nodeHeap) Swap(i, j int) {
+	h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i]
+}
+func (h *nodeHeap) Push(x interface{}) {
+	h.nodes = append(h.nodes, x.(*rbNode))
+}
+func (h *nodeHeap) Pop() interface{} {
+	last := h.nodes[len(h.nodes)-1]
+	h.nodes = h.nodes[:len(h.nodes)-1]
+	return last
+}
+
+// MergingIterator streams the items of several sets in comparator order.
+// Modifying any of the sets invalidates the iterator.
+type MergingIterator struct {
+	heap    *nodeHeap
+	dedup   bool
+	value   interface{}
+	started bool
+}
+
+// Returns an iterator k-way merging sets, which must all be ordered by
+// comparator, in O(log k) per item and without copying them. With dedup,
+// items held by several sets are produced once; otherwise once per set.
+func MergeIterator(comparator utils.Comparator, dedup bool, sets ...*Set) *MergingIterator {
+	h := &nodeHeap{comparator: comparator}
+	for _, set := range sets {
+		if node := leftmost(set.tree); node != nil {
+			h.nodes = append(h.nodes, node)
+		}
+	}
+	heap.Init(h)
+	return &MergingIterator{heap: h, dedup: dedup}
+}
+
+// Moves the iterator to the next item and returns true if there was one.
+func (it *MergingIterator) Next() bool {
+	for it.heap.Len() > 0 {
+		node := it.heap.nodes[0]
+		if next := successor(node); next != nil {
+			it.heap.nodes[0] = next
+			heap.Fix(it.heap, 0)
+		} else {
+			heap.Pop(it.heap)
+		}
+		if it.dedup && it.started && it.heap.comparator(it.value, node.Key) == 0 {
+			continue
+		}
+		it.value, it.started = node.Key, true
+		return true
+	}
+	it.value = nil
+	return false
+}
+
+// Returns the current item. Only valid after Next returned true.
+func (it *MergingIterator) Value() interface{} {
+	return it.value
+}
+
+// Returns up to n further items, e.g. the first page of a merged timeline.
+func (it *MergingIterator) Take(n int) []interface{} {
+	values := []interface{}{}
+	for len(values) < n && it.Next() {
+		values = append(values, it.value)
+	}
+	return values
+}
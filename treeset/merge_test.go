@@ -0,0 +1,119 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestNewFromSortedSlices(t *testing.T) {
+	slices := [][]interface{}{
+		{1, 4, 7, 10},
+		{},
+		{2, 4, 6, 8, 10},
+		{0, 1, 2, 11},
+	}
+	set := NewFromSortedSlices(utils.IntComparator, slices...)
+
+	expected := NewWithIntComparator()
+	for _, values := range slices {
+		expected.Add(values...)
+	}
+	if !equalValues(set.Values(), expected.Values()) {
+		t.Errorf("expected: %v, got: %v", expected.Values(), set.Values())
+	}
+
+	if empty := NewFromSortedSlices(utils.IntComparator); !empty.Empty() {
+		t.Errorf("expected empty set, got: %v", empty.Values())
+	}
+}
+
+func benchmarkShards() [][]interface{} {
+	shards := make([][]interface{}, 8)
+	for i := range shards {
+		for j := 0; j < 5000; j++ {
+			shards[i] = append(shards[i], j*len(shards)+i%3)
+		}
+	}
+	return shards
+}
+
+func BenchmarkNewFromSortedSlices(b *testing.B) {
+	shards := benchmarkShards()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewFromSortedSlices(utils.IntComparator, shards...)
+	}
+}
+
+func BenchmarkAddSortedSlices(b *testing.B) {
+	shards := benchmarkShards()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set := NewWithIntComparator()
+		for _, shard := range shards {
+			set.Add(shard...)
+		}
+	}
+}
+
+func sortedChannel(values ...int) <-chan interface{} {
+	ch := make(chan interface{}, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+func TestMergeTopN(t *testing.T) {
+	merge := func(n int) []interface{} {
+		return MergeTopN(utils.IntComparator, n,
+			sortedChannel(1, 4, 4, 9),
+			sortedChannel(2, 4, 6),
+			sortedChannel(1, 3, 6, 10))
+	}
+	if got := merge(5); !equalValues(got, []interface{}{1, 2, 3, 4, 6}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2, 3, 4, 6}, got)
+	}
+	if got := merge(100); !equalValues(got, []interface{}{1, 2, 3, 4, 6, 9, 10}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2, 3, 4, 6, 9, 10}, got)
+	}
+	if got := merge(0); len(got) != 0 {
+		t.Errorf("expected nothing, got: %v", got)
+	}
+}
+
+func TestMergeTopNStopsReading(t *testing.T) {
+	source := make(chan interface{})
+	done := make(chan []interface{})
+	go func() {
+		done <- MergeTopN(utils.IntComparator, 2, source)
+	}()
+	source <- 1
+	source <- 2
+	// MergeTopN must return without waiting for a third value or close.
+	if got := <-done; !equalValues(got, []interface{}{1, 2}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2}, got)
+	}
+}
+
+func TestMergeIterator(t *testing.T) {
+	sets := []*Set{newIntSet(1, 4, 7), newIntSet(2, 4, 8), newIntSet(), newIntSet(0, 4, 9)}
+
+	it := MergeIterator(utils.IntComparator, true, sets...)
+	if page, expected := it.Take(4), []interface{}{0, 1, 2, 4}; !equalValues(page, expected) {
+		t.Errorf("expected: %v, got: %v", expected, page)
+	}
+	if rest, expected := it.Take(10), []interface{}{7, 8, 9}; !equalValues(rest, expected) {
+		t.Errorf("expected: %v, got: %v", expected, rest)
+	}
+	if it.Next() {
+		t.Errorf("expected an exhausted iterator")
+	}
+
+	all := MergeIterator(utils.IntComparator, false, sets...).Take(100)
+	if expected := []interface{}{0, 1, 2, 4, 4, 4, 7, 8, 9}; !equalValues(all, expected) {
+		t.Errorf("expected: %v, got: %v", expected, all)
+	}
+}
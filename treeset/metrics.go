@@ -0,0 +1,25 @@
+package treeset
+
+// Metrics receives per-operation callbacks from a set, so that counters and
+// hit ratios can be exported without modifying this package. Clear and bulk
+// operations building or replacing whole trees (set algebra, Decode, ...)
+// report nothing, so Clear keeps its O(1) cost.
+type Metrics interface {
+	// ObserveAdd is called once per item passed to Add, and by GetOrAdd when
+	// it inserts.
+	ObserveAdd()
+
+	// ObserveRemove is called once per item passed to Remove or
+	// RemoveReturning, and once per item taken out by Extract.
+	ObserveRemove()
+
+	// ObserveLookup is called once per item looked up by Contains,
+	// ContainsAny or GetOrAdd, reporting whether it was present.
+	ObserveLookup(hit bool)
+}
+
+// Sets the metrics hooks of the set. A nil m disables them, which is the
+// default and costs nothing but a nil check per operation.
+func (set *Set) SetMetrics(m Metrics) {
+	set.metrics = m
+}
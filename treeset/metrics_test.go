@@ -0,0 +1,51 @@
+package treeset
+
+import "testing"
+
+type countingMetrics struct {
+	adds, removes, hits, misses int
+}
+
+func (m *countingMetrics) ObserveAdd()    { m.adds++ }
+func (m *countingMetrics) ObserveRemove() { m.removes++ }
+func (m *countingMetrics) ObserveLookup(hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := &countingMetrics{}
+	set := NewWithIntComparator()
+	set.SetMetrics(metrics)
+
+	set.Add(1, 2, 3)
+	set.Remove(2)
+	set.Contains(1)
+	set.Contains(1, 2, 3) // stops at the first miss
+	set.ContainsAny(5, 3)
+
+	expected := countingMetrics{adds: 3, removes: 1, hits: 3, misses: 2}
+	if *metrics != expected {
+		t.Errorf("expected: %+v, got: %+v", expected, *metrics)
+	}
+
+	*metrics = countingMetrics{}
+	set.GetOrAdd(1)
+	set.GetOrAdd(7)
+	set.RemoveReturning(7, 8)
+	set.Extract(func(value interface{}) bool { return value.(int) < 3 })
+	set.Clear()
+	expected = countingMetrics{adds: 1, removes: 3, hits: 1, misses: 1}
+	if *metrics != expected {
+		t.Errorf("expected: %+v, got: %+v", expected, *metrics)
+	}
+
+	set.SetMetrics(nil)
+	set.Add(4)
+	if metrics.adds != 1 {
+		t.Errorf("expected hooks to be detached, got: %+v", *metrics)
+	}
+}
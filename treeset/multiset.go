@@ -0,0 +1,124 @@
+package treeset
+
+import "github.com/emirpasic/gods/utils"
+
+// MultiSet is an ordered collection that keeps a count per distinct item,
+// stored in the red-black tree in place of the set's sentinel value.
+// Structure is not thread safe.
+type MultiSet struct {
+	set  *Set // item -> count
+	size int
+}
+
+// Instantiates a new empty multiset with the custom comparator.
+func NewMultiSetWith(comparator utils.Comparator) *MultiSet {
+	return &MultiSet{set: NewWith(comparator)}
+}
+
+// Adds one occurrence of each of the items (one or more).
+func (ms *MultiSet) Add(items ...interface{}) {
+	for _, item := range items {
+		ms.AddN(item, 1)
+	}
+}
+
+// Adds n occurrences of item. Does nothing if n <= 0.
+func (ms *MultiSet) AddN(item interface{}, n int) {
+	if n <= 0 {
+		return
+	}
+	if node := ms.set.lookup(item); node != nil {
+		node.Value = node.Value.(int) + n
+	} else {
+		ms.set.tree.Put(item, n)
+	}
+	ms.size += n
+}
+
+// Removes one occurrence of each of the items (one or more).
+// An item disappears once its count drops to zero.
+func (ms *MultiSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		ms.RemoveN(item, 1)
+	}
+}
+
+// Removes up to n occurrences of item and returns how many were removed.
+// The item disappears once its count drops to zero. Does nothing if n <= 0.
+func (ms *MultiSet) RemoveN(item interface{}, n int) int {
+	node := ms.set.lookup(item)
+	if node == nil || n <= 0 {
+		return 0
+	}
+	count := node.Value.(int)
+	if count > n {
+		node.Value = count - n
+	} else {
+		n = count
+		ms.set.tree.Remove(node.Key)
+	}
+	ms.size -= n
+	return n
+}
+
+// Removes all occurrences of item.
+func (ms *MultiSet) RemoveAll(item interface{}) {
+	node := ms.set.lookup(item)
+	if node == nil {
+		return
+	}
+	ms.size -= node.Value.(int)
+	ms.set.tree.Remove(node.Key)
+}
+
+// Returns the number of occurrences of item, 0 if absent.
+func (ms *MultiSet) Count(item interface{}) int {
+	if node := ms.set.lookup(item); node != nil {
+		return node.Value.(int)
+	}
+	return 0
+}
+
+// Check wether items (one or more) are present in the multiset.
+func (ms *MultiSet) Contains(items ...interface{}) bool {
+	return ms.set.Contains(items...)
+}
+
+// Calls f for every distinct item in ascending order with its count.
+func (ms *MultiSet) Each(f func(item interface{}, count int)) {
+	for node := leftmost(ms.set.tree); node != nil; node = successor(node) {
+		f(node.Key, node.Value.(int))
+	}
+}
+
+// Returns all items in ascending order, each repeated as many times as it occurs.
+func (ms *MultiSet) Values() []interface{} {
+	values := make([]interface{}, 0, ms.size)
+	ms.Each(func(item interface{}, count int) {
+		for i := 0; i < count; i++ {
+			values = append(values, item)
+		}
+	})
+	return values
+}
+
+// Returns true if the multiset does not contain any elements.
+func (ms *MultiSet) Empty() bool {
+	return ms.size == 0
+}
+
+// Returns the total number of occurrences within the multiset.
+func (ms *MultiSet) Size() int {
+	return ms.size
+}
+
+// Returns the number of distinct items within the multiset.
+func (ms *MultiSet) DistinctSize() int {
+	return ms.set.Size()
+}
+
+// Clears all values in the multiset.
+func (ms *MultiSet) Clear() {
+	ms.set.Clear()
+	ms.size = 0
+}
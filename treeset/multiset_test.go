@@ -0,0 +1,57 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestMultiSet(t *testing.T) {
+	ms := NewMultiSetWith(utils.IntComparator)
+	ms.Add(3, 1, 3)
+	ms.AddN(2, 2)
+	ms.AddN(5, 0)
+
+	if ms.Count(3) != 2 || ms.Count(2) != 2 || ms.Count(1) != 1 || ms.Count(5) != 0 {
+		t.Errorf("unexpected counts: %v", ms.Values())
+	}
+	if ms.Size() != 5 || ms.DistinctSize() != 3 {
+		t.Errorf("expected sizes (5, 3), got: (%d, %d)", ms.Size(), ms.DistinctSize())
+	}
+	if !equalValues(ms.Values(), []interface{}{1, 2, 2, 3, 3}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2, 2, 3, 3}, ms.Values())
+	}
+
+	ms.Remove(3, 1, 7)
+	if ms.Count(3) != 1 || ms.Contains(1) {
+		t.Errorf("expected decrements, got: %v", ms.Values())
+	}
+	ms.Remove(3)
+	if ms.Contains(3) || ms.DistinctSize() != 1 {
+		t.Errorf("expected item to disappear at zero, got: %v", ms.Values())
+	}
+
+	ms.RemoveAll(2)
+	if !ms.Empty() || ms.Size() != 0 {
+		t.Errorf("expected empty multiset, got: %v", ms.Values())
+	}
+}
+
+func TestMultiSetRemoveN(t *testing.T) {
+	ms := NewMultiSetWith(utils.IntComparator)
+	ms.AddN(7, 5)
+	ms.Add(8)
+
+	if removed := ms.RemoveN(7, 3); removed != 3 || ms.Count(7) != 2 {
+		t.Errorf("expected: %v %v, got: %v %v", 3, 2, removed, ms.Count(7))
+	}
+	if removed := ms.RemoveN(7, 10); removed != 2 || ms.Contains(7) {
+		t.Errorf("expected: %v, got: %v", 2, removed)
+	}
+	if removed := ms.RemoveN(9, 1) + ms.RemoveN(8, 0); removed != 0 {
+		t.Errorf("expected: %v, got: %v", 0, removed)
+	}
+	if ms.Size() != 1 || ms.DistinctSize() != 1 {
+		t.Errorf("expected: %v %v, got: %v %v", 1, 1, ms.Size(), ms.DistinctSize())
+	}
+}
@@ -0,0 +1,77 @@
+package treeset
+
+func nodeKey(node *rbNode) (interface{}, bool) {
+	if node == nil {
+		return nil, false
+	}
+	return node.Key, true
+}
+
+// Returns the largest item less than or equal to value, and whether there is one.
+func (set *Set) Floor(value interface{}) (interface{}, bool) {
+	return nodeKey(set.floor(value))
+}
+
+// Returns the smallest item greater than or equal to value, and whether there is one.
+func (set *Set) Ceiling(value interface{}) (interface{}, bool) {
+	return nodeKey(set.ceiling(value))
+}
+
+// Returns the smallest item strictly greater than value, and whether there is one.
+func (set *Set) Higher(value interface{}) (interface{}, bool) {
+	_, upper, _ := set.bracket(value)
+	return nodeKey(upper)
+}
+
+// Returns the largest item strictly less than value, and whether there is one.
+func (set *Set) Lower(value interface{}) (interface{}, bool) {
+	lower, _, _ := set.bracket(value)
+	return nodeKey(lower)
+}
+
+// Returns the smallest item, and whether the set is non-empty.
+func (set *Set) Min() (interface{}, bool) {
+	return nodeKey(leftmost(set.tree))
+}
+
+// Returns the largest item, and whether the set is non-empty.
+func (set *Set) Max() (interface{}, bool) {
+	return nodeKey(rightmost(set.tree))
+}
+
+// Returns the largest item, and whether the set is non-empty. It is the same
+// as Max, named for reading a time-ordered set newest first.
+func (set *Set) Last() (interface{}, bool) {
+	return set.Max()
+}
+
+// Returns up to limit items strictly less than value, in descending order,
+// e.g. the next page of a newest-first timeline below a cursor. A limit < 0
+// returns all of them.
+func (set *Set) Before(value interface{}, limit int) []interface{} {
+	values := []interface{}{}
+	lower, _, _ := set.bracket(value)
+	for node := lower; node != nil && len(values) != limit; node = predecessor(node) {
+		values = append(values, node.Key)
+	}
+	return values
+}
+
+// Removes and returns the smallest item, and whether the set was non-empty.
+// Evicting the oldest entry of a bounded timeline costs O(log n).
+func (set *Set) PopMin() (interface{}, bool) {
+	item, ok := set.Min()
+	if ok {
+		set.Remove(item)
+	}
+	return item, ok
+}
+
+// Removes and returns the largest item, and whether the set was non-empty.
+func (set *Set) PopMax() (interface{}, bool) {
+	item, ok := set.Max()
+	if ok {
+		set.Remove(item)
+	}
+	return item, ok
+}
@@ -0,0 +1,117 @@
+package treeset
+
+import "testing"
+
+func TestNavigation(t *testing.T) {
+	set := newIntSet(10, 20, 30)
+	type result struct {
+		value interface{}
+		found bool
+	}
+	check := func(name string, value interface{}, found bool, expected result) {
+		if value != expected.value || found != expected.found {
+			t.Errorf("%s, expected: %v, got: (%v, %v)", name, expected, value, found)
+		}
+	}
+	none := result{nil, false}
+
+	cases := []struct {
+		value                         int
+		floor, ceiling, higher, lower result
+	}{
+		{5, none, result{10, true}, result{10, true}, none},
+		{10, result{10, true}, result{10, true}, result{20, true}, none},
+		{25, result{20, true}, result{30, true}, result{30, true}, result{20, true}},
+		{30, result{30, true}, result{30, true}, none, result{20, true}},
+		{35, result{30, true}, none, none, result{30, true}},
+	}
+	for _, c := range cases {
+		v, ok := set.Floor(c.value)
+		check("Floor", v, ok, c.floor)
+		v, ok = set.Ceiling(c.value)
+		check("Ceiling", v, ok, c.ceiling)
+		v, ok = set.Higher(c.value)
+		check("Higher", v, ok, c.higher)
+		v, ok = set.Lower(c.value)
+		check("Lower", v, ok, c.lower)
+	}
+}
+
+func TestMinMaxPop(t *testing.T) {
+	set := newIntSet(20, 10, 30)
+	if v, ok := set.Min(); !ok || v != 10 {
+		t.Errorf("Min, expected: %v, got: %v", 10, v)
+	}
+	if v, ok := set.Max(); !ok || v != 30 {
+		t.Errorf("Max, expected: %v, got: %v", 30, v)
+	}
+	if v, ok := set.PopMin(); !ok || v != 10 {
+		t.Errorf("PopMin, expected: %v, got: %v", 10, v)
+	}
+	if v, ok := set.PopMax(); !ok || v != 30 {
+		t.Errorf("PopMax, expected: %v, got: %v", 30, v)
+	}
+	if expected := []interface{}{20}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	set.Clear()
+	if _, ok := set.Min(); ok {
+		t.Errorf("expected Min of an empty set to fail")
+	}
+	if _, ok := set.PopMax(); ok {
+		t.Errorf("expected PopMax of an empty set to fail")
+	}
+}
+
+func TestReverseAccess(t *testing.T) {
+	set := newIntSet(40, 10, 30, 20)
+	if v, ok := set.Last(); !ok || v != 40 {
+		t.Errorf("Last, expected: %v, got: %v", 40, v)
+	}
+	if expected := []interface{}{40, 30, 20, 10}; !equalValues(set.ReverseValues(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.ReverseValues())
+	}
+
+	var reversed []interface{}
+	set.EachReverse(func(index int, value interface{}) {
+		if index != len(reversed) {
+			t.Errorf("expected index: %v, got: %v", len(reversed), index)
+		}
+		reversed = append(reversed, value)
+	})
+	if expected := []interface{}{40, 30, 20, 10}; !equalValues(reversed, expected) {
+		t.Errorf("expected: %v, got: %v", expected, reversed)
+	}
+
+	it := set.ReverseIterator()
+	reversed = nil
+	for it.Prev() {
+		reversed = append(reversed, it.Value())
+	}
+	if expected := []interface{}{40, 30, 20, 10}; !equalValues(reversed, expected) {
+		t.Errorf("expected: %v, got: %v", expected, reversed)
+	}
+
+	tests := []struct {
+		value    int
+		limit    int
+		expected []interface{}
+	}{
+		{30, 10, []interface{}{20, 10}},
+		{35, 1, []interface{}{30}},
+		{100, -1, []interface{}{40, 30, 20, 10}},
+		{10, 5, []interface{}{}},
+		{30, 0, []interface{}{}},
+	}
+	for _, test := range tests {
+		if actual := set.Before(test.value, test.limit); !equalValues(actual, test.expected) {
+			t.Errorf("Before(%v, %v), expected: %v, got: %v", test.value, test.limit, test.expected, actual)
+		}
+	}
+
+	empty := NewWithIntComparator()
+	if _, ok := empty.Last(); ok || len(empty.ReverseValues()) != 0 {
+		t.Errorf("expected nothing in an empty set")
+	}
+}
@@ -0,0 +1,177 @@
+package treeset
+
+// Helpers for numeric sets, i.e. sets of int, int64 or float64 items.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// MaxRunLengthItems bounds the number of items NewFromRunLength expands
+// ranges into, so a corrupt persisted range cannot exhaust memory.
+const MaxRunLengthItems = 1 << 24
+
+var (
+	ErrInvalidWidth      = errors.New("bucket width must be positive")
+	ErrNotNumeric        = errors.New("item is not numeric")
+	ErrInvalidRanges     = errors.New("run-length ranges must be non-empty, ascending and disjoint")
+	ErrRunLengthTooLarge = fmt.Errorf("run-length ranges hold more than %d items", MaxRunLengthItems)
+)
+
+// toFloat64 converts an int, int64 or float64 item.
+func toFloat64(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// difference returns b - a for two integers of the same type.
+func difference(a, b interface{}) (interface{}, bool) {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(int); ok {
+			return y - x, true
+		}
+	case int64:
+		if y, ok := b.(int64); ok {
+			return y - x, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the common difference if the items, in order, form an evenly
+// spaced sequence such as {2, 4, 6, 8}. Sets of size 0 or 1 are trivially
+// progressions and return a nil step. Returns ok=false for anything else,
+// including items that are not int or int64.
+func (set *Set) IsArithmeticProgression() (step interface{}, ok bool) {
+	if set.Size() < 2 {
+		return nil, true
+	}
+	prev := leftmost(set.tree)
+	for node := successor(prev); node != nil; prev, node = node, successor(node) {
+		diff, ok := difference(prev.Key, node.Key)
+		if !ok || (step != nil && diff != step) {
+			return nil, false
+		}
+		step = diff
+	}
+	return step, true
+}
+
+// Splits the items into fixed-width buckets for building histograms with
+// uniform bins: an item goes to bucket floor(value/width). Each bucket is a
+// new set ordered by bucketComparator, or by the set's own comparator if nil.
+// Returns ErrInvalidWidth if width <= 0 and ErrNotNumeric if an item is not
+// an int, int64 or float64.
+func (set *Set) BucketByWidth(width float64, bucketComparator utils.Comparator) (map[int]*Set, error) {
+	if !(width > 0) {
+		return nil, ErrInvalidWidth
+	}
+	if bucketComparator == nil {
+		bucketComparator = set.comparator
+	}
+	buckets := make(map[int]*Set)
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		value, ok := toFloat64(node.Key)
+		if !ok {
+			return nil, ErrNotNumeric
+		}
+		index := int(math.Floor(value / width))
+		bucket, found := buckets[index]
+		if !found {
+			bucket = NewWith(bucketComparator)
+			buckets[index] = bucket
+		}
+		bucket.tree.Put(node.Key, itemExists)
+	}
+	return buckets, nil
+}
+
+// Returns the start and length of the longest run of consecutive integers
+// present in a set of int items, e.g. start=1, length=3 for {1, 2, 3, 10, 11}.
+// On ties the leftmost run wins. Returns length 0 for an empty set.
+// Panics if the set holds items other than int.
+func (set *Set) LongestConsecutiveRun() (start int, length int) {
+	runStart, runLength := 0, 0
+	prev := 0
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		value := node.Key.(int)
+		if runLength > 0 && value == prev+1 {
+			runLength++
+		} else {
+			runStart, runLength = value, 1
+		}
+		if runLength > length {
+			start, length = runStart, runLength
+		}
+		prev = value
+	}
+	return start, length
+}
+
+// Returns the inclusive [start, end] ranges covering exactly the items of a
+// set of int items, e.g. [[1, 3], [7, 7]] for {1, 2, 3, 7}. Compact for
+// persisting dense or clustered ID sets.
+// Panics if the set holds items other than int.
+func (set *Set) RunLengthEncode() [][2]int {
+	ranges := make([][2]int, 0)
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		value := node.Key.(int)
+		if last := len(ranges) - 1; last >= 0 && ranges[last][1] == value-1 {
+			ranges[last][1] = value
+		} else {
+			ranges = append(ranges, [2]int{value, value})
+		}
+	}
+	return ranges
+}
+
+// Instantiates a new int set holding every integer of the inclusive
+// [start, end] ranges, the inverse of RunLengthEncode, bulk loading them in
+// O(n). Ranges must have start <= end and be in ascending order without
+// overlapping, otherwise ErrInvalidRanges is returned. Ranges holding more
+// than MaxRunLengthItems items in total fail with ErrRunLengthTooLarge.
+func NewFromRunLength(ranges [][2]int) (*Set, error) {
+	var n uint64
+	for i, r := range ranges {
+		if r[0] > r[1] || (i > 0 && r[0] <= ranges[i-1][1]) {
+			return nil, ErrInvalidRanges
+		}
+		// Unsigned, as the span of a range can exceed math.MaxInt.
+		span := uint64(r[1]) - uint64(r[0])
+		if span >= MaxRunLengthItems || n+span+1 > MaxRunLengthItems {
+			return nil, ErrRunLengthTooLarge
+		}
+		n += span + 1
+	}
+
+	set := NewWithIntComparator()
+	i, value := 0, 0
+	if len(ranges) > 0 {
+		value = ranges[0][0]
+	}
+	set.tree.load(int(n), func() (interface{}, bool) {
+		item := value
+		// Compare before incrementing, so a range ending at math.MaxInt
+		// does not overflow.
+		if value == ranges[i][1] {
+			if i++; i < len(ranges) {
+				value = ranges[i][0]
+			}
+		} else {
+			value++
+		}
+		return item, true
+	}, itemExists)
+	return set, nil
+}
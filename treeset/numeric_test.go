@@ -0,0 +1,151 @@
+package treeset
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsArithmeticProgression(t *testing.T) {
+	cases := []struct {
+		set  *Set
+		step interface{}
+		ok   bool
+	}{
+		{newIntSet(2, 4, 6, 8), 2, true},
+		{newIntSet(1, 2, 4), nil, false},
+		{newIntSet(-9, 1, -4), 5, true},
+		{newIntSet(), nil, true},
+		{newIntSet(7), nil, true},
+	}
+	for _, c := range cases {
+		step, ok := c.set.IsArithmeticProgression()
+		if step != c.step || ok != c.ok {
+			t.Errorf("%v, expected: (%v, %v), got: (%v, %v)", c.set.Values(), c.step, c.ok, step, ok)
+		}
+	}
+
+	int64s := NewWith(Int64Comparator)
+	int64s.Add(int64(30), int64(10), int64(20))
+	if step, ok := int64s.IsArithmeticProgression(); step != int64(10) || !ok {
+		t.Errorf("expected: (10, true), got: (%v, %v)", step, ok)
+	}
+
+	strs := NewWithStringComparator()
+	strs.Add("a", "b")
+	if _, ok := strs.IsArithmeticProgression(); ok {
+		t.Errorf("expected non-numeric set not to be a progression")
+	}
+}
+
+func TestBucketByWidth(t *testing.T) {
+	set := newIntSet(-3, 0, 4, 9, 10, 12, 25)
+	buckets, err := set.BucketByWidth(10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int][]interface{}{
+		-1: {-3},
+		0:  {0, 4, 9},
+		1:  {10, 12},
+		2:  {25},
+	}
+	if len(buckets) != len(expected) {
+		t.Errorf("expected %d buckets, got: %d", len(expected), len(buckets))
+	}
+	for index, values := range expected {
+		bucket, found := buckets[index]
+		if !found || !equalValues(bucket.Values(), values) {
+			t.Errorf("bucket %d, expected: %v, got: %v", index, values, bucket)
+		}
+	}
+
+	if _, err := set.BucketByWidth(0, nil); err != ErrInvalidWidth {
+		t.Errorf("expected: %v, got: %v", ErrInvalidWidth, err)
+	}
+	strs := NewWithStringComparator()
+	strs.Add("a")
+	if _, err := strs.BucketByWidth(1, nil); err != ErrNotNumeric {
+		t.Errorf("expected: %v, got: %v", ErrNotNumeric, err)
+	}
+}
+
+func TestLongestConsecutiveRun(t *testing.T) {
+	cases := []struct {
+		set           *Set
+		start, length int
+	}{
+		{newIntSet(1, 2, 3, 10, 11), 1, 3},
+		{newIntSet(-2, -1, 0, 1, 2), -2, 5},
+		{newIntSet(1, 3, 5, 7), 1, 1},
+		{newIntSet(1, 2, 5, 6, 9), 1, 2},
+		{newIntSet(1, 4, 5, 6, 7), 4, 4},
+		{newIntSet(), 0, 0},
+	}
+	for _, c := range cases {
+		start, length := c.set.LongestConsecutiveRun()
+		if start != c.start || length != c.length {
+			t.Errorf("%v, expected: (%d, %d), got: (%d, %d)", c.set.Values(), c.start, c.length, start, length)
+		}
+	}
+}
+
+func TestRunLengthEncode(t *testing.T) {
+	cases := []struct {
+		set    *Set
+		ranges [][2]int
+	}{
+		{newIntSet(1, 2, 3, 7), [][2]int{{1, 3}, {7, 7}}},
+		{newIntSet(-2, -1, 0, 5, 6, 9), [][2]int{{-2, 0}, {5, 6}, {9, 9}}},
+		{newIntSet(1, 3, 5), [][2]int{{1, 1}, {3, 3}, {5, 5}}},
+		{newIntSet(4, 5, 6, 7), [][2]int{{4, 7}}},
+		{newIntSet(), [][2]int{}},
+	}
+	for _, c := range cases {
+		ranges := c.set.RunLengthEncode()
+		if len(ranges) != len(c.ranges) {
+			t.Errorf("expected: %v, got: %v", c.ranges, ranges)
+			continue
+		}
+		for i := range ranges {
+			if ranges[i] != c.ranges[i] {
+				t.Errorf("expected: %v, got: %v", c.ranges, ranges)
+				break
+			}
+		}
+		decoded, err := NewFromRunLength(ranges)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalValues(decoded.Values(), c.set.Values()) {
+			t.Errorf("expected: %v, got: %v", c.set.Values(), decoded.Values())
+		}
+		checkRBTree(t, decoded.tree.Root)
+	}
+}
+
+func TestNewFromRunLengthBounds(t *testing.T) {
+	set, err := NewFromRunLength([][2]int{{math.MinInt64, math.MinInt64 + 1}, {math.MaxInt64 - 1, math.MaxInt64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []interface{}{math.MinInt64, math.MinInt64 + 1, math.MaxInt64 - 1, math.MaxInt64}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	cases := []struct {
+		ranges [][2]int
+		err    error
+	}{
+		{[][2]int{{3, 1}}, ErrInvalidRanges},
+		{[][2]int{{1, 3}, {3, 5}}, ErrInvalidRanges},
+		{[][2]int{{5, 6}, {1, 2}}, ErrInvalidRanges},
+		{[][2]int{{0, MaxRunLengthItems}}, ErrRunLengthTooLarge},
+		{[][2]int{{math.MinInt64, math.MaxInt64}}, ErrRunLengthTooLarge},
+		{[][2]int{{0, MaxRunLengthItems/2 - 1}, {MaxRunLengthItems, MaxRunLengthItems + MaxRunLengthItems/2}}, ErrRunLengthTooLarge},
+	}
+	for _, c := range cases {
+		if _, err := NewFromRunLength(c.ranges); err != c.err {
+			t.Errorf("%v, expected: %v, got: %v", c.ranges, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package treeset
+
+import "github.com/emirpasic/gods/utils"
+
+// PersistentSet is an immutable ordered set. Add and Remove leave the
+// receiver untouched and return a new version that shares all unchanged
+// subtrees with it, copying only the O(log n) nodes on the modified path.
+// Any version can therefore be read from many goroutines without locks
+// while a writer derives the next one, e.g. publishing each new timeline
+// version through an atomic.Value.
+// It is backed by an AVL tree instead of a red-black tree because its nodes
+// carry no parent pointers, which would defeat sharing.
+type PersistentSet struct {
+	root       *pnode
+	comparator utils.Comparator
+}
+
+// pnode is never modified after construction.
+type pnode struct {
+	key    interface{}
+	left   *pnode
+	right  *pnode
+	height int
+	size   int
+}
+
+// Instantiates a new empty persistent set with the custom comparator.
+func NewPersistentWith(comparator utils.Comparator) *PersistentSet {
+	return &PersistentSet{comparator: comparator}
+}
+
+// Returns a persistent copy of the set, built in O(n).
+func (set *Set) Persistent() *PersistentSet {
+	keys := set.Values()
+	return &PersistentSet{root: buildPersistent(keys), comparator: set.comparator}
+}
+
+func buildPersistent(keys []interface{}) *pnode {
+	if len(keys) == 0 {
+		return nil
+	}
+	mid := len(keys) / 2
+	return newPNode(keys[mid], buildPersistent(keys[:mid]), buildPersistent(keys[mid+1:]))
+}
+
+// Returns a version of the set with the items (one or more) added. Returns
+// the receiver itself if all of them are already present.
+func (ps *PersistentSet) Add(items ...interface{}) *PersistentSet {
+	root := ps.root
+	for _, item := range items {
+		root = ps.insert(root, item)
+	}
+	return ps.withRoot(root)
+}
+
+// Returns a version of the set with the items (one or more) removed. Returns
+// the receiver itself if none of them is present.
+func (ps *PersistentSet) Remove(items ...interface{}) *PersistentSet {
+	root := ps.root
+	for _, item := range items {
+		root = ps.remove(root, item)
+	}
+	return ps.withRoot(root)
+}
+
+func (ps *PersistentSet) withRoot(root *pnode) *PersistentSet {
+	if root == ps.root {
+		return ps
+	}
+	return &PersistentSet{root: root, comparator: ps.comparator}
+}
+
+// Check wether items (one or more) are present in the set.
+// Returns true if no arguments are passed at all.
+func (ps *PersistentSet) Contains(items ...interface{}) bool {
+	for _, item := range items {
+		node := ps.root
+		for node != nil {
+			compare := ps.comparator(item, node.key)
+			if compare == 0 {
+				break
+			}
+			if compare < 0 {
+				node = node.left
+			} else {
+				node = node.right
+			}
+		}
+		if node == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Calls f for every item in ascending order along with its 0-based index.
+func (ps *PersistentSet) Each(f func(index int, value interface{})) {
+	index := 0
+	var walk func(node *pnode)
+	walk = func(node *pnode) {
+		if node == nil {
+			return
+		}
+		walk(node.left)
+		f(index, node.key)
+		index++
+		walk(node.right)
+	}
+	walk(ps.root)
+}
+
+// Returns true if set does not contain any elements.
+func (ps *PersistentSet) Empty() bool {
+	return ps.root == nil
+}
+
+// Returns number of elements within the set.
+func (ps *PersistentSet) Size() int {
+	return psize(ps.root)
+}
+
+// Returns all items in the set in ascending order.
+func (ps *PersistentSet) Values() []interface{} {
+	values := make([]interface{}, 0, ps.Size())
+	ps.Each(func(index int, value interface{}) {
+		values = append(values, value)
+	})
+	return values
+}
+
+func pheight(node *pnode) int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
+
+func psize(node *pnode) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func newPNode(key interface{}, left, right *pnode) *pnode {
+	height := pheight(left)
+	if h := pheight(right); h > height {
+		height = h
+	}
+	return &pnode{key: key, left: left, right: right, height: height + 1, size: psize(left) + psize(right) + 1}
+}
+
+// balance returns a new node holding key over left and right, rotating once
+// or twice if their heights differ by two.
+func balance(key interface{}, left, right *pnode) *pnode {
+	switch {
+	case pheight(left) > pheight(right)+1:
+		if pheight(left.left) >= pheight(left.right) {
+			return newPNode(left.key, left.left, newPNode(key, left.right, right))
+		}
+		return newPNode(left.right.key,
+			newPNode(left.key, left.left, left.right.left),
+			newPNode(key, left.right.right, right))
+	case pheight(right) > pheight(left)+1:
+		if pheight(right.right) >= pheight(right.left) {
+			return newPNode(right.key, newPNode(key, left, right.left), right.right)
+		}
+		return newPNode(right.left.key,
+			newPNode(key, left, right.left.left),
+			newPNode(right.key, right.left.right, right.right))
+	}
+	return newPNode(key, left, right)
+}
+
+// insert returns node itself if key is already present below it.
+func (ps *PersistentSet) insert(node *pnode, key interface{}) *pnode {
+	if node == nil {
+		return newPNode(key, nil, nil)
+	}
+	compare := ps.comparator(key, node.key)
+	switch {
+	case compare < 0:
+		if left := ps.insert(node.left, key); left != node.left {
+			return balance(node.key, left, node.right)
+		}
+	case compare > 0:
+		if right := ps.insert(node.right, key); right != node.right {
+			return balance(node.key, node.left, right)
+		}
+	}
+	return node
+}
+
+// remove returns node itself if key is not present below it.
+func (ps *PersistentSet) remove(node *pnode, key interface{}) *pnode {
+	if node == nil {
+		return nil
+	}
+	compare := ps.comparator(key, node.key)
+	switch {
+	case compare < 0:
+		if left := ps.remove(node.left, key); left != node.left {
+			return balance(node.key, left, node.right)
+		}
+		return node
+	case compare > 0:
+		if right := ps.remove(node.right, key); right != node.right {
+			return balance(node.key, node.left, right)
+		}
+		return node
+	}
+	if node.left == nil {
+		return node.right
+	}
+	if node.right == nil {
+		return node.left
+	}
+	min := node.right
+	for min.left != nil {
+		min = min.left
+	}
+	return balance(min.key, node.left, removeMin(node.right))
+}
+
+func removeMin(node *pnode) *pnode {
+	if node.left == nil {
+		return node.right
+	}
+	return balance(node.key, removeMin(node.left), node.right)
+}
@@ -0,0 +1,78 @@
+package treeset
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// checkAVL verifies heights, sizes and balance below node.
+func checkAVL(t *testing.T, node *pnode) {
+	if node == nil {
+		return
+	}
+	checkAVL(t, node.left)
+	checkAVL(t, node.right)
+	if diff := pheight(node.left) - pheight(node.right); diff < -1 || diff > 1 {
+		t.Fatalf("unbalanced node %v: %d", node.key, diff)
+	}
+	if expected := newPNode(node.key, node.left, node.right); node.height != expected.height || node.size != expected.size {
+		t.Fatalf("wrong height or size at %v", node.key)
+	}
+}
+
+func TestPersistentSetVersions(t *testing.T) {
+	v0 := NewPersistentWith(utils.IntComparator)
+	v1 := v0.Add(3, 1, 2)
+	v2 := v1.Remove(2).Add(4)
+
+	if !v0.Empty() {
+		t.Errorf("expected: %v, got: %v", []int{}, v0.Values())
+	}
+	if expected := []interface{}{1, 2, 3}; !equalValues(v1.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, v1.Values())
+	}
+	if expected := []interface{}{1, 3, 4}; !equalValues(v2.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, v2.Values())
+	}
+	if v1.Add(1) != v1 || v1.Remove(9) != v1 {
+		t.Errorf("expected no-op updates to return the receiver")
+	}
+	if !v2.Contains(1, 4) || v2.Contains(2) {
+		t.Errorf("unexpected Contains result for: %v", v2.Values())
+	}
+}
+
+func TestPersistentSetMatchesMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	set := newIntSet()
+	for i := 0; i < 300; i++ {
+		set.Add(rnd.Intn(1000))
+	}
+	ps := set.Persistent()
+	checkAVL(t, ps.root)
+
+	versions := []*PersistentSet{ps}
+	expected := [][]interface{}{set.Values()}
+	for i := 0; i < 3000; i++ {
+		key := rnd.Intn(1000)
+		if rnd.Intn(2) == 0 {
+			ps = ps.Remove(key)
+			set.Remove(key)
+		} else {
+			ps = ps.Add(key)
+			set.Add(key)
+		}
+		checkAVL(t, ps.root)
+		if i%300 == 0 {
+			versions = append(versions, ps)
+			expected = append(expected, set.Values())
+		}
+	}
+	for i, version := range versions {
+		if !equalValues(version.Values(), expected[i]) || version.Size() != len(expected[i]) {
+			t.Fatalf("version %d, expected: %v, got: %v", i, expected[i], version.Values())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package treeset
+
+// Set comparison predicates. Both sets must be ordered by the same
+// comparator. Size checks answer most mismatches up front. Equals walks both
+// sets in step, comparing with the receiver's comparator. IsSubset,
+// IsSuperset and Disjoint walk only the smaller set and look its items up
+// in the larger one, with the larger one's comparator, which costs
+// O(min(n, m) log max(n, m)).
+
+// Returns true if every item of set is present in otherSet.
+func (set *Set) IsSubset(otherSet *Set) bool {
+	if set.Size() > otherSet.Size() {
+		return false
+	}
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		if otherSet.lookup(node.Key) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if every item of otherSet is present in set.
+func (set *Set) IsSuperset(otherSet *Set) bool {
+	return otherSet.IsSubset(set)
+}
+
+// Returns true if set and otherSet hold the same items.
+func (set *Set) Equals(otherSet *Set) bool {
+	if set.Size() != otherSet.Size() {
+		return false
+	}
+	i, j := leftmost(set.tree), leftmost(otherSet.tree)
+	for i != nil {
+		if set.comparator(i.Key, j.Key) != 0 {
+			return false
+		}
+		i, j = successor(i), successor(j)
+	}
+	return true
+}
+
+// Returns true if set and otherSet have no item in common.
+func (set *Set) Disjoint(otherSet *Set) bool {
+	smaller, larger := set, otherSet
+	if smaller.Size() > larger.Size() {
+		smaller, larger = larger, smaller
+	}
+	for node := leftmost(smaller.tree); node != nil; node = successor(node) {
+		if larger.lookup(node.Key) != nil {
+			return false
+		}
+	}
+	return true
+}
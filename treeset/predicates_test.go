@@ -0,0 +1,33 @@
+package treeset
+
+import "testing"
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		a, b                               *Set
+		subset, superset, equals, disjoint bool
+	}{
+		{newIntSet(1, 2), newIntSet(1, 2, 3), true, false, false, false},
+		{newIntSet(1, 2, 3), newIntSet(2, 3), false, true, false, false},
+		{newIntSet(1, 2), newIntSet(1, 2), true, true, true, false},
+		{newIntSet(1, 2), newIntSet(3, 4), false, false, false, true},
+		{newIntSet(1, 4), newIntSet(1, 3), false, false, false, false},
+		{newIntSet(), newIntSet(1), true, false, false, true},
+		{newIntSet(), newIntSet(), true, true, true, true},
+	}
+	for _, test := range tests {
+		a, b := test.a.Values(), test.b.Values()
+		if actual := test.a.IsSubset(test.b); actual != test.subset {
+			t.Errorf("IsSubset(%v, %v), expected: %v, got: %v", a, b, test.subset, actual)
+		}
+		if actual := test.a.IsSuperset(test.b); actual != test.superset {
+			t.Errorf("IsSuperset(%v, %v), expected: %v, got: %v", a, b, test.superset, actual)
+		}
+		if actual := test.a.Equals(test.b); actual != test.equals {
+			t.Errorf("Equals(%v, %v), expected: %v, got: %v", a, b, test.equals, actual)
+		}
+		if actual := test.a.Disjoint(test.b); actual != test.disjoint {
+			t.Errorf("Disjoint(%v, %v), expected: %v, got: %v", a, b, test.disjoint, actual)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package treeset
+
+// Range copies follow java.util.TreeSet: the lower bound is inclusive and
+// the upper bound exclusive. The returned sets are independent copies that
+// carry the receiver's comparator.
+
+// Returns a new set with the items strictly less than to.
+func (set *Set) HeadSet(to interface{}) *Set {
+	end, _ := set.Rank(to)
+	return set.copyRange(0, end)
+}
+
+// Returns a new set with the items greater than or equal to from.
+func (set *Set) TailSet(from interface{}) *Set {
+	start, _ := set.Rank(from)
+	return set.copyRange(start, set.Size())
+}
+
+// Returns a new set with the items greater than or equal to from and
+// strictly less than to. Empty if from is not less than to.
+func (set *Set) SubSet(from, to interface{}) *Set {
+	start, _ := set.Rank(from)
+	end, _ := set.Rank(to)
+	return set.copyRange(start, end)
+}
+
+// copyRange copies the items at positions [start, end), locating them from
+// the subtree sizes and bulk loading them in O(log n + end - start).
+func (set *Set) copyRange(start, end int) *Set {
+	newSet := set.newEmpty()
+	if start >= end {
+		return newSet
+	}
+	node := set.selectNode(start)
+	newSet.tree.load(end-start, func() (interface{}, bool) {
+		key := node.Key
+		node = successor(node)
+		return key, true
+	}, itemExists)
+	return newSet
+}
@@ -0,0 +1,34 @@
+package treeset
+
+import "testing"
+
+func TestRangeSets(t *testing.T) {
+	set := newIntSet(10, 20, 30, 40, 50)
+	cases := []struct {
+		name     string
+		got      *Set
+		expected []interface{}
+	}{
+		{"HeadSet(30)", set.HeadSet(30), []interface{}{10, 20}},
+		{"HeadSet(35)", set.HeadSet(35), []interface{}{10, 20, 30}},
+		{"HeadSet(5)", set.HeadSet(5), []interface{}{}},
+		{"TailSet(30)", set.TailSet(30), []interface{}{30, 40, 50}},
+		{"TailSet(35)", set.TailSet(35), []interface{}{40, 50}},
+		{"TailSet(55)", set.TailSet(55), []interface{}{}},
+		{"SubSet(20, 40)", set.SubSet(20, 40), []interface{}{20, 30}},
+		{"SubSet(15, 45)", set.SubSet(15, 45), []interface{}{20, 30, 40}},
+		{"SubSet(40, 20)", set.SubSet(40, 20), []interface{}{}},
+	}
+	for _, c := range cases {
+		if !equalValues(c.got.Values(), c.expected) {
+			t.Errorf("%s, expected: %v, got: %v", c.name, c.expected, c.got.Values())
+		}
+		checkRBTree(t, c.got.tree.Root)
+	}
+
+	head := set.HeadSet(30)
+	head.Add(25)
+	if set.Contains(25) {
+		t.Errorf("expected range sets to be independent copies")
+	}
+}
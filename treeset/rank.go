@@ -0,0 +1,71 @@
+package treeset
+
+// Order statistics, answered in O(log n) from the subtree sizes kept in every
+// tree node.
+
+// Returns the 0-based position of item in ascending order and true if item is
+// present. Otherwise returns the position item would take if it were added,
+// i.e. the number of smaller items, and false.
+func (set *Set) Rank(item interface{}) (rank int, found bool) {
+	node := set.tree.Root
+	for node != nil {
+		compare := set.comparator(item, node.Key)
+		switch {
+		case compare == 0:
+			return rank + sizeOf(node.Left), true
+		case compare < 0:
+			node = node.Left
+		case compare > 0:
+			rank += sizeOf(node.Left) + 1
+			node = node.Right
+		}
+	}
+	return rank, false
+}
+
+// Returns the k-th smallest item (0-based) and true, or nil and false if k is
+// out of range.
+func (set *Set) Select(k int) (interface{}, bool) {
+	return nodeKey(set.selectNode(k))
+}
+
+// Returns the items at positions [from, to) in ascending order, clamped to
+// the bounds of the set, e.g. SelectRange(100, 120) for the sixth page of
+// twenty. Costs O(log n + to - from).
+func (set *Set) SelectRange(from, to int) []interface{} {
+	if from < 0 {
+		from = 0
+	}
+	if size := set.Size(); to > size {
+		to = size
+	}
+	if from >= to {
+		return []interface{}{}
+	}
+	values := make([]interface{}, 0, to-from)
+	for node := set.selectNode(from); len(values) < to-from; node = successor(node) {
+		values = append(values, node.Key)
+	}
+	return values
+}
+
+// selectNode returns the node holding the k-th smallest item, or nil.
+func (set *Set) selectNode(k int) *rbNode {
+	if k < 0 || k >= set.Size() {
+		return nil
+	}
+	node := set.tree.Root
+	for node != nil {
+		left := sizeOf(node.Left)
+		switch {
+		case k < left:
+			node = node.Left
+		case k > left:
+			k -= left + 1
+			node = node.Right
+		default:
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package treeset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRankAndSelect(t *testing.T) {
+	set := newIntSet(10, 20, 30, 40)
+	tests := []struct {
+		item  int
+		rank  int
+		found bool
+	}{
+		{5, 0, false},
+		{10, 0, true},
+		{25, 2, false},
+		{40, 3, true},
+		{50, 4, false},
+	}
+	for _, test := range tests {
+		rank, found := set.Rank(test.item)
+		if rank != test.rank || found != test.found {
+			t.Errorf("Rank(%v), expected: %v %v, got: %v %v", test.item, test.rank, test.found, rank, found)
+		}
+	}
+
+	for k, expected := range []int{10, 20, 30, 40} {
+		if actual, ok := set.Select(k); !ok || actual != expected {
+			t.Errorf("Select(%v), expected: %v, got: %v", k, expected, actual)
+		}
+	}
+	if _, ok := set.Select(4); ok {
+		t.Errorf("expected Select out of range to fail")
+	}
+	if _, ok := set.Select(-1); ok {
+		t.Errorf("expected Select out of range to fail")
+	}
+}
+
+func TestSelectRange(t *testing.T) {
+	set := newIntSet(0, 1, 2, 3, 4, 5)
+	tests := []struct {
+		from, to int
+		expected []interface{}
+	}{
+		{1, 3, []interface{}{1, 2}},
+		{-2, 2, []interface{}{0, 1}},
+		{4, 100, []interface{}{4, 5}},
+		{3, 3, []interface{}{}},
+		{7, 9, []interface{}{}},
+	}
+	for _, test := range tests {
+		if actual := set.SelectRange(test.from, test.to); !equalValues(actual, test.expected) {
+			t.Errorf("SelectRange(%v, %v), expected: %v, got: %v", test.from, test.to, test.expected, actual)
+		}
+	}
+}
+
+func TestRankSurvivesChurn(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	set := NewWithIntComparator()
+	for i := 0; i < 2000; i++ {
+		if rnd.Intn(3) == 0 {
+			set.Remove(rnd.Intn(300))
+		} else {
+			set.Add(rnd.Intn(300))
+		}
+	}
+	for k, value := range set.Values() {
+		if rank, found := set.Rank(value); !found || rank != k {
+			t.Fatalf("Rank(%v), expected: %v, got: %v", value, k, rank)
+		}
+		if actual, _ := set.Select(k); actual != value {
+			t.Fatalf("Select(%v), expected: %v, got: %v", k, value, actual)
+		}
+	}
+}
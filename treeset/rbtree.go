@@ -0,0 +1,460 @@
+package treeset
+
+// Red-black tree backing Set. Same algorithm and API shape as gods'
+// redblacktree (http://en.wikipedia.org/wiki/Red%E2%80%93black_tree), with
+// each node also recording the size of its subtree for order statistics.
+
+import (
+	"sync"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+type rbNode struct {
+	Key    interface{}
+	Value  interface{}
+	Left   *rbNode
+	Right  *rbNode
+	Parent *rbNode
+	red    bool
+	size   int
+}
+
+type rbTree struct {
+	Root       *rbNode
+	comparator utils.Comparator
+	kind       comparatorKind
+	shared     bool // referenced by a snapshot, so must not be modified
+	pooled     bool // nodes come from and return to nodePool
+}
+
+func newRBTree(comparator utils.Comparator) *rbTree {
+	return &rbTree{comparator: comparator, kind: kindOf(comparator)}
+}
+
+// nodePool recycles the nodes of pooled trees. Nodes freed by one pooled set
+// are reused by any other, so high-churn sets stop producing garbage.
+var nodePool = sync.Pool{New: func() interface{} { return new(rbNode) }}
+
+func (tree *rbTree) newNode(key interface{}, value interface{}) *rbNode {
+	if !tree.pooled {
+		return &rbNode{Key: key, Value: value, red: true, size: 1}
+	}
+	node := nodePool.Get().(*rbNode)
+	node.Key, node.Value, node.red, node.size = key, value, true, 1
+	return node
+}
+
+// free returns a node unlinked from a pooled tree to nodePool, dropping its
+// references so the pool does not keep items alive.
+func (tree *rbTree) free(node *rbNode) {
+	if tree.pooled {
+		*node = rbNode{}
+		nodePool.Put(node)
+	}
+}
+
+func (tree *rbTree) freeAll(node *rbNode) {
+	if node == nil {
+		return
+	}
+	tree.freeAll(node.Left)
+	tree.freeAll(node.Right)
+	tree.free(node)
+}
+
+func isRed(node *rbNode) bool {
+	return node != nil && node.red
+}
+
+func sizeOf(node *rbNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func (node *rbNode) resize() {
+	node.size = sizeOf(node.Left) + sizeOf(node.Right) + 1
+}
+
+// Inserts key with value, or only updates the value if a comparator-equal key
+// is already present (the stored key is kept).
+func (tree *rbTree) Put(key interface{}, value interface{}) {
+	var inserted *rbNode
+	if tree.Root == nil {
+		inserted = tree.newNode(key, value)
+		tree.Root = inserted
+	} else {
+		node, compare := tree.descend(key)
+		if compare == 0 {
+			node.Value = value
+			return
+		}
+		inserted = tree.newNode(key, value)
+		if compare < 0 {
+			node.Left = inserted
+		} else {
+			node.Right = inserted
+		}
+		inserted.Parent = node
+		for ; node != nil; node = node.Parent {
+			node.size++
+		}
+	}
+	tree.insertFixup(inserted)
+}
+
+// descend walks the non-empty tree down to the node holding key, returning
+// it with 0, or to the node key would hang from, returning the sign of key
+// compared to it. Trees ordered by the int or string comparator compare keys
+// inline instead of calling through the comparator.
+func (tree *rbTree) descend(key interface{}) (*rbNode, int) {
+	switch tree.kind {
+	case intComparator:
+		if k, ok := key.(int); ok {
+			return descendInt(tree.Root, k)
+		}
+	case stringComparator:
+		if k, ok := key.(string); ok {
+			return descendString(tree.Root, k)
+		}
+	}
+	node := tree.Root
+	for {
+		compare := tree.comparator(key, node.Key)
+		var child *rbNode
+		switch {
+		case compare == 0:
+			return node, 0
+		case compare < 0:
+			child = node.Left
+		default:
+			child = node.Right
+		}
+		if child == nil {
+			return node, compare
+		}
+		node = child
+	}
+}
+
+func descendInt(node *rbNode, key int) (*rbNode, int) {
+	for {
+		nodeKey := node.Key.(int)
+		var child *rbNode
+		switch {
+		case key < nodeKey:
+			if child = node.Left; child == nil {
+				return node, -1
+			}
+		case key > nodeKey:
+			if child = node.Right; child == nil {
+				return node, 1
+			}
+		default:
+			return node, 0
+		}
+		node = child
+	}
+}
+
+func descendString(node *rbNode, key string) (*rbNode, int) {
+	for {
+		nodeKey := node.Key.(string)
+		var child *rbNode
+		switch {
+		case key < nodeKey:
+			if child = node.Left; child == nil {
+				return node, -1
+			}
+		case key > nodeKey:
+			if child = node.Right; child == nil {
+				return node, 1
+			}
+		default:
+			return node, 0
+		}
+		node = child
+	}
+}
+
+func (tree *rbTree) Get(key interface{}) (value interface{}, found bool) {
+	if node := tree.lookup(key); node != nil {
+		return node.Value, true
+	}
+	return nil, false
+}
+
+// Removes the comparator-equal key from the tree, if present.
+func (tree *rbTree) Remove(key interface{}) {
+	node := tree.lookup(key)
+	if node == nil {
+		return
+	}
+	if node.Left != nil && node.Right != nil {
+		pred := node.Left
+		for pred.Right != nil {
+			pred = pred.Right
+		}
+		node.Key = pred.Key
+		node.Value = pred.Value
+		node = pred
+	}
+	child := node.Left
+	if child == nil {
+		child = node.Right
+	}
+	if !node.red {
+		node.red = isRed(child)
+		tree.deleteFixup(node)
+	}
+	tree.replace(node, child)
+	if node.Parent == nil && child != nil {
+		child.red = false
+	}
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		parent.size--
+	}
+	tree.free(node)
+}
+
+func (tree *rbTree) Size() int {
+	return sizeOf(tree.Root)
+}
+
+// Returns all keys in order.
+func (tree *rbTree) Keys() []interface{} {
+	keys := make([]interface{}, 0, tree.Size())
+	for node := leftmost(tree); node != nil; node = successor(node) {
+		keys = append(keys, node.Key)
+	}
+	return keys
+}
+
+// Empties the tree. A pooled tree returns all its nodes to the pool in O(n).
+func (tree *rbTree) Clear() {
+	tree.freeAll(tree.Root)
+	tree.Root = nil
+}
+
+func (tree *rbTree) lookup(key interface{}) *rbNode {
+	node := tree.Root
+	for node != nil {
+		compare := tree.comparator(key, node.Key)
+		switch {
+		case compare == 0:
+			return node
+		case compare < 0:
+			node = node.Left
+		case compare > 0:
+			node = node.Right
+		}
+	}
+	return nil
+}
+
+func (node *rbNode) grandparent() *rbNode {
+	if node != nil && node.Parent != nil {
+		return node.Parent.Parent
+	}
+	return nil
+}
+
+func (node *rbNode) uncle() *rbNode {
+	grandparent := node.grandparent()
+	if grandparent == nil {
+		return nil
+	}
+	if node.Parent == grandparent.Left {
+		return grandparent.Right
+	}
+	return grandparent.Left
+}
+
+func (node *rbNode) sibling() *rbNode {
+	if node == nil || node.Parent == nil {
+		return nil
+	}
+	if node == node.Parent.Left {
+		return node.Parent.Right
+	}
+	return node.Parent.Left
+}
+
+func (tree *rbTree) insertFixup(node *rbNode) {
+	for {
+		if node.Parent == nil {
+			node.red = false
+			return
+		}
+		if !node.Parent.red {
+			return
+		}
+		if uncle := node.uncle(); isRed(uncle) {
+			node.Parent.red = false
+			uncle.red = false
+			grandparent := node.grandparent()
+			grandparent.red = true
+			node = grandparent
+			continue
+		}
+		grandparent := node.grandparent()
+		if node == node.Parent.Right && node.Parent == grandparent.Left {
+			tree.rotateLeft(node.Parent)
+			node = node.Left
+		} else if node == node.Parent.Left && node.Parent == grandparent.Right {
+			tree.rotateRight(node.Parent)
+			node = node.Right
+		}
+		node.Parent.red = false
+		grandparent = node.grandparent()
+		grandparent.red = true
+		if node == node.Parent.Left && node.Parent == grandparent.Left {
+			tree.rotateRight(grandparent)
+		} else {
+			tree.rotateLeft(grandparent)
+		}
+		return
+	}
+}
+
+func (tree *rbTree) deleteFixup(node *rbNode) {
+	for {
+		if node.Parent == nil {
+			return
+		}
+		sibling := node.sibling()
+		if isRed(sibling) {
+			node.Parent.red = true
+			sibling.red = false
+			if node == node.Parent.Left {
+				tree.rotateLeft(node.Parent)
+			} else {
+				tree.rotateRight(node.Parent)
+			}
+			sibling = node.sibling()
+		}
+		if !isRed(sibling) && !isRed(sibling.Left) && !isRed(sibling.Right) {
+			sibling.red = true
+			if !node.Parent.red {
+				node = node.Parent
+				continue
+			}
+			node.Parent.red = false
+			return
+		}
+		if node == node.Parent.Left && isRed(sibling.Left) && !isRed(sibling.Right) {
+			sibling.red = true
+			sibling.Left.red = false
+			tree.rotateRight(sibling)
+		} else if node == node.Parent.Right && isRed(sibling.Right) && !isRed(sibling.Left) {
+			sibling.red = true
+			sibling.Right.red = false
+			tree.rotateLeft(sibling)
+		}
+		sibling = node.sibling()
+		sibling.red = node.Parent.red
+		node.Parent.red = false
+		if node == node.Parent.Left {
+			sibling.Right.red = false
+			tree.rotateLeft(node.Parent)
+		} else {
+			sibling.Left.red = false
+			tree.rotateRight(node.Parent)
+		}
+		return
+	}
+}
+
+func (tree *rbTree) rotateLeft(node *rbNode) {
+	right := node.Right
+	tree.replace(node, right)
+	node.Right = right.Left
+	if right.Left != nil {
+		right.Left.Parent = node
+	}
+	right.Left = node
+	node.Parent = right
+	node.resize()
+	right.resize()
+}
+
+func (tree *rbTree) rotateRight(node *rbNode) {
+	left := node.Left
+	tree.replace(node, left)
+	node.Left = left.Right
+	if left.Right != nil {
+		left.Right.Parent = node
+	}
+	left.Right = node
+	node.Parent = left
+	node.resize()
+	left.resize()
+}
+
+func (tree *rbTree) replace(old *rbNode, new *rbNode) {
+	if old.Parent == nil {
+		tree.Root = new
+	} else if old == old.Parent.Left {
+		old.Parent.Left = new
+	} else {
+		old.Parent.Right = new
+	}
+	if new != nil {
+		new.Parent = old.Parent
+	}
+}
+
+// Replaces the contents of the tree with keys, which must be strictly
+// ascending under the comparator, in O(n).
+func (tree *rbTree) loadSorted(keys []interface{}, value interface{}) {
+	i := 0
+	tree.load(len(keys), func() (interface{}, bool) {
+		i++
+		return keys[i-1], true
+	}, value)
+}
+
+// Replaces the contents of the tree with n keys pulled from next in strictly
+// ascending order, in O(n) and without buffering them. The tree is built
+// perfectly balanced, so all levels are black except the bottom one, which
+// is red. If next fails the build stops early, leaving a broken tree the
+// caller must discard.
+func (tree *rbTree) load(n int, next func() (interface{}, bool), value interface{}) {
+	deepest := 0 // floor(log2(n))
+	for m := n; m > 1; m >>= 1 {
+		deepest++
+	}
+	tree.Root = buildBalanced(n, next, value, nil, 0, deepest)
+}
+
+func buildBalanced(n int, next func() (interface{}, bool), value interface{}, parent *rbNode, depth, deepest int) *rbNode {
+	if n == 0 {
+		return nil
+	}
+	node := &rbNode{Value: value, Parent: parent, red: depth > 0 && depth == deepest, size: n}
+	node.Left = buildBalanced(n/2, next, value, node, depth+1, deepest)
+	key, ok := next()
+	if !ok {
+		return nil
+	}
+	node.Key = key
+	node.Right = buildBalanced(n-n/2-1, next, value, node, depth+1, deepest)
+	return node
+}
+
+// Returns a structural copy of the tree in O(n), without comparing keys.
+func (tree *rbTree) clone() *rbTree {
+	return &rbTree{Root: copyNode(tree.Root, nil), comparator: tree.comparator, kind: tree.kind, pooled: tree.pooled}
+}
+
+func copyNode(node *rbNode, parent *rbNode) *rbNode {
+	if node == nil {
+		return nil
+	}
+	copied := &rbNode{Key: node.Key, Value: node.Value, Parent: parent, red: node.red, size: node.size}
+	copied.Left = copyNode(node.Left, copied)
+	copied.Right = copyNode(node.Right, copied)
+	return copied
+}
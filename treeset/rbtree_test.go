@@ -0,0 +1,130 @@
+package treeset
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// checkRBTree verifies the red-black properties, parent links and subtree
+// sizes below node, returning its black height.
+func checkRBTree(t *testing.T, node *rbNode) int {
+	if node == nil {
+		return 1
+	}
+	if node.red && (isRed(node.Left) || isRed(node.Right)) {
+		t.Fatalf("red node %v has a red child", node.Key)
+	}
+	if (node.Left != nil && node.Left.Parent != node) || (node.Right != nil && node.Right.Parent != node) {
+		t.Fatalf("broken parent link at %v", node.Key)
+	}
+	if node.size != sizeOf(node.Left)+sizeOf(node.Right)+1 {
+		t.Fatalf("wrong subtree size at %v: %d", node.Key, node.size)
+	}
+	left, right := checkRBTree(t, node.Left), checkRBTree(t, node.Right)
+	if left != right {
+		t.Fatalf("black height mismatch at %v: %d != %d", node.Key, left, right)
+	}
+	if node.red {
+		return left
+	}
+	return left + 1
+}
+
+func TestRBTreeInvariants(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := newRBTree(utils.IntComparator)
+	present := make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		key := rnd.Intn(500)
+		if rnd.Intn(3) == 0 {
+			tree.Remove(key)
+			delete(present, key)
+		} else {
+			tree.Put(key, itemExists)
+			present[key] = true
+		}
+		if isRed(tree.Root) {
+			t.Fatalf("red root")
+		}
+		checkRBTree(t, tree.Root)
+		if tree.Size() != len(present) {
+			t.Fatalf("expected: %v, got: %v", len(present), tree.Size())
+		}
+	}
+}
+
+func TestRBTreeLoadSorted(t *testing.T) {
+	for n := 0; n <= 130; n++ {
+		keys := make([]interface{}, n)
+		for i := range keys {
+			keys[i] = i
+		}
+		tree := newRBTree(utils.IntComparator)
+		tree.loadSorted(keys, itemExists)
+		if isRed(tree.Root) {
+			t.Fatalf("red root for %d keys", n)
+		}
+		checkRBTree(t, tree.Root)
+		if tree.Size() != n || !equalValues(tree.Keys(), keys) {
+			t.Fatalf("expected: %v, got: %v", keys, tree.Keys())
+		}
+
+		// The loaded tree must stay valid under further updates.
+		tree.Put(n, itemExists)
+		tree.Remove(n / 2)
+		checkRBTree(t, tree.Root)
+	}
+}
+
+func TestPooledSetChurn(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	set := NewPooledWith(utils.IntComparator)
+	present := make(map[int]bool)
+	var snapshot *Set
+	var snapshotValues []interface{}
+	for i := 0; i < 5000; i++ {
+		key := rnd.Intn(200)
+		switch rnd.Intn(10) {
+		case 0:
+			set.Clear()
+			present = make(map[int]bool)
+		case 1, 2, 3:
+			set.Remove(key)
+			delete(present, key)
+		default:
+			set.Add(key)
+			present[key] = true
+		}
+		if i%500 == 0 {
+			snapshot, snapshotValues = set.Snapshot(), set.Values()
+		}
+		checkRBTree(t, set.tree.Root)
+		if set.Size() != len(present) {
+			t.Fatalf("expected: %v, got: %v", len(present), set.Size())
+		}
+	}
+	// Nodes recycled by the set must not be shared with the snapshot.
+	if !equalValues(snapshot.Values(), snapshotValues) {
+		t.Errorf("expected: %v, got: %v", snapshotValues, snapshot.Values())
+	}
+}
+
+func benchmarkChurn(b *testing.B, set *Set) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		set.Add(i)
+		if i >= 1000 {
+			set.Remove(i - 1000)
+		}
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	benchmarkChurn(b, NewWithIntComparator())
+}
+
+func BenchmarkChurnPooled(b *testing.B) {
+	benchmarkChurn(b, NewPooledWith(utils.IntComparator))
+}
@@ -0,0 +1,111 @@
+package treeset
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Returns n distinct items picked uniformly at random, in ascending order,
+// or all items if the set holds fewer than n. Picks random ranks and selects
+// them in the tree, so it costs O(n log size) and does not copy the set.
+func (set *Set) Sample(n int) []interface{} {
+	size := set.Size()
+	if n > size {
+		n = size
+	}
+	if n <= 0 {
+		return []interface{}{}
+	}
+	// Floyd's algorithm draws n distinct ranks with n random numbers.
+	picked := make(map[int]bool, n)
+	ranks := make([]int, 0, n)
+	for j := size - n; j < size; j++ {
+		rank := rand.Intn(j + 1)
+		if picked[rank] {
+			rank = j
+		}
+		picked[rank] = true
+		ranks = append(ranks, rank)
+	}
+	sort.Ints(ranks)
+	values := make([]interface{}, n)
+	for i, rank := range ranks {
+		values[i], _ = set.Select(rank)
+	}
+	return values
+}
+
+// Returns n distinct items picked at random with probability proportional
+// to weight(item), in the order they were picked, or all items of positive
+// weight if there are fewer than n. Items of weight <= 0 are never picked.
+// Makes one pass over the set in O(size log n) without copying it.
+func (set *Set) WeightedSample(n int, weight func(item interface{}) float64) []interface{} {
+	// Efraimidis and Spirakis: keep the n items with the largest u^(1/w)
+	// for u uniform in (0, 1), compared as log(u)/w.
+	h := &sampleHeap{}
+	if n > 0 {
+		for node := leftmost(set.tree); node != nil; node = successor(node) {
+			w := weight(node.Key)
+			if w <= 0 {
+				continue
+			}
+			key := math.Log(1-rand.Float64()) / w
+			if h.Len() < n {
+				heap.Push(h, sampleEntry{item: node.Key, key: key})
+			} else if key > h.entries[0].key {
+				h.entries[0] = sampleEntry{item: node.Key, key: key}
+				heap.Fix(h, 0)
+			}
+		}
+	}
+	values := make([]interface{}, h.Len())
+	for i := len(values) - 1; i >= 0; i-- {
+		values[i] = heap.Pop(h).(sampleEntry).item
+	}
+	return values
+}
+
+type sampleEntry struct {
+	item interface{}
+	key  float64
+}
+
+// sampleHeap is a min-heap of entries by key.
+type sampleHeap struct {
+	entries []sampleEntry
+}
+
+func (h *sampleHeap) Len() int           { return len(h.entries) }
+func (h *sampleHeap) Less(i, j int) bool { return h.entries[i].key < h.entries[j].key }
+func (h *sampleHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *sampleHeap) Push(x interface{}) { h.entries = append(h.entries, x.(sampleEntry)) }
+func (h *sampleHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+// Returns n distinct members picked uniformly at random, in ascending score
+// order, or all members if there are fewer than n.
+func (ss *ScoredSet) Sample(n int) []ScoredMember {
+	return toScoredMembers(ss.byScore.Sample(n))
+}
+
+// Returns n distinct members picked at random with probability proportional
+// to weight(member), in the order they were picked; see Set.WeightedSample.
+// Passing a weight returning the score samples members by score.
+func (ss *ScoredSet) WeightedSample(n int, weight func(member ScoredMember) float64) []ScoredMember {
+	return toScoredMembers(ss.byScore.WeightedSample(n, func(item interface{}) float64 {
+		return weight(item.(ScoredMember))
+	}))
+}
+
+func toScoredMembers(items []interface{}) []ScoredMember {
+	members := make([]ScoredMember, len(items))
+	for i, item := range items {
+		members[i] = item.(ScoredMember)
+	}
+	return members
+}
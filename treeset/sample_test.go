@@ -0,0 +1,79 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestSample(t *testing.T) {
+	set := NewWithIntComparator()
+	for i := 0; i < 10; i++ {
+		set.Add(i)
+	}
+	counts := make(map[interface{}]int)
+	for round := 0; round < 10000; round++ {
+		sample := set.Sample(3)
+		if len(sample) != 3 {
+			t.Fatalf("expected: %v, got: %v", 3, len(sample))
+		}
+		for i, item := range sample {
+			if i > 0 && item.(int) <= sample[i-1].(int) {
+				t.Fatalf("expected distinct ascending items, got: %v", sample)
+			}
+			counts[item]++
+		}
+	}
+	for item, count := range counts {
+		if count < 2700 || count > 3300 {
+			t.Errorf("%v, expected about 3000 picks, got: %d", item, count)
+		}
+	}
+
+	if sample := set.Sample(20); !equalValues(sample, set.Values()) {
+		t.Errorf("expected all items, got: %v", sample)
+	}
+	if sample := NewWithIntComparator().Sample(3); len(sample) != 0 {
+		t.Errorf("expected no items, got: %v", sample)
+	}
+}
+
+func TestWeightedSample(t *testing.T) {
+	set := newIntSet(0, 1, 2, 3)
+	weight := func(item interface{}) float64 { return float64(item.(int)) }
+	counts := make(map[interface{}]int)
+	for round := 0; round < 12000; round++ {
+		sample := set.WeightedSample(1, weight)
+		if len(sample) != 1 {
+			t.Fatalf("expected: %v, got: %v", 1, len(sample))
+		}
+		counts[sample[0]]++
+	}
+	// Weights 1:2:3, so about 2000, 4000 and 6000 picks; 0 is never picked.
+	for item, expected := range map[interface{}]int{0: 0, 1: 2000, 2: 4000, 3: 6000} {
+		if diff := counts[item] - expected; diff < -400 || diff > 400 {
+			t.Errorf("%v, expected about %d picks, got: %d", item, expected, counts[item])
+		}
+	}
+
+	sample := set.WeightedSample(5, weight)
+	if len(sample) != 3 || !set.Contains(sample...) || sample[0] == sample[1] || sample[1] == sample[2] {
+		t.Errorf("expected the three items of positive weight, got: %v", sample)
+	}
+}
+
+func TestScoredSetSample(t *testing.T) {
+	ss := NewScoredSetWith(utils.StringComparator)
+	ss.AddWithScore("a", 1)
+	ss.AddWithScore("b", 0)
+	ss.AddWithScore("c", 2)
+	if sample := ss.Sample(3); len(sample) != 3 || sample[0].Member != "b" || sample[2].Member != "c" {
+		t.Errorf("expected all members by score, got: %v", sample)
+	}
+	byScore := func(member ScoredMember) float64 { return member.Score }
+	for round := 0; round < 100; round++ {
+		if sample := ss.WeightedSample(2, byScore); len(sample) != 2 || sample[0].Member == "b" || sample[1].Member == "b" {
+			t.Fatalf("expected the members of positive score, got: %v", sample)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package treeset
+
+import "github.com/emirpasic/gods/utils"
+
+// ScoredMember is a member of a ScoredSet together with its score.
+type ScoredMember struct {
+	Member interface{}
+	Score  float64
+}
+
+// ScoredSet follows Redis sorted set (ZSET) semantics: every member has a
+// float64 score, members are unique, and the set is ordered by score with
+// ties broken by the member comparator. A member index maps members to their
+// current score so updates can find the old position.
+// Structure is not thread safe.
+type ScoredSet struct {
+	members *Set // member -> score
+	byScore *Set // ScoredMember ordered by score, then member
+}
+
+// Instantiates a new empty scored set ordering members with memberComparator.
+func NewScoredSetWith(memberComparator utils.Comparator) *ScoredSet {
+	byScore := func(a, b interface{}) int {
+		x, y := a.(ScoredMember), b.(ScoredMember)
+		switch {
+		case x.Score < y.Score:
+			return -1
+		case x.Score > y.Score:
+			return 1
+		default:
+			return memberComparator(x.Member, y.Member)
+		}
+	}
+	return &ScoredSet{members: NewWith(memberComparator), byScore: NewWith(byScore)}
+}
+
+// Sets the score of member, adding it if absent. Returns true if member was added.
+func (ss *ScoredSet) AddWithScore(member interface{}, score float64) bool {
+	old, existed := ss.Score(member)
+	if existed {
+		if old == score {
+			return false
+		}
+		ss.byScore.Remove(ScoredMember{Member: member, Score: old})
+	}
+	ss.members.tree.Put(member, score)
+	ss.byScore.Add(ScoredMember{Member: member, Score: score})
+	return !existed
+}
+
+// Returns the score of member and whether it is present.
+func (ss *ScoredSet) Score(member interface{}) (float64, bool) {
+	if node := ss.members.lookup(member); node != nil {
+		return node.Value.(float64), true
+	}
+	return 0, false
+}
+
+// Adds delta to the score of member, treating an absent member as scored 0,
+// and returns the new score.
+func (ss *ScoredSet) IncrBy(member interface{}, delta float64) float64 {
+	score, _ := ss.Score(member)
+	score += delta
+	ss.AddWithScore(member, score)
+	return score
+}
+
+// Removes the members (one or more) from the set.
+func (ss *ScoredSet) Remove(members ...interface{}) {
+	for _, member := range members {
+		if score, ok := ss.Score(member); ok {
+			ss.byScore.Remove(ScoredMember{Member: member, Score: score})
+			ss.members.Remove(member)
+		}
+	}
+}
+
+// Returns the 0-based position of member in ascending score order and
+// whether it is present.
+func (ss *ScoredSet) Rank(member interface{}) (int, bool) {
+	score, ok := ss.Score(member)
+	if !ok {
+		return 0, false
+	}
+	return ss.byScore.Rank(ScoredMember{Member: member, Score: score})
+}
+
+// Returns the members with min <= score <= max in ascending score order.
+func (ss *ScoredSet) RangeByScore(min, max float64) []ScoredMember {
+	result := []ScoredMember{}
+	first, ok := ss.byScore.Select(ss.countBelow(min))
+	if !ok {
+		return result
+	}
+	it := ss.byScore.Iterator()
+	for ok = it.Seek(first); ok; ok = it.Next() {
+		entry := it.Value().(ScoredMember)
+		if entry.Score > max {
+			break
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// countBelow returns the number of entries scored strictly less than min.
+func (ss *ScoredSet) countBelow(min float64) int {
+	count := 0
+	node := ss.byScore.tree.Root
+	for node != nil {
+		if node.Key.(ScoredMember).Score < min {
+			count += sizeOf(node.Left) + 1
+			node = node.Right
+		} else {
+			node = node.Left
+		}
+	}
+	return count
+}
+
+// Returns the members ranked start through stop inclusive, in ascending
+// score order. Like Redis ZRANGE, negative positions count from the end, so
+// RangeByRank(0, -1) returns everything.
+func (ss *ScoredSet) RangeByRank(start, stop int) []ScoredMember {
+	size := ss.Size()
+	if start < 0 {
+		start += size
+	}
+	if stop < 0 {
+		stop += size
+	}
+	result := []ScoredMember{}
+	for _, entry := range ss.byScore.SelectRange(start, stop+1) {
+		result = append(result, entry.(ScoredMember))
+	}
+	return result
+}
+
+// Same as RangeByRank with positions counted from the highest score, like
+// Redis ZREVRANGE. Members are returned in descending score order.
+func (ss *ScoredSet) RevRangeByRank(start, stop int) []ScoredMember {
+	size := ss.Size()
+	if start < 0 {
+		start += size
+	}
+	if stop < 0 {
+		stop += size
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= size {
+		stop = size - 1
+	}
+	if start > stop {
+		return []ScoredMember{}
+	}
+	result := ss.RangeByRank(size-1-stop, size-1-start)
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// Returns true if set does not contain any members.
+func (ss *ScoredSet) Empty() bool {
+	return ss.members.Empty()
+}
+
+// Returns number of members within the set.
+func (ss *ScoredSet) Size() int {
+	return ss.members.Size()
+}
+
+// Clears all members in the set.
+func (ss *ScoredSet) Clear() {
+	ss.members.Clear()
+	ss.byScore.Clear()
+}
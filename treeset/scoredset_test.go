@@ -0,0 +1,66 @@
+package treeset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func scoredMembers(set []ScoredMember) []interface{} {
+	members := []interface{}{}
+	for _, entry := range set {
+		members = append(members, entry.Member)
+	}
+	return members
+}
+
+func TestScoredSet(t *testing.T) {
+	ss := NewScoredSetWith(utils.StringComparator)
+	ss.AddWithScore("a", 3)
+	ss.AddWithScore("b", 1)
+	ss.AddWithScore("c", 2)
+	if ss.AddWithScore("d", 2) != true || ss.AddWithScore("d", 2) != false {
+		t.Errorf("expected AddWithScore to report new members only")
+	}
+
+	if score, ok := ss.Score("c"); !ok || score != 2 {
+		t.Errorf("expected: %v, got: %v", 2, score)
+	}
+	if score := ss.IncrBy("b", 5); score != 6 {
+		t.Errorf("expected: %v, got: %v", 6, score)
+	}
+	if score := ss.IncrBy("e", 0.5); score != 0.5 {
+		t.Errorf("expected: %v, got: %v", 0.5, score)
+	}
+	// e:0.5 c:2 d:2 a:3 b:6
+	if rank, ok := ss.Rank("a"); !ok || rank != 3 {
+		t.Errorf("expected: %v, got: %v", 3, rank)
+	}
+
+	tests := []struct {
+		name     string
+		actual   []ScoredMember
+		expected []interface{}
+	}{
+		{"RangeByScore", ss.RangeByScore(2, 3), []interface{}{"c", "d", "a"}},
+		{"RangeByScore fractional", ss.RangeByScore(0.6, 2.5), []interface{}{"c", "d"}},
+		{"RangeByScore empty", ss.RangeByScore(7, 9), []interface{}{}},
+		{"RangeByRank", ss.RangeByRank(1, 2), []interface{}{"c", "d"}},
+		{"RangeByRank negative", ss.RangeByRank(-2, -1), []interface{}{"a", "b"}},
+		{"RangeByRank all", ss.RangeByRank(0, -1), []interface{}{"e", "c", "d", "a", "b"}},
+		{"RevRangeByRank", ss.RevRangeByRank(0, 1), []interface{}{"b", "a"}},
+		{"RevRangeByRank clamped", ss.RevRangeByRank(3, 10), []interface{}{"c", "e"}},
+		{"RevRangeByRank empty", ss.RevRangeByRank(5, 10), []interface{}{}},
+	}
+	for _, test := range tests {
+		if actual := scoredMembers(test.actual); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s, expected: %v, got: %v", test.name, test.expected, actual)
+		}
+	}
+
+	ss.Remove("a", "zzz")
+	if _, ok := ss.Score("a"); ok || ss.Size() != 4 {
+		t.Errorf("expected a to be removed, got: %v", scoredMembers(ss.RangeByRank(0, -1)))
+	}
+}
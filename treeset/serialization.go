@@ -0,0 +1,129 @@
+package treeset
+
+// A set is serialized as its items in comparator order: a JSON array, or a
+// gob-encoded slice of the item type. The comparator itself is not encoded,
+// so decoding goes into a set already constructed with the right comparator,
+// which also tells the decoder the item type for int and string sets. Other
+// sets must declare it with SetItemType first.
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+var (
+	ErrNoComparator    = errors.New("set has no comparator, construct it with NewWith before decoding")
+	ErrUnknownItemType = errors.New("item type unknown, call SetItemType before decoding")
+)
+
+// Declares the concrete type of the items, e.g. SetItemType(int64(0)), so
+// UnmarshalJSON and GobDecode can restore items of that type. Sets built with
+// NewWithIntComparator or NewWithStringComparator do not need it.
+func (set *Set) SetItemType(sample interface{}) {
+	set.itemType = reflect.TypeOf(sample)
+}
+
+// resolveItemType returns the declared item type, falling back to the
+// comparator kind and then to the type of the first stored item.
+func (set *Set) resolveItemType() reflect.Type {
+	if set.itemType != nil {
+		return set.itemType
+	}
+	switch set.tree.kind {
+	case intComparator:
+		return reflect.TypeOf(0)
+	case stringComparator:
+		return reflect.TypeOf("")
+	}
+	if node := leftmost(set.tree); node != nil {
+		return reflect.TypeOf(node.Key)
+	}
+	return nil
+}
+
+// Encodes the items as a JSON array in ascending order.
+func (set *Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(set.Values())
+}
+
+// Replaces the contents of set with the items of a JSON array.
+func (set *Set) UnmarshalJSON(data []byte) error {
+	if set.tree == nil {
+		return ErrNoComparator
+	}
+	itemType := set.resolveItemType()
+	if itemType == nil {
+		return ErrUnknownItemType
+	}
+	items := reflect.New(reflect.SliceOf(itemType))
+	if err := json.Unmarshal(data, items.Interface()); err != nil {
+		return err
+	}
+	set.Clear()
+	set.addSlice(items.Elem())
+	return nil
+}
+
+// Encodes the items as a gob slice of the item type in ascending order.
+func (set *Set) GobEncode() ([]byte, error) {
+	itemType := set.resolveItemType()
+	if itemType == nil {
+		// Nothing stored and nothing declared: an empty set.
+		return nil, nil
+	}
+	items := reflect.MakeSlice(reflect.SliceOf(itemType), 0, set.Size())
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		items = reflect.Append(items, reflect.ValueOf(node.Key))
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).EncodeValue(items); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Replaces the contents of set with items encoded by GobEncode. On error the
+// set is left unchanged.
+func (set *Set) GobDecode(data []byte) error {
+	if set.tree == nil {
+		return ErrNoComparator
+	}
+	if len(data) == 0 {
+		set.Clear()
+		return nil
+	}
+	itemType := set.resolveItemType()
+	if itemType == nil {
+		return ErrUnknownItemType
+	}
+	items := reflect.New(reflect.SliceOf(itemType))
+	if err := gob.NewDecoder(bytes.NewReader(data)).DecodeValue(items); err != nil {
+		return err
+	}
+	set.Clear()
+	set.addSlice(items.Elem())
+	return nil
+}
+
+func (set *Set) addSlice(items reflect.Value) {
+	for i := 0; i < items.Len(); i++ {
+		set.Add(items.Index(i).Interface())
+	}
+}
+
+// Returns an approximate size in bytes of the set marshalled as a JSON array,
+// where format reports the encoded size of a single item. Callers can use it
+// to decide whether to compress or chunk a large set without marshalling it.
+func (set *Set) EstimateJSONSize(format func(v interface{}) int) int {
+	size := 2 // brackets
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		size += format(node.Key)
+	}
+	if n := set.Size(); n > 1 {
+		size += n - 1 // separators
+	}
+	return size
+}
@@ -0,0 +1,149 @@
+package treeset
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func intJSONSize(v interface{}) int {
+	return len(strconv.Itoa(v.(int)))
+}
+
+func TestEstimateJSONSize(t *testing.T) {
+	sets := []*Set{
+		newIntSet(),
+		newIntSet(7),
+		newIntSet(-3, 0, 12, 345, 6789, 1000000),
+	}
+	for _, set := range sets {
+		data, err := json.Marshal(set.Values())
+		if err != nil {
+			t.Fatal(err)
+		}
+		estimate := set.EstimateJSONSize(intJSONSize)
+		if diff := estimate - len(data); diff < -len(data)/10 || diff > len(data)/10 {
+			t.Errorf("expected about: %d, got: %d", len(data), estimate)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	set := newIntSet(3, -1, 2)
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[-1,2,3]" {
+		t.Errorf("expected: %v, got: %v", "[-1,2,3]", string(data))
+	}
+
+	restored := NewWithIntComparator()
+	restored.Add(100)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !equalValues(restored.Values(), set.Values()) {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Values())
+	}
+}
+
+func TestJSONRoundTripInt64(t *testing.T) {
+	set := NewWith(Int64Comparator)
+	set.Add(int64(1)<<60, int64(5))
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewWith(Int64Comparator)
+	if err := json.Unmarshal(data, restored); err != ErrUnknownItemType {
+		t.Errorf("expected: %v, got: %v", ErrUnknownItemType, err)
+	}
+	restored.SetItemType(int64(0))
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !equalValues(restored.Values(), set.Values()) {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Values())
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	type cached struct {
+		Name  string
+		Items *Set
+	}
+	set := NewWithStringComparator()
+	set.Add("b", "a", "c")
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cached{Name: "feed", Items: set}); err != nil {
+		t.Fatal(err)
+	}
+	restored := cached{Items: NewWithStringComparator()}
+	if err := gob.NewDecoder(&buf).Decode(&restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Name != "feed" || !equalValues(restored.Items.Values(), set.Values()) {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Items.Values())
+	}
+}
+
+func TestGobDecodeKeepsSetOnError(t *testing.T) {
+	set := NewWith(Int64Comparator)
+	set.Add(int64(1), int64(2))
+	data, err := set.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No item type declared: it is taken from the items held before decoding.
+	restored := NewWith(Int64Comparator)
+	restored.Add(int64(9))
+	if err := restored.GobDecode(data); err != nil {
+		t.Fatal(err)
+	}
+	if !equalValues(restored.Values(), set.Values()) {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Values())
+	}
+
+	if err := restored.GobDecode(data[:len(data)-1]); err == nil {
+		t.Errorf("expected an error decoding truncated data")
+	}
+	if !equalValues(restored.Values(), set.Values()) {
+		t.Errorf("expected: %v, got: %v", set.Values(), restored.Values())
+	}
+}
+
+func benchmarkSet(n int) *Set {
+	set := NewWithIntComparator()
+	for i := 0; i < n; i++ {
+		set.Add(i * 7919 % n)
+	}
+	return set
+}
+
+func BenchmarkJSONRoundTrip(b *testing.B) {
+	set := benchmarkSet(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := json.Marshal(set)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := json.Unmarshal(data, NewWithIntComparator()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEstimateJSONSize(b *testing.B) {
+	set := benchmarkSet(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.EstimateJSONSize(intJSONSize)
+	}
+}
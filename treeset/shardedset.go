@@ -0,0 +1,138 @@
+package treeset
+
+import (
+	"sync"
+
+	"github.com/emirpasic/gods/sets"
+	"github.com/emirpasic/gods/utils"
+)
+
+func assertShardedSetImplementation() {
+	var _ sets.Set = (*ShardedSet)(nil)
+}
+
+// ShardedSet is an ordered set partitioned by item hash across several sets,
+// each behind its own lock, so that writers to different shards do not
+// contend as they do on a single SyncSet. Whole-set reads such as Values
+// k-way merge the shards back into one ordered view.
+// Operations on several items lock one shard at a time, so they are atomic
+// per shard only; whole-set reads are consistent per shard only.
+// Structure is thread safe.
+type ShardedSet struct {
+	shards     []shard
+	hash       func(item interface{}) uint64
+	comparator utils.Comparator
+}
+
+type shard struct {
+	mu  sync.RWMutex
+	set *Set
+	_   [40]byte // keeps neighbouring locks off the same cache line
+}
+
+// Instantiates a new empty set with the custom comparator, partitioned into
+// n shards by hash, which must return the same value for items the
+// comparator considers equal.
+func NewShardedWith(comparator utils.Comparator, n int, hash func(item interface{}) uint64) *ShardedSet {
+	if n < 1 {
+		n = 1
+	}
+	ss := &ShardedSet{shards: make([]shard, n), hash: hash, comparator: comparator}
+	for i := range ss.shards {
+		ss.shards[i].set = NewWith(comparator)
+	}
+	return ss
+}
+
+func (ss *ShardedSet) shardOf(item interface{}) *shard {
+	return &ss.shards[ss.hash(item)%uint64(len(ss.shards))]
+}
+
+// Adds the items (one or more) to the set.
+func (ss *ShardedSet) Add(items ...interface{}) {
+	for _, item := range items {
+		s := ss.shardOf(item)
+		s.mu.Lock()
+		s.set.Add(item)
+		s.mu.Unlock()
+	}
+}
+
+// Removes the items (one or more) from the set.
+func (ss *ShardedSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		s := ss.shardOf(item)
+		s.mu.Lock()
+		s.set.Remove(item)
+		s.mu.Unlock()
+	}
+}
+
+// Check wether items (one or more) are present in the set.
+// Returns true if no arguments are passed at all.
+func (ss *ShardedSet) Contains(items ...interface{}) bool {
+	for _, item := range items {
+		s := ss.shardOf(item)
+		s.mu.RLock()
+		found := s.set.Contains(item)
+		s.mu.RUnlock()
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if set does not contain any elements.
+func (ss *ShardedSet) Empty() bool {
+	return ss.Size() == 0
+}
+
+// Returns number of elements within the set.
+func (ss *ShardedSet) Size() int {
+	size := 0
+	for i := range ss.shards {
+		s := &ss.shards[i]
+		s.mu.RLock()
+		size += s.set.Size()
+		s.mu.RUnlock()
+	}
+	return size
+}
+
+// Clears all values in the set.
+func (ss *ShardedSet) Clear() {
+	for i := range ss.shards {
+		s := &ss.shards[i]
+		s.mu.Lock()
+		s.set.Clear()
+		s.mu.Unlock()
+	}
+}
+
+// Returns all items in the set in ascending order.
+func (ss *ShardedSet) Values() []interface{} {
+	slices := make([][]interface{}, len(ss.shards))
+	for i := range ss.shards {
+		s := &ss.shards[i]
+		s.mu.RLock()
+		slices[i] = s.set.Values()
+		s.mu.RUnlock()
+	}
+	return mergeSorted(ss.comparator, slices)
+}
+
+// Returns an iterator over all items in ascending order, merging snapshots
+// of the shards. It needs no locking while in use and does not see later
+// writes, but the first write to each shard after it is taken copies that
+// shard's tree (see Set.Snapshot).
+func (ss *ShardedSet) Iterator() *MergingIterator {
+	snapshots := make([]*Set, len(ss.shards))
+	for i := range ss.shards {
+		s := &ss.shards[i]
+		s.mu.Lock()
+		snapshots[i] = s.set.Snapshot()
+		s.mu.Unlock()
+	}
+	return MergeIterator(ss.comparator, false, snapshots...)
+}
@@ -0,0 +1,85 @@
+package treeset
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func intHash(item interface{}) uint64 {
+	return uint64(item.(int)) * 0x9e3779b97f4a7c15
+}
+
+func TestShardedSet(t *testing.T) {
+	set := NewShardedWith(utils.IntComparator, 8, intHash)
+	set.Add(5, 3, 9, 1, 7, 3)
+	set.Remove(9, 4)
+	if expected := []interface{}{1, 3, 5, 7}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+	if !set.Contains(1, 7) || set.Contains(1, 9) || set.Size() != 4 {
+		t.Errorf("unexpected contents: %v", set.Values())
+	}
+
+	it := set.Iterator()
+	set.Add(2)
+	if expected := []interface{}{1, 3, 5, 7}; !equalValues(it.Take(10), expected) {
+		t.Errorf("expected the iterator to ignore later writes")
+	}
+
+	set.Clear()
+	if !set.Empty() || len(set.Values()) != 0 {
+		t.Errorf("expected empty set, got: %v", set.Values())
+	}
+}
+
+func TestShardedSetConcurrent(t *testing.T) {
+	set := NewShardedWith(utils.IntComparator, 4, intHash)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				set.Add(g*1000 + i)
+				if i%2 == 1 {
+					set.Remove(g*1000 + i)
+				}
+			}
+			set.Values()
+		}(g)
+	}
+	wg.Wait()
+	values := set.Values()
+	if len(values) != 4000 {
+		t.Fatalf("expected: %v, got: %v", 4000, len(values))
+	}
+	for i, value := range values {
+		if value != 2*i {
+			t.Fatalf("expected: %v, got: %v", 2*i, value)
+		}
+	}
+}
+
+func BenchmarkParallelAddShardedSet(b *testing.B) {
+	set := NewShardedWith(utils.IntComparator, 64, intHash)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			set.Add(i % 100000)
+			i += 7919
+		}
+	})
+}
+
+func BenchmarkParallelAddSyncSet(b *testing.B) {
+	set := NewSyncSet(NewWithIntComparator())
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			set.Add(i % 100000)
+			i += 7919
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package treeset
+
+// Returns a copy-on-write snapshot of the set in O(1). The snapshot and the
+// set share the tree until either is modified; the first write to each side
+// copies the tree once in O(n), leaving the other untouched. Readers can
+// paginate a consistent version while writers keep mutating the original,
+// at the cost of one tree copy per write that follows a new snapshot.
+// Taking a snapshot is a write as far as synchronization goes: it must not
+// race with other calls on the set. Using the snapshot afterwards needs no
+// synchronization with the set.
+func (set *Set) Snapshot() *Set {
+	if !set.tree.shared {
+		set.tree.shared = true
+	}
+	snapshot := set.newEmpty()
+	snapshot.tree = set.tree
+	return snapshot
+}
+
+// own gives the set a private copy of its tree before a write, if the tree
+// is shared with a snapshot.
+func (set *Set) own() {
+	if set.tree.shared {
+		set.tree = set.tree.clone()
+	}
+}
@@ -0,0 +1,61 @@
+package treeset
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	set := newIntSet(1, 2, 3)
+	snapshot := set.Snapshot()
+
+	set.Add(4)
+	set.Remove(1)
+	if expected := []interface{}{1, 2, 3}; !equalValues(snapshot.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, snapshot.Values())
+	}
+	if expected := []interface{}{2, 3, 4}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	// Writes to the snapshot do not leak back either.
+	snapshot.Clear()
+	if set.Size() != 3 {
+		t.Errorf("expected: %v, got: %v", 3, set.Size())
+	}
+
+	other := set.Snapshot()
+	set.InPlaceDiff(newIntSet(2))
+	set.InPlaceUnion(newIntSet(9))
+	set.Extract(func(v interface{}) bool { return v.(int) == 3 })
+	if expected := []interface{}{2, 3, 4}; !equalValues(other.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, other.Values())
+	}
+}
+
+func TestSnapshotConcurrentReaders(t *testing.T) {
+	set := benchmarkSet(1000)
+	var wg sync.WaitGroup
+	for round := 0; round < 20; round++ {
+		snapshot := set.Snapshot()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values := snapshot.SelectRange(0, snapshot.Size())
+			if len(values) != 1000 {
+				t.Errorf("expected: %v, got: %v", 1000, len(values))
+			}
+		}()
+		set.Remove(round)
+		set.Add(round)
+	}
+	wg.Wait()
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	set := benchmarkSet(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.Snapshot()
+	}
+}
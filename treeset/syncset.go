@@ -0,0 +1,125 @@
+package treeset
+
+import "sync"
+
+// SyncSet wraps a Set with a read-write lock so it can be shared between
+// goroutines. Readers never observe a partially applied write.
+type SyncSet struct {
+	mu  sync.RWMutex
+	set *Set
+}
+
+// Wraps set for concurrent use. The caller must not use set directly afterwards.
+func NewSyncSet(set *Set) *SyncSet {
+	return &SyncSet{set: set}
+}
+
+// Replaces the wrapped set with newSet and returns the previous one.
+// Lets a shared lookup set be rebuilt off to the side and swapped in at once,
+// e.g. for periodically reloaded allowlists. The caller must not use newSet
+// directly afterwards.
+func (ss *SyncSet) Swap(newSet *Set) *Set {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	old := ss.set
+	ss.set = newSet
+	return old
+}
+
+// Adds the items (one or more) to the set.
+func (ss *SyncSet) Add(items ...interface{}) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.set.Add(items...)
+}
+
+// Same as Set.GetOrAdd, atomically.
+func (ss *SyncSet) GetOrAdd(item interface{}) (actual interface{}, existed bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.set.GetOrAdd(item)
+}
+
+// Removes the items (one or more) from the set.
+func (ss *SyncSet) Remove(items ...interface{}) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.set.Remove(items...)
+}
+
+// Check wether items (one or more) are present in the set.
+func (ss *SyncSet) Contains(items ...interface{}) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.set.Contains(items...)
+}
+
+// Returns true if set does not contain any elements.
+func (ss *SyncSet) Empty() bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.set.Empty()
+}
+
+// Returns number of elements within the set.
+func (ss *SyncSet) Size() int {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.set.Size()
+}
+
+// Clears all values in the set.
+func (ss *SyncSet) Clear() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.set.Clear()
+}
+
+// Returns all items in the set.
+func (ss *SyncSet) Values() []interface{} {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.set.Values()
+}
+
+// Returns an independent copy of the set as of now, safe to iterate and
+// modify without holding any lock. See Set.Snapshot for the cost.
+func (ss *SyncSet) Snapshot() *Set {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.set.Snapshot()
+}
+
+// Calls f for every item in order. Iteration runs over a copy taken under the
+// read lock, so f sees a consistent set and may call back into ss (including
+// writes) without deadlocking; writes made by f are not visible to the loop.
+func (ss *SyncSet) Each(f func(item interface{})) {
+	for _, item := range ss.Values() {
+		f(item)
+	}
+}
+
+// Runs f with the read lock held, for multi-step reads that must agree with
+// each other (e.g. Size then Values). f must not modify set, keep it after
+// returning, or call back into ss.
+func (ss *SyncSet) Read(f func(set *Set)) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	f(ss.set)
+}
+
+// Runs f with the write lock held, for read-modify-write sequences such as
+// "remove X only if Y is present". f must not keep set after returning or
+// call back into ss.
+func (ss *SyncSet) Update(f func(set *Set)) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	f(ss.set)
+}
+
+// Same as Set.Validate, under the read lock.
+func (ss *SyncSet) Validate() error {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.set.Validate()
+}
@@ -0,0 +1,100 @@
+package treeset
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncSetSwap(t *testing.T) {
+	even, odd := NewWithIntComparator(), NewWithIntComparator()
+	for i := 0; i < 100; i++ {
+		even.Add(2 * i)
+		odd.Add(2*i + 1)
+	}
+	set := NewSyncSet(even.Clone())
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				values := set.Values()
+				if len(values) != 100 {
+					t.Errorf("expected a complete set, got %d items", len(values))
+					return
+				}
+				parity := values[0].(int) % 2
+				for _, v := range values {
+					if v.(int)%2 != parity {
+						t.Errorf("expected a consistent set, got: %v", values)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		if i%2 == 0 {
+			set.Swap(odd.Clone())
+		} else {
+			set.Swap(even.Clone())
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	old := set.Swap(NewWithIntComparator())
+	if old.Size() != 100 || !set.Empty() {
+		t.Errorf("expected swap to return the previous set")
+	}
+}
+
+func TestSyncSetUpdateIsAtomic(t *testing.T) {
+	set := NewSyncSet(NewWithIntComparator())
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// Append the next integer after the current maximum.
+				set.Update(func(s *Set) {
+					s.Add(s.Size())
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	set.Read(func(s *Set) {
+		if s.Size() != 800 {
+			t.Errorf("expected: %v, got: %v", 800, s.Size())
+		}
+	})
+}
+
+func TestSyncSetEachAllowsWrites(t *testing.T) {
+	set := NewSyncSet(newIntSet(1, 2, 3))
+	var seen []interface{}
+	set.Each(func(item interface{}) {
+		seen = append(seen, item)
+		set.Remove(item)
+	})
+	if !equalValues(seen, []interface{}{1, 2, 3}) || !set.Empty() {
+		t.Errorf("expected: %v, got: %v (remaining %v)", []int{1, 2, 3}, seen, set.Values())
+	}
+
+	snapshot := set.Snapshot()
+	set.Add(4)
+	if !snapshot.Empty() {
+		t.Errorf("expected snapshot to be independent, got: %v", snapshot.Values())
+	}
+}
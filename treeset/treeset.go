@@ -0,0 +1,694 @@
+/*
+Copyright (c) Emir Pasic, All rights reserved.
+
+This library is free software; you can redistribute it and/or
+modify it under the terms of the GNU Lesser General Public
+License as published by the Free Software Foundation; either
+version 3.0 of the License, or (at your option) any later version.
+
+This library is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public
+License along with this library. See the file LICENSE included
+with this distribution for more information.
+*/
+
+// Implementation of an ordered set backed by a red-black tree.
+// Structure is not thread safe.
+// References: http://en.wikipedia.org/wiki/Set_%28abstract_data_type%29
+
+package treeset
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/emirpasic/gods/sets"
+	"github.com/emirpasic/gods/utils"
+)
+
+func assertInterfaceImplementation() {
+	var _ sets.Set = (*Set)(nil)
+}
+
+type Set struct {
+	tree       *rbTree
+	comparator utils.Comparator
+	metrics    Metrics
+	itemType   reflect.Type
+}
+
+var itemExists = struct{}{}
+
+// Instantiates a new empty set with the custom comparator.
+func NewWith(comparator utils.Comparator) *Set {
+	return &Set{tree: newRBTree(comparator), comparator: comparator}
+}
+
+// Instantiates a new empty set with the IntComparator, i.e. keys are of type int.
+func NewWithIntComparator() *Set {
+	return NewWith(utils.IntComparator)
+}
+
+// Instantiates a new empty set with the StringComparator, i.e. keys are of type string.
+func NewWithStringComparator() *Set {
+	return NewWith(utils.StringComparator)
+}
+
+// Instantiates a new empty set with the custom comparator whose tree nodes
+// are recycled through a shared sync.Pool: Remove and Clear return nodes to
+// the pool and Add takes them from it, which cuts GC pressure for high-churn
+// sets. Clear becomes O(n) since it walks the tree to free the nodes.
+// Sets derived from a pooled set (Clone, Inter, Filter, ...) are pooled too.
+func NewPooledWith(comparator utils.Comparator) *Set {
+	set := NewWith(comparator)
+	set.tree.pooled = true
+	return set
+}
+
+// Instantiates a new empty set ordered by primary, falling back to tieBreak
+// whenever primary reports two items as equal.
+// A set treats comparator-equal items as duplicates, so a primary comparator
+// that only looks at one field (e.g. a timestamp) would silently collapse
+// distinct items sharing that field. The tieBreak keeps them apart.
+func NewWithTieBreak(primary utils.Comparator, tieBreak utils.Comparator) *Set {
+	return NewWith(withTieBreak(primary, tieBreak))
+}
+
+// Instantiates a new set from values already sorted by comparator, building
+// the tree in O(n) instead of inserting item by item in O(n log n).
+// Comparator-equal neighbours are kept once. If values turn out not to be
+// sorted, falls back to plain insertion, so the result is always correct.
+func NewFromSorted(comparator utils.Comparator, values ...interface{}) *Set {
+	set := NewWith(comparator)
+	keys := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if len(keys) > 0 {
+			compare := comparator(keys[len(keys)-1], value)
+			if compare == 0 {
+				continue
+			}
+			if compare > 0 {
+				set.Add(values...)
+				return set
+			}
+		}
+		keys = append(keys, value)
+	}
+	set.tree.loadSorted(keys, itemExists)
+	return set
+}
+
+// newEmpty returns an empty set with the same comparator as set.
+func (set *Set) newEmpty() *Set {
+	return &Set{tree: set.newTree(), comparator: set.comparator, itemType: set.itemType}
+}
+
+// newTree returns an empty tree configured like the set's current one.
+func (set *Set) newTree() *rbTree {
+	tree := newRBTree(set.comparator)
+	tree.pooled = set.tree.pooled
+	return tree
+}
+
+// Returns a copy of the set in O(n), copying the tree structure as is.
+func (set *Set) Clone() *Set {
+	newSet := set.newEmpty()
+	newSet.tree = set.tree.clone()
+	return newSet
+}
+
+// Returns a copy of the set ordered by coarser, a comparator that considers
+// more items equal than the set's own (e.g. timestamps rounded to the minute).
+// The copy is deliberately lossy: items coarser reports as equal collapse into
+// one representative, which is the first of them in the receiver's order.
+func (set *Set) ViewWith(coarser utils.Comparator) *Set {
+	view := NewWith(coarser)
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		if _, contains := view.tree.Get(node.Key); !contains {
+			view.tree.Put(node.Key, itemExists)
+		}
+	}
+	return view
+}
+
+// Clears the set and loads all items of otherSet, re-sorting and
+// deduplicating them under the receiver's comparator. Unlike Clone this does
+// not assume both sets order items the same way. If the receiver's
+// comparator is coarser than otherSet's, items it considers equal collapse
+// into one.
+func (set *Set) LoadFrom(otherSet *Set) {
+	values := otherSet.Values()
+	set.Clear()
+	set.Add(values...)
+}
+
+// Set algebra walks both trees in order and builds the result tree directly
+// from the merge in O(n + m), without intermediate slices. For items present
+// in both sets, results keep the receiver's one.
+
+func (set *Set) Union(otherSet *Set) *Set {
+	return set.merged(otherSet, func(inSet, inOther bool) bool { return true })
+}
+
+// Returns the union of set and otherSet, plus the items present in both
+// (their intersection) in ascending order.
+// Handy to log conflicting keys while merging.
+func (set *Set) UnionReporting(otherSet *Set) (result *Set, collisions []interface{}) {
+	result = set.mergedVisiting(otherSet, func(inSet, inOther bool) bool { return true }, func(key interface{}, inSet, inOther bool) {
+		if inSet && inOther {
+			collisions = append(collisions, key)
+		}
+	})
+	return result, collisions
+}
+
+// Adds the items of otherSet one by one, which beats rebuilding the whole
+// tree when otherSet is the smaller side, as with incremental fan-in.
+func (set *Set) InPlaceUnion(otherSet *Set) {
+	if otherSet == set {
+		return
+	}
+	set.own()
+	for node := leftmost(otherSet.tree); node != nil; node = successor(node) {
+		set.tree.Put(node.Key, itemExists)
+	}
+}
+
+func (set *Set) Diff(otherSet *Set) *Set {
+	return set.merged(otherSet, func(inSet, inOther bool) bool { return inSet && !inOther })
+}
+
+// Removes the items of otherSet one by one.
+func (set *Set) InPlaceDiff(otherSet *Set) {
+	if otherSet == set {
+		set.Clear()
+		return
+	}
+	set.own()
+	for node := leftmost(otherSet.tree); node != nil; node = successor(node) {
+		set.tree.Remove(node.Key)
+	}
+}
+
+func (set *Set) Inter(otherSet *Set) *Set {
+	return set.merged(otherSet, func(inSet, inOther bool) bool { return inSet && inOther })
+}
+
+// Returns true as soon as set and otherSet are found to share at least n items.
+// The merge walk stops once the count reaches n, so it is cheaper than
+// computing the full intersection for threshold checks.
+// Returns true if n <= 0.
+func (set *Set) IntersectsAtLeast(otherSet *Set, n int) bool {
+	if n <= 0 {
+		return true
+	}
+	if set.Size() < n || otherSet.Size() < n {
+		return false
+	}
+
+	count := 0
+	i, j := leftmost(set.tree), leftmost(otherSet.tree)
+	for i != nil && j != nil {
+		compare := set.comparator(i.Key, j.Key)
+		switch {
+		case compare == 0:
+			count++
+			if count >= n {
+				return true
+			}
+			i = successor(i)
+			j = successor(j)
+		case compare < 0:
+			i = successor(i)
+		case compare > 0:
+			j = successor(j)
+		}
+	}
+	return false
+}
+
+func (set *Set) InPlaceInter(otherSet *Set) {
+	set.tree = set.Inter(otherSet).tree
+}
+
+// Returns the items present in exactly one of set and otherSet, in a single
+// merge walk rather than two Diffs and a Union.
+func (set *Set) SymDiff(otherSet *Set) *Set {
+	return set.merged(otherSet, func(inSet, inOther bool) bool { return inSet != inOther })
+}
+
+// Keeps only the items present in exactly one of set and otherSet.
+// Both sets are walked once in order and the receiver's tree is rebuilt from
+// the merge, instead of looking up and toggling every item of otherSet.
+func (set *Set) InPlaceSymDiff(otherSet *Set) {
+	set.tree = set.SymDiff(otherSet).tree
+}
+
+// Same as InPlaceSymDiff.
+func (set *Set) InPlaceSymmetricDifference(otherSet *Set) {
+	set.InPlaceSymDiff(otherSet)
+}
+
+// Reinserts every item into a fresh tree, restoring the ordering after items
+// were mutated in place in a way that changed how they compare (a misuse
+// that silently breaks the tree). Only needed after such external mutation;
+// costs O(n log n).
+func (set *Set) Rebuild() {
+	values := set.Values()
+	set.tree = set.newTree()
+	set.Add(values...)
+}
+
+// Adds the items (one or more) to the set.
+func (set *Set) Add(items ...interface{}) {
+	set.own()
+	for _, item := range items {
+		set.tree.Put(item, itemExists)
+		if set.metrics != nil {
+			set.metrics.ObserveAdd()
+		}
+	}
+}
+
+// Returns the stored item comparator-equal to item with existed=true if there
+// is one, without inserting item. Otherwise inserts item and returns it with
+// existed=false. This is the interning primitive, like sync.Map's LoadOrStore.
+func (set *Set) GetOrAdd(item interface{}) (actual interface{}, existed bool) {
+	node := set.lookup(item)
+	if set.metrics != nil {
+		set.metrics.ObserveLookup(node != nil)
+	}
+	if node != nil {
+		return node.Key, true
+	}
+	set.Add(item)
+	return item, false
+}
+
+// Removes the items (one or more) from the set.
+func (set *Set) Remove(items ...interface{}) {
+	set.own()
+	for _, item := range items {
+		set.tree.Remove(item)
+		if set.metrics != nil {
+			set.metrics.ObserveRemove()
+		}
+	}
+}
+
+// Removes the items (one or more) from the set and returns the ones that were
+// actually present, in argument order.
+func (set *Set) RemoveReturning(items ...interface{}) []interface{} {
+	removed := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if set.lookup(item) != nil {
+			set.own()
+			set.tree.Remove(item)
+			removed = append(removed, item)
+		}
+		if set.metrics != nil {
+			set.metrics.ObserveRemove()
+		}
+	}
+	return removed
+}
+
+// Replaces the stored item comparator-equal to oldItem with newItem, but only
+// if cond approves the stored item (e.g. its version field still matches).
+// Returns whether the swap happened; false if oldItem is not present.
+func (set *Set) ReplaceIf(oldItem, newItem interface{}, cond func(stored interface{}) bool) bool {
+	node := set.lookup(oldItem)
+	if node == nil || !cond(node.Key) {
+		return false
+	}
+	set.own()
+	set.tree.Remove(node.Key)
+	set.tree.Put(newItem, itemExists)
+	return true
+}
+
+// Removes all items satisfying predicate from the set and returns them as a
+// new set with the same comparator.
+func (set *Set) Extract(predicate func(value interface{}) bool) *Set {
+	extracted := set.newEmpty()
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		if predicate(node.Key) {
+			extracted.tree.Put(node.Key, itemExists)
+		}
+	}
+	if !extracted.Empty() {
+		set.own()
+	}
+	for node := leftmost(extracted.tree); node != nil; node = successor(node) {
+		set.tree.Remove(node.Key)
+		if set.metrics != nil {
+			set.metrics.ObserveRemove()
+		}
+	}
+	return extracted
+}
+
+// Check wether items (one or more) are present in the set.
+// All items have to be present in the set for the method to return true.
+// Returns true if no arguments are passed at all, i.e. set is always superset of empty set.
+func (set *Set) Contains(items ...interface{}) bool {
+	for _, item := range items {
+		hit := set.lookup(item) != nil
+		if set.metrics != nil {
+			set.metrics.ObserveLookup(hit)
+		}
+		if !hit {
+			return false
+		}
+	}
+	return true
+}
+
+// Check wether any of the items (one or more) is present in the set.
+// Returns false if no arguments are passed at all.
+func (set *Set) ContainsAny(items ...interface{}) bool {
+	return set.ContainsAnySlice(items)
+}
+
+// Same as Contains, but takes a slice. Returns true for an empty slice.
+func (set *Set) ContainsAllSlice(items []interface{}) bool {
+	return set.Contains(items...)
+}
+
+// Same as ContainsAny, but takes a slice. Returns false for an empty slice.
+func (set *Set) ContainsAnySlice(items []interface{}) bool {
+	for _, item := range items {
+		hit := set.lookup(item) != nil
+		if set.metrics != nil {
+			set.metrics.ObserveLookup(hit)
+		}
+		if hit {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if set does not contain any elements.
+func (set *Set) Empty() bool {
+	return set.tree.Size() == 0
+}
+
+// Returns number of elements within the set.
+func (set *Set) Size() int {
+	return set.tree.Size()
+}
+
+// Clears all values in the set. Not reported to the metrics hooks.
+func (set *Set) Clear() {
+	if set.tree.shared {
+		set.tree = set.newTree()
+		return
+	}
+	set.tree.Clear()
+}
+
+// Returns all items in the set.
+func (set *Set) Values() []interface{} {
+	return set.tree.Keys()
+}
+
+// Returns all items in the set in descending order.
+func (set *Set) ReverseValues() []interface{} {
+	return set.ReverseValuesInto(nil)
+}
+
+// Fills buf with the items in ascending order and returns it resliced to the
+// set's size. buf is reused when its capacity is large enough, so the result
+// aliases buf; otherwise a new slice is allocated.
+func (set *Set) ValuesInto(buf []interface{}) []interface{} {
+	buf = resize(buf, set.Size())
+	i := 0
+	for node := leftmost(set.tree); node != nil; node = successor(node) {
+		buf[i] = node.Key
+		i++
+	}
+	return buf
+}
+
+// Same as ValuesInto, but fills buf in descending order.
+func (set *Set) ReverseValuesInto(buf []interface{}) []interface{} {
+	buf = resize(buf, set.Size())
+	i := 0
+	for node := rightmost(set.tree); node != nil; node = predecessor(node) {
+		buf[i] = node.Key
+		i++
+	}
+	return buf
+}
+
+func resize(buf []interface{}, size int) []interface{} {
+	if cap(buf) < size {
+		return make([]interface{}, size)
+	}
+	return buf[:size]
+}
+
+// Returns the two items value would fall between if it were inserted, and
+// whether value is already present in the set. On an exact match lower and
+// upper are the items right before and after the matching one.
+// A missing bound (value sorts before the first or after the last item) is nil.
+func (set *Set) FitBetween(value interface{}) (lower interface{}, upper interface{}, exactMatch bool) {
+	lowerNode, upperNode, exact := set.bracket(value)
+	if lowerNode != nil {
+		lower = lowerNode.Key
+	}
+	if upperNode != nil {
+		upper = upperNode.Key
+	}
+	return lower, upper, exact != nil
+}
+
+// Visits the items in order of increasing distance, in sorted positions, from
+// pivot: the pivot itself first if present (distance 0), then alternating the
+// nearest remaining lower and higher items, lower first. The nearest items
+// on either side of an absent pivot are at distance 1.
+// Useful for nearest-first scans such as finding the closest free slot.
+func (set *Set) EachAround(pivot interface{}, f func(value interface{}, distanceRank int)) {
+	lower, upper, exact := set.bracket(pivot)
+	if exact != nil {
+		f(exact.Key, 0)
+	}
+	for distance := 1; lower != nil || upper != nil; distance++ {
+		if lower != nil {
+			f(lower.Key, distance)
+			lower = predecessor(lower)
+		}
+		if upper != nil {
+			f(upper.Key, distance)
+			upper = successor(upper)
+		}
+	}
+}
+
+// Returns the number of items less than or equal to upTo.
+func (set *Set) CumulativeCount(upTo interface{}) int {
+	count, found := set.Rank(upTo)
+	if found {
+		count++
+	}
+	return count
+}
+
+// Returns the fraction of items less than or equal to upTo, i.e. the
+// empirical distribution function at upTo. Returns 0 for an empty set.
+func (set *Set) CumulativeFraction(upTo interface{}) float64 {
+	if set.Empty() {
+		return 0
+	}
+	return float64(set.CumulativeCount(upTo)) / float64(set.Size())
+}
+
+func (set *Set) String() string {
+	str := "TreeSet\n"
+	items := []string{}
+	for _, v := range set.tree.Keys() {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	str += strings.Join(items, ", ")
+	return str
+}
+
+// leftmost returns the node holding the smallest key, or nil if tree is empty.
+func leftmost(tree *rbTree) *rbNode {
+	node := tree.Root
+	if node == nil {
+		return nil
+	}
+	for node.Left != nil {
+		node = node.Left
+	}
+	return node
+}
+
+// rightmost returns the node holding the largest key, or nil if tree is empty.
+func rightmost(tree *rbTree) *rbNode {
+	node := tree.Root
+	if node == nil {
+		return nil
+	}
+	for node.Right != nil {
+		node = node.Right
+	}
+	return node
+}
+
+// successor returns the in-order successor of node, or nil if node is the last one.
+func successor(node *rbNode) *rbNode {
+	if node.Right != nil {
+		node = node.Right
+		for node.Left != nil {
+			node = node.Left
+		}
+		return node
+	}
+	for node.Parent != nil && node == node.Parent.Right {
+		node = node.Parent
+	}
+	return node.Parent
+}
+
+// predecessor returns the in-order predecessor of node, or nil if node is the first one.
+func predecessor(node *rbNode) *rbNode {
+	if node.Left != nil {
+		node = node.Left
+		for node.Right != nil {
+			node = node.Right
+		}
+		return node
+	}
+	for node.Parent != nil && node == node.Parent.Left {
+		node = node.Parent
+	}
+	return node.Parent
+}
+
+// bracket returns the nodes holding the nearest items strictly below and above
+// value, and the node holding value itself if present. Missing ones are nil.
+func (set *Set) bracket(value interface{}) (lower, upper, exact *rbNode) {
+	node := set.tree.Root
+	for node != nil {
+		compare := set.comparator(value, node.Key)
+		switch {
+		case compare == 0:
+			return predecessor(node), successor(node), node
+		case compare < 0:
+			upper = node
+			node = node.Left
+		case compare > 0:
+			lower = node
+			node = node.Right
+		}
+	}
+	return lower, upper, nil
+}
+
+// ceiling returns the node holding the smallest item greater than or equal
+// to value, or nil if there is none.
+func (set *Set) ceiling(value interface{}) *rbNode {
+	var found *rbNode
+	node := set.tree.Root
+	for node != nil {
+		compare := set.comparator(value, node.Key)
+		switch {
+		case compare == 0:
+			return node
+		case compare < 0:
+			found = node
+			node = node.Left
+		case compare > 0:
+			node = node.Right
+		}
+	}
+	return found
+}
+
+// floor returns the node holding the largest item less than or equal to
+// value, or nil if there is none.
+func (set *Set) floor(value interface{}) *rbNode {
+	var found *rbNode
+	node := set.tree.Root
+	for node != nil {
+		compare := set.comparator(value, node.Key)
+		switch {
+		case compare == 0:
+			return node
+		case compare < 0:
+			node = node.Left
+		case compare > 0:
+			found = node
+			node = node.Right
+		}
+	}
+	return found
+}
+
+// lookup returns the node holding the item comparator-equal to key, or nil.
+// Sets ordered by the int or string comparator compare keys inline instead of
+// calling through the comparator.
+func (set *Set) lookup(key interface{}) *rbNode {
+	switch set.tree.kind {
+	case intComparator:
+		if k, ok := key.(int); ok {
+			return lookupInt(set.tree.Root, k)
+		}
+	case stringComparator:
+		if k, ok := key.(string); ok {
+			return lookupString(set.tree.Root, k)
+		}
+	}
+
+	node := set.tree.Root
+	for node != nil {
+		compare := set.comparator(key, node.Key)
+		switch {
+		case compare == 0:
+			return node
+		case compare < 0:
+			node = node.Left
+		case compare > 0:
+			node = node.Right
+		}
+	}
+	return nil
+}
+
+func lookupInt(node *rbNode, key int) *rbNode {
+	for node != nil {
+		nodeKey := node.Key.(int)
+		switch {
+		case key < nodeKey:
+			node = node.Left
+		case key > nodeKey:
+			node = node.Right
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+func lookupString(node *rbNode, key string) *rbNode {
+	for node != nil {
+		nodeKey := node.Key.(string)
+		switch {
+		case key < nodeKey:
+			node = node.Left
+		case key > nodeKey:
+			node = node.Right
+		default:
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,651 @@
+package treeset
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func newIntSet(items ...int) *Set {
+	set := NewWithIntComparator()
+	for _, item := range items {
+		set.Add(item)
+	}
+	return set
+}
+
+func countingComparator(calls *int) utils.Comparator {
+	return func(a, b interface{}) int {
+		*calls++
+		return utils.IntComparator(a, b)
+	}
+}
+
+func TestIntersectsAtLeast(t *testing.T) {
+	a := newIntSet(1, 2, 3, 5, 8, 13)
+	b := newIntSet(2, 3, 4, 8, 16)
+	overlap := a.Inter(b).Size()
+
+	for n := -1; n <= 6; n++ {
+		expected := n <= overlap
+		if got := a.IntersectsAtLeast(b, n); got != expected {
+			t.Errorf("n: %d, expected: %v, got: %v", n, expected, got)
+		}
+	}
+	if !a.IntersectsAtLeast(NewWithIntComparator(), 0) {
+		t.Errorf("expected: %v, got: %v", true, false)
+	}
+}
+
+func TestIntersectsAtLeastStopsEarly(t *testing.T) {
+	var calls int
+	a := NewWith(countingComparator(&calls))
+	b := NewWith(countingComparator(&calls))
+	for i := 0; i < 1000; i++ {
+		a.Add(i)
+		b.Add(i)
+	}
+
+	calls = 0
+	if !a.IntersectsAtLeast(b, 3) {
+		t.Errorf("expected: %v, got: %v", true, false)
+	}
+	if calls != 3 {
+		t.Errorf("expected %d comparisons, got: %d", 3, calls)
+	}
+}
+
+type post struct {
+	ts int
+	id int
+}
+
+func byTimestamp(a, b interface{}) int {
+	return utils.IntComparator(a.(post).ts, b.(post).ts)
+}
+
+func byID(a, b interface{}) int {
+	return utils.IntComparator(a.(post).id, b.(post).id)
+}
+
+func TestNewWithTieBreak(t *testing.T) {
+	posts := []interface{}{post{ts: 10, id: 2}, post{ts: 10, id: 1}, post{ts: 5, id: 3}}
+
+	collapsed := NewWith(byTimestamp)
+	collapsed.Add(posts...)
+	if collapsed.Size() != 2 {
+		t.Errorf("expected: %d, got: %d", 2, collapsed.Size())
+	}
+
+	set := NewWithTieBreak(byTimestamp, byID)
+	set.Add(posts...)
+	if set.Size() != 3 {
+		t.Errorf("expected: %d, got: %d", 3, set.Size())
+	}
+	expected := []post{{ts: 5, id: 3}, {ts: 10, id: 1}, {ts: 10, id: 2}}
+	for i, v := range set.Values() {
+		if v.(post) != expected[i] {
+			t.Errorf("expected: %v, got: %v", expected[i], v)
+		}
+	}
+}
+
+func TestFitBetween(t *testing.T) {
+	set := newIntSet(10, 20, 30)
+	cases := []struct {
+		value        int
+		lower, upper interface{}
+		exact        bool
+	}{
+		{5, nil, 10, false},
+		{10, nil, 20, true},
+		{15, 10, 20, false},
+		{20, 10, 30, true},
+		{25, 20, 30, false},
+		{30, 20, nil, true},
+		{35, 30, nil, false},
+	}
+	for _, c := range cases {
+		lower, upper, exact := set.FitBetween(c.value)
+		if lower != c.lower || upper != c.upper || exact != c.exact {
+			t.Errorf("value: %d, expected: (%v, %v, %v), got: (%v, %v, %v)",
+				c.value, c.lower, c.upper, c.exact, lower, upper, exact)
+		}
+	}
+
+	lower, upper, exact := NewWithIntComparator().FitBetween(1)
+	if lower != nil || upper != nil || exact {
+		t.Errorf("expected: (<nil>, <nil>, false), got: (%v, %v, %v)", lower, upper, exact)
+	}
+}
+
+func TestRemoveReturning(t *testing.T) {
+	set := newIntSet(1, 2, 3, 4)
+	removed := set.RemoveReturning(4, 9, 1, 1, 7)
+	if len(removed) != 2 || removed[0] != 4 || removed[1] != 1 {
+		t.Errorf("expected: %v, got: %v", []int{4, 1}, removed)
+	}
+	if set.Size() != 2 || !set.Contains(2, 3) {
+		t.Errorf("unexpected values left: %v", set.Values())
+	}
+	if removed := set.RemoveReturning(); len(removed) != 0 {
+		t.Errorf("expected nothing removed, got: %v", removed)
+	}
+}
+
+func TestContainsSlice(t *testing.T) {
+	set := newIntSet(1, 2, 3)
+	cases := []struct {
+		items    []interface{}
+		all, any bool
+	}{
+		{[]interface{}{1, 3}, true, true},
+		{[]interface{}{1, 4}, false, true},
+		{[]interface{}{4, 5}, false, false},
+		{[]interface{}{}, true, false},
+		{nil, true, false},
+	}
+	for _, c := range cases {
+		if got := set.ContainsAllSlice(c.items); got != c.all {
+			t.Errorf("ContainsAllSlice(%v), expected: %v, got: %v", c.items, c.all, got)
+		}
+		if got := set.ContainsAnySlice(c.items); got != c.any {
+			t.Errorf("ContainsAnySlice(%v), expected: %v, got: %v", c.items, c.any, got)
+		}
+		if got := set.ContainsAny(c.items...); got != c.any {
+			t.Errorf("ContainsAny(%v), expected: %v, got: %v", c.items, c.any, got)
+		}
+	}
+}
+
+func toggleSymmetricDifference(set, otherSet *Set) {
+	for _, item := range otherSet.Values() {
+		if set.Contains(item) {
+			set.Remove(item)
+		} else {
+			set.Add(item)
+		}
+	}
+}
+
+func equalValues(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInPlaceSymmetricDifference(t *testing.T) {
+	pairs := [][2]*Set{
+		{newIntSet(1, 2, 3, 5), newIntSet(2, 4, 5, 6)},
+		{newIntSet(1, 2), newIntSet()},
+		{newIntSet(), newIntSet(3)},
+		{newIntSet(1, 2), newIntSet(1, 2)},
+	}
+	for _, pair := range pairs {
+		expected := pair[0].Clone()
+		toggleSymmetricDifference(expected, pair[1])
+		got := pair[0].Clone()
+		got.InPlaceSymmetricDifference(pair[1])
+		if !equalValues(expected.Values(), got.Values()) {
+			t.Errorf("expected: %v, got: %v", expected.Values(), got.Values())
+		}
+	}
+}
+
+func benchmarkSymmetricDifferencePair() (*Set, *Set) {
+	a, b := NewWithIntComparator(), NewWithIntComparator()
+	for i := 0; i < 20000; i++ {
+		a.Add(i * 7919 % 20000)
+		b.Add((i*7919 + 10000) % 30000)
+	}
+	return a, b
+}
+
+func BenchmarkInPlaceSymmetricDifference(b *testing.B) {
+	set, otherSet := benchmarkSymmetricDifferencePair()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := set.Clone()
+		b.StartTimer()
+		s.InPlaceSymmetricDifference(otherSet)
+	}
+}
+
+func BenchmarkToggleSymmetricDifference(b *testing.B) {
+	set, otherSet := benchmarkSymmetricDifferencePair()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := set.Clone()
+		b.StartTimer()
+		toggleSymmetricDifference(s, otherSet)
+	}
+}
+
+func byTime(a, b interface{}) int {
+	x, y := a.(time.Time), b.(time.Time)
+	switch {
+	case x.Before(y):
+		return -1
+	case x.After(y):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func byMinute(a, b interface{}) int {
+	return byTime(a.(time.Time).Truncate(time.Minute), b.(time.Time).Truncate(time.Minute))
+}
+
+func TestViewWith(t *testing.T) {
+	base := time.Unix(1473000000, 0).Truncate(time.Minute)
+	set := NewWith(byTime)
+	for _, offset := range []time.Duration{50, 10, 30, 70, 65, 200} {
+		set.Add(base.Add(offset * time.Second))
+	}
+
+	view := set.ViewWith(byMinute)
+	expected := []time.Time{base.Add(10 * time.Second), base.Add(65 * time.Second), base.Add(200 * time.Second)}
+	values := view.Values()
+	if len(values) != len(expected) {
+		t.Fatalf("expected: %v, got: %v", expected, values)
+	}
+	for i, v := range values {
+		if !v.(time.Time).Equal(expected[i]) {
+			t.Errorf("expected: %v, got: %v", expected[i], v)
+		}
+	}
+	if set.Size() != 6 {
+		t.Errorf("expected receiver untouched, got: %v", set.Values())
+	}
+}
+
+type versioned struct {
+	id      int
+	version int
+}
+
+func byVersionedID(a, b interface{}) int {
+	return utils.IntComparator(a.(versioned).id, b.(versioned).id)
+}
+
+func TestReplaceIf(t *testing.T) {
+	set := NewWith(byVersionedID)
+	set.Add(versioned{id: 1, version: 1}, versioned{id: 2, version: 1})
+	versionIs := func(v int) func(stored interface{}) bool {
+		return func(stored interface{}) bool { return stored.(versioned).version == v }
+	}
+
+	if !set.ReplaceIf(versioned{id: 1}, versioned{id: 1, version: 2}, versionIs(1)) {
+		t.Errorf("expected swap to happen")
+	}
+	if set.ReplaceIf(versioned{id: 2}, versioned{id: 2, version: 3}, versionIs(2)) {
+		t.Errorf("expected swap to be rejected")
+	}
+	if set.ReplaceIf(versioned{id: 3}, versioned{id: 3, version: 1}, versionIs(0)) {
+		t.Errorf("expected missing item not to be swapped")
+	}
+
+	expected := []versioned{{id: 1, version: 2}, {id: 2, version: 1}}
+	for i, v := range set.Values() {
+		if v.(versioned) != expected[i] {
+			t.Errorf("expected: %v, got: %v", expected[i], v)
+		}
+	}
+}
+
+func TestCumulativeCount(t *testing.T) {
+	set := newIntSet(10, 20, 30, 40)
+	cases := []struct {
+		upTo     int
+		count    int
+		fraction float64
+	}{
+		{5, 0, 0},
+		{10, 1, 0.25},
+		{25, 2, 0.5},
+		{40, 4, 1},
+		{100, 4, 1},
+	}
+	for _, c := range cases {
+		if got := set.CumulativeCount(c.upTo); got != c.count {
+			t.Errorf("CumulativeCount(%d), expected: %d, got: %d", c.upTo, c.count, got)
+		}
+		if got := set.CumulativeFraction(c.upTo); got != c.fraction {
+			t.Errorf("CumulativeFraction(%d), expected: %v, got: %v", c.upTo, c.fraction, got)
+		}
+	}
+
+	empty := NewWithIntComparator()
+	if empty.CumulativeCount(1) != 0 || empty.CumulativeFraction(1) != 0 {
+		t.Errorf("expected zero for empty set")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	original := newIntSet(1, 2, 3, 4, 5, 6, 7)
+	set := original.Clone()
+	even := set.Extract(func(value interface{}) bool { return value.(int)%2 == 0 })
+
+	if !equalValues(even.Values(), []interface{}{2, 4, 6}) {
+		t.Errorf("expected: %v, got: %v", []int{2, 4, 6}, even.Values())
+	}
+	if !equalValues(set.Values(), []interface{}{1, 3, 5, 7}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 3, 5, 7}, set.Values())
+	}
+	if set.Inter(even).Size() != 0 {
+		t.Errorf("expected no overlap, got: %v", set.Inter(even).Values())
+	}
+	if !equalValues(set.Union(even).Values(), original.Values()) {
+		t.Errorf("expected: %v, got: %v", original.Values(), set.Union(even).Values())
+	}
+}
+
+func TestBuiltinComparatorLookup(t *testing.T) {
+	fast, generic := NewWithIntComparator(), NewWith(genericIntComparator)
+	for i := 0; i < 200; i++ {
+		fast.Add(i * 37 % 101)
+		generic.Add(i * 37 % 101)
+	}
+	for i := -5; i < 110; i++ {
+		if fast.Contains(i) != generic.Contains(i) {
+			t.Errorf("Contains(%d) differs, fast: %v, generic: %v", i, fast.Contains(i), generic.Contains(i))
+		}
+	}
+	if !equalValues(fast.Values(), generic.Values()) {
+		t.Errorf("expected: %v, got: %v", generic.Values(), fast.Values())
+	}
+	checkRBTree(t, fast.tree.Root)
+	if fast.Clone().tree.kind != intComparator {
+		t.Errorf("expected clone to keep the int comparator fast path")
+	}
+	derived := []*Set{
+		NewWith(utils.IntComparator),
+		NewFromSorted(utils.IntComparator, 1, 2, 3),
+		NewFromSortedSlices(utils.IntComparator, []interface{}{1, 2}),
+		fast.Union(generic),
+		generic.ViewWith(IntComparator),
+	}
+	loaded := NewWithIntComparator()
+	loaded.LoadFrom(generic)
+	derived = append(derived, loaded)
+	for i, set := range derived {
+		if set.tree.kind != intComparator {
+			t.Errorf("expected set %d to take the int comparator fast path", i)
+		}
+	}
+	if generic.tree.kind != customComparator {
+		t.Errorf("expected a wrapped comparator to take the generic path")
+	}
+
+	strs := NewWithStringComparator()
+	strs.Add("b", "a", "c")
+	if !strs.Contains("a", "b", "c") || strs.Contains("d") {
+		t.Errorf("unexpected string lookup result for: %v", strs.Values())
+	}
+}
+
+// genericIntComparator orders ints like utils.IntComparator but is not
+// recognized as built in, so it always goes through the comparator.
+func genericIntComparator(a, b interface{}) int {
+	return utils.IntComparator(a, b)
+}
+
+func benchmarkAdd(b *testing.B, newSet func() *Set) {
+	set := newSet()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%10000 == 0 {
+			set = newSet()
+		}
+		set.Add(i * 7919 % 10000)
+	}
+}
+
+func BenchmarkAddIntComparator(b *testing.B) {
+	benchmarkAdd(b, NewWithIntComparator)
+}
+
+func BenchmarkAddGenericComparator(b *testing.B) {
+	benchmarkAdd(b, func() *Set { return NewWith(genericIntComparator) })
+}
+
+func TestEachAround(t *testing.T) {
+	set := newIntSet(1, 3, 5, 7, 9, 11)
+	type visit struct{ value, distance int }
+	collect := func(pivot int) []visit {
+		var visits []visit
+		set.EachAround(pivot, func(value interface{}, distanceRank int) {
+			visits = append(visits, visit{value.(int), distanceRank})
+		})
+		return visits
+	}
+
+	cases := []struct {
+		pivot    int
+		expected []visit
+	}{
+		{5, []visit{{5, 0}, {3, 1}, {7, 1}, {1, 2}, {9, 2}, {11, 3}}},
+		{6, []visit{{5, 1}, {7, 1}, {3, 2}, {9, 2}, {1, 3}, {11, 3}}},
+		{0, []visit{{1, 1}, {3, 2}, {5, 3}, {7, 4}, {9, 5}, {11, 6}}},
+	}
+	for _, c := range cases {
+		got := collect(c.pivot)
+		if len(got) != len(c.expected) {
+			t.Errorf("pivot: %d, expected: %v, got: %v", c.pivot, c.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.expected[i] {
+				t.Errorf("pivot: %d, expected: %v, got: %v", c.pivot, c.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestUnionReporting(t *testing.T) {
+	a := newIntSet(1, 3, 5, 7)
+	b := newIntSet(2, 3, 4, 7, 9)
+	result, collisions := a.UnionReporting(b)
+
+	if !equalValues(result.Values(), a.Union(b).Values()) {
+		t.Errorf("expected: %v, got: %v", a.Union(b).Values(), result.Values())
+	}
+	if !equalValues(collisions, a.Inter(b).Values()) {
+		t.Errorf("expected: %v, got: %v", a.Inter(b).Values(), collisions)
+	}
+	if _, collisions := a.UnionReporting(newIntSet(2, 4)); len(collisions) != 0 {
+		t.Errorf("expected no collisions, got: %v", collisions)
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	descending := NewWith(func(a, b interface{}) int { return -utils.IntComparator(a, b) })
+	descending.Add(3, 1, 4, 5, 9, 2, 6)
+
+	set := newIntSet(100, 200)
+	set.LoadFrom(descending)
+	if !equalValues(set.Values(), []interface{}{1, 2, 3, 4, 5, 6, 9}) {
+		t.Errorf("expected ascending values, got: %v", set.Values())
+	}
+
+	set.LoadFrom(set)
+	if set.Size() != 7 {
+		t.Errorf("expected loading from itself to keep values, got: %v", set.Values())
+	}
+}
+
+func TestValuesInto(t *testing.T) {
+	set := newIntSet(3, 1, 2)
+
+	small := make([]interface{}, 1)
+	got := set.ValuesInto(small)
+	if !equalValues(got, []interface{}{1, 2, 3}) {
+		t.Errorf("expected: %v, got: %v", []int{1, 2, 3}, got)
+	}
+
+	large := make([]interface{}, 0, 10)
+	got = set.ReverseValuesInto(large)
+	if !equalValues(got, []interface{}{3, 2, 1}) {
+		t.Errorf("expected: %v, got: %v", []int{3, 2, 1}, got)
+	}
+	if &got[0] != &large[:1][0] {
+		t.Errorf("expected large buffer to be reused")
+	}
+
+	if got := NewWithIntComparator().ReverseValuesInto(large); len(got) != 0 {
+		t.Errorf("expected empty result, got: %v", got)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	byPointedID := func(a, b interface{}) int {
+		return utils.IntComparator(a.(*versioned).id, b.(*versioned).id)
+	}
+	items := []*versioned{{id: 1}, {id: 2}, {id: 3}, {id: 4}}
+	set := NewWith(byPointedID)
+	for _, item := range items {
+		set.Add(item)
+	}
+
+	items[0].id, items[3].id = 40, 10
+	set.Rebuild()
+
+	expected := []int{2, 3, 10, 40}
+	for i, v := range set.Values() {
+		if v.(*versioned).id != expected[i] {
+			t.Errorf("expected: %v, got: %v", expected[i], v.(*versioned).id)
+		}
+	}
+	if !set.Contains(&versioned{id: 40}) || set.Contains(&versioned{id: 1}) {
+		t.Errorf("expected lookups to follow the repaired order")
+	}
+}
+
+func TestGetOrAdd(t *testing.T) {
+	set := NewWith(byVersionedID)
+	first := versioned{id: 1, version: 1}
+	second := versioned{id: 1, version: 2}
+
+	actual, existed := set.GetOrAdd(first)
+	if existed || actual.(versioned) != first {
+		t.Errorf("expected: (%v, false), got: (%v, %v)", first, actual, existed)
+	}
+	actual, existed = set.GetOrAdd(second)
+	if !existed || actual.(versioned) != first {
+		t.Errorf("expected: (%v, true), got: (%v, %v)", first, actual, existed)
+	}
+	if set.Size() != 1 || set.Values()[0].(versioned) != first {
+		t.Errorf("expected the first item to be kept, got: %v", set.Values())
+	}
+}
+
+func TestNewFromSorted(t *testing.T) {
+	set := NewFromSorted(IntComparator, 1, 2, 2, 3, 5)
+	if expected := []interface{}{1, 2, 3, 5}; !equalValues(set.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, set.Values())
+	}
+
+	unsorted := NewFromSorted(IntComparator, 3, 1, 2, 1)
+	if expected := []interface{}{1, 2, 3}; !equalValues(unsorted.Values(), expected) {
+		t.Errorf("expected: %v, got: %v", expected, unsorted.Values())
+	}
+	unsorted.Add(0)
+	if rank, _ := unsorted.Rank(3); rank != 3 {
+		t.Errorf("expected: %v, got: %v", 3, rank)
+	}
+}
+
+func benchmarkSortedValues(n int) []interface{} {
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
+
+func BenchmarkNewFromSorted(b *testing.B) {
+	values := benchmarkSortedValues(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewFromSorted(IntComparator, values...)
+	}
+}
+
+func BenchmarkAddSorted(b *testing.B) {
+	values := benchmarkSortedValues(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewWithIntComparator().Add(values...)
+	}
+}
+
+func TestSetAlgebraMatchesMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		a, b := NewWithIntComparator(), NewWithIntComparator()
+		inA, inB := make(map[int]bool), make(map[int]bool)
+		for i := 0; i < rnd.Intn(100); i++ {
+			v := rnd.Intn(100)
+			a.Add(v)
+			inA[v] = true
+		}
+		for i := 0; i < rnd.Intn(100); i++ {
+			v := rnd.Intn(100)
+			b.Add(v)
+			inB[v] = true
+		}
+
+		results := map[string]*Set{
+			"Union":   a.Union(b),
+			"Inter":   a.Inter(b),
+			"Diff":    a.Diff(b),
+			"SymDiff": a.SymDiff(b),
+			"Clone":   a.Clone(),
+		}
+		inPlace := a.Clone()
+		inPlace.InPlaceInter(b)
+		results["InPlaceInter"] = inPlace
+		keep := map[string]func(inA, inB bool) bool{
+			"Union":        func(inA, inB bool) bool { return inA || inB },
+			"Inter":        func(inA, inB bool) bool { return inA && inB },
+			"Diff":         func(inA, inB bool) bool { return inA && !inB },
+			"SymDiff":      func(inA, inB bool) bool { return inA != inB },
+			"Clone":        func(inA, inB bool) bool { return inA },
+			"InPlaceInter": func(inA, inB bool) bool { return inA && inB },
+		}
+		for name, result := range results {
+			var expected []interface{}
+			for v := 0; v < 100; v++ {
+				if keep[name](inA[v], inB[v]) {
+					expected = append(expected, v)
+				}
+			}
+			if !equalValues(result.Values(), expected) {
+				t.Fatalf("%s, expected: %v, got: %v", name, expected, result.Values())
+			}
+			checkRBTree(t, result.tree.Root)
+		}
+	}
+}
+
+func BenchmarkInter(b *testing.B) {
+	x, y := benchmarkSet(100000), benchmarkSet(50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Inter(y)
+	}
+}
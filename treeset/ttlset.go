@@ -0,0 +1,120 @@
+package treeset
+
+import (
+	"time"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+// TTLSet is an ordered set that records the insertion time of every item,
+// so that stale items can be expired in bulk (e.g. sliding-window dedup caches).
+// Items are kept in comparator order; a parallel time-ordered index makes
+// expiry proportional to the number of expired items.
+// Structure is not thread safe.
+type TTLSet struct {
+	set    *Set // item -> insertion time
+	byTime *Set // ttlEntry ordered by insertion time
+}
+
+type ttlEntry struct {
+	item interface{}
+	at   time.Time
+}
+
+// Instantiates a new empty TTL set with the custom comparator.
+func NewTTLSetWith(comparator utils.Comparator) *TTLSet {
+	byTime := func(a, b interface{}) int {
+		x, y := a.(ttlEntry), b.(ttlEntry)
+		switch {
+		case x.at.Before(y.at):
+			return -1
+		case x.at.After(y.at):
+			return 1
+		default:
+			return comparator(x.item, y.item)
+		}
+	}
+	return &TTLSet{set: NewWith(comparator), byTime: NewWith(byTime)}
+}
+
+// Adds the items (one or more) to the set, stamped with the current time.
+func (ts *TTLSet) Add(items ...interface{}) {
+	ts.AddAt(time.Now(), items...)
+}
+
+// Adds the items (one or more) to the set, stamped with the given time.
+// Re-adding an item that is already present refreshes its timestamp.
+func (ts *TTLSet) AddAt(at time.Time, items ...interface{}) {
+	for _, item := range items {
+		ts.remove(item)
+		ts.set.tree.Put(item, at)
+		ts.byTime.Add(ttlEntry{item: item, at: at})
+	}
+}
+
+// Removes the items (one or more) from the set.
+func (ts *TTLSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		ts.remove(item)
+	}
+}
+
+func (ts *TTLSet) remove(item interface{}) {
+	at, found := ts.set.tree.Get(item)
+	if !found {
+		return
+	}
+	ts.byTime.Remove(ttlEntry{item: item, at: at.(time.Time)})
+	ts.set.tree.Remove(item)
+}
+
+// Removes and returns all items inserted before olderThan, oldest first.
+func (ts *TTLSet) Expire(olderThan time.Time) []interface{} {
+	var expired []interface{}
+	for node := leftmost(ts.byTime.tree); node != nil; node = successor(node) {
+		entry := node.Key.(ttlEntry)
+		if !entry.at.Before(olderThan) {
+			break
+		}
+		expired = append(expired, entry.item)
+	}
+	for _, item := range expired {
+		ts.remove(item)
+	}
+	return expired
+}
+
+// Returns the insertion time of item and whether it is present in the set.
+func (ts *TTLSet) InsertedAt(item interface{}) (time.Time, bool) {
+	at, found := ts.set.tree.Get(item)
+	if !found {
+		return time.Time{}, false
+	}
+	return at.(time.Time), true
+}
+
+// Check wether items (one or more) are present in the set.
+func (ts *TTLSet) Contains(items ...interface{}) bool {
+	return ts.set.Contains(items...)
+}
+
+// Returns true if set does not contain any elements.
+func (ts *TTLSet) Empty() bool {
+	return ts.set.Empty()
+}
+
+// Returns number of elements within the set.
+func (ts *TTLSet) Size() int {
+	return ts.set.Size()
+}
+
+// Clears all values in the set.
+func (ts *TTLSet) Clear() {
+	ts.set.Clear()
+	ts.byTime.Clear()
+}
+
+// Returns all items in the set in comparator order.
+func (ts *TTLSet) Values() []interface{} {
+	return ts.set.Values()
+}
@@ -0,0 +1,58 @@
+package treeset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestTTLSetExpire(t *testing.T) {
+	base := time.Unix(1473000000, 0)
+	set := NewTTLSetWith(utils.IntComparator)
+	set.AddAt(base, 5, 1)
+	set.AddAt(base.Add(time.Minute), 3)
+	set.AddAt(base.Add(2*time.Minute), 4, 2)
+
+	expired := set.Expire(base.Add(90 * time.Second))
+	if len(expired) != 3 || expired[0] != 1 || expired[1] != 5 || expired[2] != 3 {
+		t.Errorf("expected: %v, got: %v", []int{1, 5, 3}, expired)
+	}
+	if set.Size() != 2 || !set.Contains(2, 4) || set.Contains(1, 3, 5) {
+		t.Errorf("unexpected values left: %v", set.Values())
+	}
+}
+
+func TestTTLSetExpireNoneAndAll(t *testing.T) {
+	base := time.Unix(1473000000, 0)
+	set := NewTTLSetWith(utils.IntComparator)
+	set.AddAt(base, 1, 2, 3)
+
+	if expired := set.Expire(base); len(expired) != 0 {
+		t.Errorf("expected nothing expired, got: %v", expired)
+	}
+	if set.Size() != 3 {
+		t.Errorf("expected: %d, got: %d", 3, set.Size())
+	}
+
+	if expired := set.Expire(base.Add(time.Second)); len(expired) != 3 {
+		t.Errorf("expected all expired, got: %v", expired)
+	}
+	if !set.Empty() {
+		t.Errorf("expected empty set, got: %v", set.Values())
+	}
+}
+
+func TestTTLSetRefresh(t *testing.T) {
+	base := time.Unix(1473000000, 0)
+	set := NewTTLSetWith(utils.IntComparator)
+	set.AddAt(base, 1)
+	set.AddAt(base.Add(time.Hour), 1)
+
+	if expired := set.Expire(base.Add(time.Minute)); len(expired) != 0 {
+		t.Errorf("expected refreshed item to survive, got: %v", expired)
+	}
+	if at, _ := set.InsertedAt(1); !at.Equal(base.Add(time.Hour)) {
+		t.Errorf("expected: %v, got: %v", base.Add(time.Hour), at)
+	}
+}
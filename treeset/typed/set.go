@@ -0,0 +1,176 @@
+//go:build go1.18
+// +build go1.18
+
+// Package typed is a type-parameterized variant of treeset: an ordered set
+// backed by a red-black tree whose items are stored as T rather than
+// interface{}, so adding an int64 does not allocate and mixing item types
+// is a compile error instead of a comparator panic.
+// Structure is not thread safe.
+package typed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comparator orders two items: negative if a < b, zero if equal, positive if a > b.
+type Comparator[T any] func(a, b T) int
+
+// Ordered is satisfied by the built-in types with a natural order.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// Compare orders two values of an Ordered type naturally.
+func Compare[T Ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type Set[T any] struct {
+	tree *tree[T]
+}
+
+// Instantiates a new empty set with the custom comparator.
+func NewWith[T any](comparator Comparator[T]) *Set[T] {
+	return &Set[T]{tree: &tree[T]{comparator: comparator}}
+}
+
+// Instantiates a new empty set ordered naturally, e.g. Set[int64] or Set[string].
+func NewOrdered[T Ordered]() *Set[T] {
+	return NewWith[T](Compare[T])
+}
+
+func (set *Set[T]) newEmpty() *Set[T] {
+	return NewWith[T](set.tree.comparator)
+}
+
+func (set *Set[T]) Clone() *Set[T] {
+	newSet := set.newEmpty()
+	newSet.Add(set.Values()...)
+	return newSet
+}
+
+func (set *Set[T]) Union(otherSet *Set[T]) *Set[T] {
+	newSet := set.Clone()
+	newSet.InPlaceUnion(otherSet)
+	return newSet
+}
+
+func (set *Set[T]) InPlaceUnion(otherSet *Set[T]) {
+	for n := otherSet.tree.leftmost(); n != nil; n = n.successor() {
+		set.tree.put(n.key)
+	}
+}
+
+func (set *Set[T]) Diff(otherSet *Set[T]) *Set[T] {
+	newSet := set.newEmpty()
+	for n := set.tree.leftmost(); n != nil; n = n.successor() {
+		if otherSet.tree.lookup(n.key) == nil {
+			newSet.tree.put(n.key)
+		}
+	}
+	return newSet
+}
+
+func (set *Set[T]) InPlaceDiff(otherSet *Set[T]) {
+	for n := otherSet.tree.leftmost(); n != nil; n = n.successor() {
+		set.tree.remove(n.key)
+	}
+}
+
+func (set *Set[T]) Inter(otherSet *Set[T]) *Set[T] {
+	newSet := set.newEmpty()
+	i, j := set.tree.leftmost(), otherSet.tree.leftmost()
+	for i != nil && j != nil {
+		compare := set.tree.comparator(i.key, j.key)
+		switch {
+		case compare == 0:
+			newSet.tree.put(i.key)
+			i = i.successor()
+			j = j.successor()
+		case compare < 0:
+			i = i.successor()
+		case compare > 0:
+			j = j.successor()
+		}
+	}
+	return newSet
+}
+
+func (set *Set[T]) InPlaceInter(otherSet *Set[T]) {
+	set.tree = set.Inter(otherSet).tree
+}
+
+// Adds the items (one or more) to the set.
+func (set *Set[T]) Add(items ...T) {
+	for _, item := range items {
+		set.tree.put(item)
+	}
+}
+
+// Removes the items (one or more) from the set.
+func (set *Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		set.tree.remove(item)
+	}
+}
+
+// Check wether items (one or more) are present in the set.
+// Returns true if no arguments are passed at all.
+func (set *Set[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if set.tree.lookup(item) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Calls f for every item in ascending order.
+func (set *Set[T]) Each(f func(item T)) {
+	for n := set.tree.leftmost(); n != nil; n = n.successor() {
+		f(n.key)
+	}
+}
+
+// Returns true if set does not contain any elements.
+func (set *Set[T]) Empty() bool {
+	return set.tree.size == 0
+}
+
+// Returns number of elements within the set.
+func (set *Set[T]) Size() int {
+	return set.tree.size
+}
+
+// Clears all values in the set.
+func (set *Set[T]) Clear() {
+	set.tree.root = nil
+	set.tree.size = 0
+}
+
+// Returns all items in the set in ascending order.
+func (set *Set[T]) Values() []T {
+	values := make([]T, 0, set.tree.size)
+	set.Each(func(item T) {
+		values = append(values, item)
+	})
+	return values
+}
+
+func (set *Set[T]) String() string {
+	items := make([]string, 0, set.tree.size)
+	set.Each(func(item T) {
+		items = append(items, fmt.Sprintf("%v", item))
+	})
+	return "TreeSet\n" + strings.Join(items, ", ")
+}
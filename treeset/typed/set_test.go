@@ -0,0 +1,111 @@
+//go:build go1.18
+// +build go1.18
+
+package typed
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkTree verifies the red-black properties and parent links, returning
+// the black height of n.
+func checkTree[T any](t *testing.T, n *node[T]) int {
+	if n == nil {
+		return 1
+	}
+	if n.red && (isRed(n.left) || isRed(n.right)) {
+		t.Fatalf("red node %v has a red child", n.key)
+	}
+	if (n.left != nil && n.left.parent != n) || (n.right != nil && n.right.parent != n) {
+		t.Fatalf("broken parent link at %v", n.key)
+	}
+	left, right := checkTree(t, n.left), checkTree(t, n.right)
+	if left != right {
+		t.Fatalf("black height mismatch at %v: %d != %d", n.key, left, right)
+	}
+	if n.red {
+		return left
+	}
+	return left + 1
+}
+
+func TestSetMatchesMap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	set := NewOrdered[int64]()
+	expected := make(map[int64]bool)
+	for i := 0; i < 5000; i++ {
+		item := rnd.Int63n(500)
+		if rnd.Intn(3) == 0 {
+			set.Remove(item)
+			delete(expected, item)
+		} else {
+			set.Add(item)
+			expected[item] = true
+		}
+		if set.tree.root != nil && set.tree.root.red {
+			t.Fatalf("red root")
+		}
+		checkTree(t, set.tree.root)
+	}
+
+	keys := make([]int64, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	values := set.Values()
+	if len(values) != len(keys) || set.Size() != len(keys) {
+		t.Fatalf("expected %d items, got: %d", len(keys), len(values))
+	}
+	for i := range keys {
+		if values[i] != keys[i] {
+			t.Fatalf("expected: %v, got: %v", keys, values)
+		}
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a, b := NewOrdered[string](), NewOrdered[string]()
+	a.Add("a", "b", "c")
+	b.Add("b", "c", "d")
+
+	check := func(name string, got *Set[string], expected ...string) {
+		values := got.Values()
+		if len(values) != len(expected) {
+			t.Errorf("%s, expected: %v, got: %v", name, expected, values)
+			return
+		}
+		for i := range values {
+			if values[i] != expected[i] {
+				t.Errorf("%s, expected: %v, got: %v", name, expected, values)
+				return
+			}
+		}
+	}
+	check("Union", a.Union(b), "a", "b", "c", "d")
+	check("Inter", a.Inter(b), "b", "c")
+	check("Diff", a.Diff(b), "a")
+
+	c := a.Clone()
+	c.InPlaceInter(b)
+	check("InPlaceInter", c, "b", "c")
+	c.InPlaceDiff(b)
+	check("InPlaceDiff", c)
+	c.InPlaceUnion(a)
+	check("InPlaceUnion", c, "a", "b", "c")
+	if !a.Contains("a", "c") || a.Contains("d") {
+		t.Errorf("unexpected Contains result for: %v", a.Values())
+	}
+}
+
+func BenchmarkTypedAdd(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		set := NewOrdered[int64]()
+		for j := int64(0); j < 1000; j++ {
+			set.Add(j * 7919 % 1000)
+		}
+	}
+}
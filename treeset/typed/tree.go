@@ -0,0 +1,280 @@
+//go:build go1.18
+// +build go1.18
+
+package typed
+
+// A red-black tree over T, following the same algorithm as the rbTree
+// backing treeset.Set, without boxing keys in interface{} and without its
+// per-node subtree sizes.
+
+type node[T any] struct {
+	key    T
+	red    bool
+	left   *node[T]
+	right  *node[T]
+	parent *node[T]
+}
+
+type tree[T any] struct {
+	root       *node[T]
+	size       int
+	comparator Comparator[T]
+}
+
+func isRed[T any](n *node[T]) bool {
+	return n != nil && n.red
+}
+
+func (n *node[T]) grandparent() *node[T] {
+	if n != nil && n.parent != nil {
+		return n.parent.parent
+	}
+	return nil
+}
+
+func (n *node[T]) uncle() *node[T] {
+	g := n.grandparent()
+	if g == nil {
+		return nil
+	}
+	if n.parent == g.left {
+		return g.right
+	}
+	return g.left
+}
+
+func (n *node[T]) sibling() *node[T] {
+	if n == nil || n.parent == nil {
+		return nil
+	}
+	if n == n.parent.left {
+		return n.parent.right
+	}
+	return n.parent.left
+}
+
+func (t *tree[T]) lookup(key T) *node[T] {
+	n := t.root
+	for n != nil {
+		compare := t.comparator(key, n.key)
+		switch {
+		case compare == 0:
+			return n
+		case compare < 0:
+			n = n.left
+		default:
+			n = n.right
+		}
+	}
+	return nil
+}
+
+// put inserts key, keeping the stored key if an equal one is already present.
+// Returns whether key was inserted.
+func (t *tree[T]) put(key T) bool {
+	inserted := &node[T]{key: key, red: true}
+	if t.root == nil {
+		t.root = inserted
+	} else {
+		n := t.root
+		for {
+			compare := t.comparator(key, n.key)
+			if compare == 0 {
+				return false
+			}
+			if compare < 0 {
+				if n.left == nil {
+					n.left = inserted
+					break
+				}
+				n = n.left
+			} else {
+				if n.right == nil {
+					n.right = inserted
+					break
+				}
+				n = n.right
+			}
+		}
+		inserted.parent = n
+	}
+	t.insertFixup(inserted)
+	t.size++
+	return true
+}
+
+func (t *tree[T]) insertFixup(n *node[T]) {
+	for {
+		if n.parent == nil {
+			n.red = false
+			return
+		}
+		if !n.parent.red {
+			return
+		}
+		if u := n.uncle(); isRed(u) {
+			n.parent.red = false
+			u.red = false
+			g := n.grandparent()
+			g.red = true
+			n = g
+			continue
+		}
+		g := n.grandparent()
+		if n == n.parent.right && n.parent == g.left {
+			t.rotateLeft(n.parent)
+			n = n.left
+		} else if n == n.parent.left && n.parent == g.right {
+			t.rotateRight(n.parent)
+			n = n.right
+		}
+		n.parent.red = false
+		g = n.grandparent()
+		g.red = true
+		if n == n.parent.left && n.parent == g.left {
+			t.rotateRight(g)
+		} else {
+			t.rotateLeft(g)
+		}
+		return
+	}
+}
+
+// remove deletes the key equal to key. Returns whether one was present.
+func (t *tree[T]) remove(key T) bool {
+	n := t.lookup(key)
+	if n == nil {
+		return false
+	}
+	if n.left != nil && n.right != nil {
+		pred := n.left
+		for pred.right != nil {
+			pred = pred.right
+		}
+		n.key = pred.key
+		n = pred
+	}
+	child := n.left
+	if child == nil {
+		child = n.right
+	}
+	if !n.red {
+		n.red = isRed(child)
+		t.deleteFixup(n)
+	}
+	t.replace(n, child)
+	if n.parent == nil && child != nil {
+		child.red = false
+	}
+	t.size--
+	return true
+}
+
+func (t *tree[T]) deleteFixup(n *node[T]) {
+	for {
+		if n.parent == nil {
+			return
+		}
+		s := n.sibling()
+		if isRed(s) {
+			n.parent.red = true
+			s.red = false
+			if n == n.parent.left {
+				t.rotateLeft(n.parent)
+			} else {
+				t.rotateRight(n.parent)
+			}
+			s = n.sibling()
+		}
+		if !n.parent.red && !isRed(s) && !isRed(s.left) && !isRed(s.right) {
+			s.red = true
+			n = n.parent
+			continue
+		}
+		if n.parent.red && !isRed(s) && !isRed(s.left) && !isRed(s.right) {
+			s.red = true
+			n.parent.red = false
+			return
+		}
+		if n == n.parent.left && !isRed(s) && isRed(s.left) && !isRed(s.right) {
+			s.red = true
+			s.left.red = false
+			t.rotateRight(s)
+		} else if n == n.parent.right && !isRed(s) && isRed(s.right) && !isRed(s.left) {
+			s.red = true
+			s.right.red = false
+			t.rotateLeft(s)
+		}
+		s = n.sibling()
+		s.red = n.parent.red
+		n.parent.red = false
+		if n == n.parent.left {
+			s.right.red = false
+			t.rotateLeft(n.parent)
+		} else {
+			s.left.red = false
+			t.rotateRight(n.parent)
+		}
+		return
+	}
+}
+
+func (t *tree[T]) rotateLeft(n *node[T]) {
+	r := n.right
+	t.replace(n, r)
+	n.right = r.left
+	if r.left != nil {
+		r.left.parent = n
+	}
+	r.left = n
+	n.parent = r
+}
+
+func (t *tree[T]) rotateRight(n *node[T]) {
+	l := n.left
+	t.replace(n, l)
+	n.left = l.right
+	if l.right != nil {
+		l.right.parent = n
+	}
+	l.right = n
+	n.parent = l
+}
+
+func (t *tree[T]) replace(old, new *node[T]) {
+	if old.parent == nil {
+		t.root = new
+	} else if old == old.parent.left {
+		old.parent.left = new
+	} else {
+		old.parent.right = new
+	}
+	if new != nil {
+		new.parent = old.parent
+	}
+}
+
+func (t *tree[T]) leftmost() *node[T] {
+	n := t.root
+	if n == nil {
+		return nil
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+func (n *node[T]) successor() *node[T] {
+	if n.right != nil {
+		n = n.right
+		for n.left != nil {
+			n = n.left
+		}
+		return n
+	}
+	for n.parent != nil && n == n.parent.right {
+		n = n.parent
+	}
+	return n.parent
+}
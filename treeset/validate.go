@@ -0,0 +1,81 @@
+package treeset
+
+import "fmt"
+
+// Checks the internal consistency of the set in O(n) and returns an error
+// describing the first problem found, or nil if the set is healthy:
+// the red-black invariants (black root, no red node with a red child, equal
+// black height on every path), parent links, subtree sizes, and that the
+// comparator orders every pair of neighbouring items strictly and
+// antisymmetrically and considers each item equal to itself.
+// Meant for diagnosing suspected corruption, e.g. from unsynchronized
+// concurrent writes or a comparator that changed its order.
+func (set *Set) Validate() error {
+	root := set.tree.Root
+	if root == nil {
+		return nil
+	}
+	if root.red {
+		return fmt.Errorf("treeset: root %v is red", root.Key)
+	}
+	if root.Parent != nil {
+		return fmt.Errorf("treeset: root %v has a parent", root.Key)
+	}
+	if _, err := validateNode(root); err != nil {
+		return err
+	}
+
+	var prev *rbNode
+	for node, index := leftmost(set.tree), 0; node != nil; node, index = successor(node), index+1 {
+		if set.comparator(node.Key, node.Key) != 0 {
+			return fmt.Errorf("treeset: comparator does not consider item %d (%v) equal to itself", index, node.Key)
+		}
+		if prev != nil {
+			if set.comparator(prev.Key, node.Key) >= 0 {
+				return fmt.Errorf("treeset: items %d (%v) and %d (%v) are out of order", index-1, prev.Key, index, node.Key)
+			}
+			if set.comparator(node.Key, prev.Key) <= 0 {
+				return fmt.Errorf("treeset: comparator is not antisymmetric for items %d (%v) and %d (%v)", index-1, prev.Key, index, node.Key)
+			}
+		}
+		prev = node
+	}
+	return nil
+}
+
+// validateNode checks the structure of the subtree at node and returns its
+// black height.
+func validateNode(node *rbNode) (int, error) {
+	if node == nil {
+		return 1, nil
+	}
+	for _, child := range []*rbNode{node.Left, node.Right} {
+		if child == nil {
+			continue
+		}
+		if child.Parent != node {
+			return 0, fmt.Errorf("treeset: child %v of %v has a broken parent link", child.Key, node.Key)
+		}
+		if node.red && child.red {
+			return 0, fmt.Errorf("treeset: red node %v has a red child %v", node.Key, child.Key)
+		}
+	}
+	if node.size != sizeOf(node.Left)+sizeOf(node.Right)+1 {
+		return 0, fmt.Errorf("treeset: node %v records size %d, expected %d", node.Key, node.size, sizeOf(node.Left)+sizeOf(node.Right)+1)
+	}
+	left, err := validateNode(node.Left)
+	if err != nil {
+		return 0, err
+	}
+	right, err := validateNode(node.Right)
+	if err != nil {
+		return 0, err
+	}
+	if left != right {
+		return 0, fmt.Errorf("treeset: black heights of the subtrees of %v differ: %d != %d", node.Key, left, right)
+	}
+	if node.red {
+		return left, nil
+	}
+	return left + 1, nil
+}
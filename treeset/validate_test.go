@@ -0,0 +1,58 @@
+package treeset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emirpasic/gods/utils"
+)
+
+func TestValidateHealthy(t *testing.T) {
+	set := NewWithIntComparator()
+	if err := set.Validate(); err != nil {
+		t.Errorf("expected empty set to be valid, got: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		set.Add((i * 7919) % 1000)
+		if i%3 == 0 {
+			set.Remove(i)
+		}
+	}
+	if err := set.Validate(); err != nil {
+		t.Errorf("expected: %v, got: %v", nil, err)
+	}
+	if err := NewFromSorted(utils.IntComparator, 1, 2, 3, 4, 5).Validate(); err != nil {
+		t.Errorf("expected bulk loaded set to be valid, got: %v", err)
+	}
+	if err := NewSyncSet(set).Validate(); err != nil {
+		t.Errorf("expected: %v, got: %v", nil, err)
+	}
+}
+
+func TestValidateCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(set *Set)
+		problem string
+	}{
+		{"red root", func(set *Set) { set.tree.Root.red = true }, "root"},
+		{"parent link", func(set *Set) { set.tree.Root.Left.Parent = nil }, "parent link"},
+		{"size", func(set *Set) { set.tree.Root.size++ }, "size"},
+		{"order", func(set *Set) { set.tree.Root.Key = -1 }, "out of order"},
+		{"black height", func(set *Set) {
+			leaf := leftmost(set.tree)
+			leaf.red = !leaf.red
+		}, ""},
+		{"comparator", func(set *Set) {
+			set.comparator = func(a, b interface{}) int { return 1 }
+		}, "equal to itself"},
+	}
+	for _, test := range tests {
+		set := newIntSet(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+		test.corrupt(set)
+		err := set.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("%s, expected an error about %q, got: %v", test.name, test.problem, err)
+		}
+	}
+}
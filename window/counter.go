@@ -0,0 +1,190 @@
+// Package window counts events over sliding time windows, e.g. posts in the
+// last 10 minutes per author, in constant memory per counter.
+package window
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+const binaryVersion = 1
+
+var (
+	ErrMismatch = errors.New("window counters differ in bucket width or count")
+	ErrCorrupt  = errors.New("corrupt window counter encoding")
+)
+
+// Counter sums events into fixed-width time buckets and keeps the most
+// recent ones, so that counts are exact to within one bucket width for any
+// window up to width times the number of buckets. Events older than that
+// are forgotten.
+// Structure is not thread safe.
+type Counter struct {
+	width   time.Duration
+	buckets []float64 // ring indexed by bucket number modulo its length
+	head    int64     // number of the newest bucket, counted from the epoch
+}
+
+// Instantiates a new empty counter of n buckets, each width long, e.g. 60
+// buckets of 10 seconds to count over up to 10 minutes.
+func New(width time.Duration, n int) *Counter {
+	if width <= 0 || n < 1 {
+		panic("window: width and bucket count must be positive")
+	}
+	return &Counter{width: width, buckets: make([]float64, n), head: math.MinInt64}
+}
+
+func (c *Counter) bucket(now time.Time) int64 {
+	return now.UnixNano() / int64(c.width)
+}
+
+func (c *Counter) slot(bucket int64) int {
+	n := int64(len(c.buckets))
+	return int(((bucket % n) + n) % n)
+}
+
+// advance moves the head to bucket, zeroing the buckets it passes over.
+func (c *Counter) advance(bucket int64) {
+	if bucket <= c.head {
+		return
+	}
+	if c.head == math.MinInt64 || bucket-c.head >= int64(len(c.buckets)) {
+		for i := range c.buckets {
+			c.buckets[i] = 0
+		}
+	} else {
+		for b := c.head + 1; b <= bucket; b++ {
+			c.buckets[c.slot(b)] = 0
+		}
+	}
+	c.head = bucket
+}
+
+// Counts one event at now.
+func (c *Counter) Incr(now time.Time) {
+	c.IncrBy(now, 1)
+}
+
+// Counts delta events at now. Events that fall behind the oldest bucket kept
+// are dropped.
+func (c *Counter) IncrBy(now time.Time, delta float64) {
+	bucket := c.bucket(now)
+	c.advance(bucket)
+	if c.head-bucket < int64(len(c.buckets)) {
+		c.buckets[c.slot(bucket)] += delta
+	}
+}
+
+// Returns the number of events in the window ending at now, that is in the
+// bucket of now and the ones before it, window/width buckets in all (rounded
+// up). Windows longer than the counter's span are truncated to it.
+func (c *Counter) Count(now time.Time, window time.Duration) float64 {
+	return c.sum(now, window, 1)
+}
+
+// Returns the events in the window ending at now as Count does, but with
+// each bucket's count weighted by factor to the power of its age in buckets,
+// so that recent events weigh more (e.g. a factor of 0.9).
+func (c *Counter) Decayed(now time.Time, window time.Duration, factor float64) float64 {
+	return c.sum(now, window, factor)
+}
+
+func (c *Counter) sum(now time.Time, window time.Duration, factor float64) float64 {
+	if c.head == math.MinInt64 {
+		return 0
+	}
+	newest := c.bucket(now)
+	oldest := newest - int64((window+c.width-1)/c.width) + 1
+	if span := newest - int64(len(c.buckets)) + 1; oldest < span {
+		oldest = span
+	}
+	if newest > c.head {
+		newest = c.head
+	}
+	total, weight := 0.0, math.Pow(factor, float64(c.bucket(now)-newest))
+	for b := newest; b >= oldest && c.head-b < int64(len(c.buckets)); b-- {
+		total += weight * c.buckets[c.slot(b)]
+		weight *= factor
+	}
+	return total
+}
+
+// Adds the counts of other, which must have the same bucket width and
+// count, so that c counts the events of both, e.g. to combine per-shard
+// counters.
+func (c *Counter) Merge(other *Counter) error {
+	if c.width != other.width || len(c.buckets) != len(other.buckets) {
+		return ErrMismatch
+	}
+	if other.head == math.MinInt64 {
+		return nil
+	}
+	c.advance(other.head)
+	for b := other.head; c.head-b < int64(len(c.buckets)) && other.head-b < int64(len(other.buckets)); b-- {
+		c.buckets[c.slot(b)] += other.buckets[other.slot(b)]
+	}
+	return nil
+}
+
+// Clears all counts.
+func (c *Counter) Clear() {
+	for i := range c.buckets {
+		c.buckets[i] = 0
+	}
+	c.head = math.MinInt64
+}
+
+// Implements encoding.BinaryMarshaler. The encoding is a version byte, the
+// bucket width in nanoseconds and the bucket count as uvarints, the head as
+// a varint, then the bucket counts oldest first as little-endian float64s.
+func (c *Counter) MarshalBinary() ([]byte, error) {
+	data := []byte{binaryVersion}
+	var buf [binary.MaxVarintLen64]byte
+	data = append(data, buf[:binary.PutUvarint(buf[:], uint64(c.width))]...)
+	data = append(data, buf[:binary.PutUvarint(buf[:], uint64(len(c.buckets)))]...)
+	data = append(data, buf[:binary.PutVarint(buf[:], c.head)]...)
+	for i := range c.buckets {
+		var word [8]byte
+		binary.LittleEndian.PutUint64(word[:], math.Float64bits(c.buckets[c.oldest(i)]))
+		data = append(data, word[:]...)
+	}
+	return data, nil
+}
+
+// Implements encoding.BinaryUnmarshaler, replacing the contents of c.
+func (c *Counter) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return ErrCorrupt
+	}
+	data = data[1:]
+	width, n := binary.Uvarint(data)
+	if n <= 0 || width == 0 || width > math.MaxInt64 {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count == 0 {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	head, n := binary.Varint(data)
+	// Bound count by the data before multiplying, which would overflow.
+	if n <= 0 || count > uint64(len(data)-n)/8 || uint64(len(data)-n) != 8*count {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	restored := &Counter{width: time.Duration(width), buckets: make([]float64, count), head: head}
+	for i := range restored.buckets {
+		restored.buckets[restored.oldest(i)] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	*c = *restored
+	return nil
+}
+
+// oldest returns the slot of the i-th bucket kept, counting from the oldest.
+// It wraps around harmlessly for an empty counter, whose buckets are all zero.
+func (c *Counter) oldest(i int) int {
+	return c.slot(c.head - int64(len(c.buckets)-1-i))
+}
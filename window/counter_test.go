@@ -0,0 +1,123 @@
+package window
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+var base = time.Unix(1473000000, 0)
+
+func TestCount(t *testing.T) {
+	c := New(time.Minute, 10)
+	for i := 0; i < 20; i++ {
+		c.Incr(base.Add(time.Duration(i) * time.Minute))
+	}
+	now := base.Add(19 * time.Minute)
+
+	tests := []struct {
+		window   time.Duration
+		expected float64
+	}{
+		{time.Minute, 1},
+		{5 * time.Minute, 5},
+		{10 * time.Minute, 10},
+		{time.Hour, 10}, // truncated to the span kept
+	}
+	for _, test := range tests {
+		if actual := c.Count(now, test.window); actual != test.expected {
+			t.Errorf("%v, expected: %v, got: %v", test.window, test.expected, actual)
+		}
+	}
+	if actual := c.Count(now.Add(3*time.Minute), 5*time.Minute); actual != 2 {
+		t.Errorf("expected: %v, got: %v", 2, actual)
+	}
+	if actual := c.Count(now.Add(time.Hour), time.Hour); actual != 0 {
+		t.Errorf("expected: %v, got: %v", 0, actual)
+	}
+}
+
+func TestLateEvents(t *testing.T) {
+	c := New(time.Minute, 5)
+	c.Incr(base.Add(10 * time.Minute))
+	c.Incr(base.Add(8 * time.Minute)) // late but kept
+	c.Incr(base)                      // too old
+	if actual := c.Count(base.Add(10*time.Minute), 5*time.Minute); actual != 2 {
+		t.Errorf("expected: %v, got: %v", 2, actual)
+	}
+}
+
+func TestDecayed(t *testing.T) {
+	c := New(time.Minute, 10)
+	c.IncrBy(base, 8)
+	c.IncrBy(base.Add(time.Minute), 8)
+	if actual := c.Decayed(base.Add(time.Minute), 10*time.Minute, 0.5); actual != 12 {
+		t.Errorf("expected: %v, got: %v", 12, actual)
+	}
+	if actual := c.Decayed(base.Add(2*time.Minute), 10*time.Minute, 0.5); actual != 6 {
+		t.Errorf("expected: %v, got: %v", 6, actual)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(time.Minute, 5), New(time.Minute, 5)
+	a.Incr(base)
+	a.Incr(base.Add(3 * time.Minute))
+	b.Incr(base.Add(2 * time.Minute))
+	b.Incr(base.Add(6 * time.Minute))
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	// The event at base fell out of the span when b advanced a.
+	if actual := a.Count(base.Add(6*time.Minute), time.Hour); actual != 3 {
+		t.Errorf("expected: %v, got: %v", 3, actual)
+	}
+	if err := a.Merge(New(time.Second, 5)); err != ErrMismatch {
+		t.Errorf("expected: %v, got: %v", ErrMismatch, err)
+	}
+	if err := a.Merge(New(time.Minute, 5)); err != nil {
+		t.Errorf("expected merging an empty counter to succeed, got: %v", err)
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	c := New(time.Minute, 7)
+	for i := 0; i < 3; i++ {
+		c.IncrBy(base.Add(time.Duration(i)*time.Minute), float64(i+1))
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := New(time.Second, 1)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	now := base.Add(2 * time.Minute)
+	if actual := restored.Count(now, time.Hour); actual != 6 {
+		t.Errorf("expected: %v, got: %v", 6, actual)
+	}
+	if actual := restored.Count(now, time.Minute); actual != 3 {
+		t.Errorf("expected: %v, got: %v", 3, actual)
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+	// A huge bucket count would overflow the expected data length to 0.
+	var count [binary.MaxVarintLen64]byte
+	huge := append([]byte{binaryVersion, 1}, count[:binary.PutUvarint(count[:], 1<<61)]...)
+	huge = append(huge, 0)
+	if err := restored.UnmarshalBinary(huge); err != ErrCorrupt {
+		t.Errorf("expected: %v, got: %v", ErrCorrupt, err)
+	}
+
+	empty := New(time.Minute, 3)
+	data, _ = empty.MarshalBinary()
+	if err := restored.UnmarshalBinary(data); err != nil || restored.Count(base, time.Hour) != 0 {
+		t.Errorf("expected an empty counter, got: %v", err)
+	}
+	restored.Incr(base)
+	if actual := restored.Count(base, time.Hour); actual != 1 {
+		t.Errorf("expected: %v, got: %v", 1, actual)
+	}
+}